@@ -67,14 +67,20 @@ Usage:
 
 Usage:
 
-	librarian add <api>
+	librarian add [--library <name>] <api>...
 
-add registers a single API in librarian.yaml.
+add registers a single API in librarian.yaml, or several APIs in one
+library with --library.
 
 The <api> is a path within the configured googleapis source, such as
 "google/cloud/secretmanager/v1". The library name and other defaults are
 derived from the first API path using language-specific rules.
 
+Unless the API is configured in librarian's built-in API list, its directory
+in googleapis must contain a service config, a YAML file with
+"type: google.api.Service". add fails before changing librarian.yaml if it
+is missing.
+
 If the API path should naturally be included in an existing library, and if the
 language supports doing so, that library is modified. Otherwise, a new library
 is created.
@@ -86,16 +92,41 @@ library.
 To add a preview client of an existing library, prefix the API path with
 "preview/".
 
+With --library, all the given APIs are added to the named library, which is
+created if it does not exist. This is used for libraries that aggregate
+several API versions. Adding APIs to an existing library is only supported
+by languages whose libraries may contain several APIs.
+
+New libraries record the current year as their copyright year, which is
+used in the license headers of generated files. The --copyright-year flag
+records another year instead, for example when onboarding a library whose
+code already exists. The year of existing libraries is never changed.
+
+With --api-path-file, the APIs listed in the file are added one at a time,
+as if add was run for each of them. Each line holds an API path, optionally
+followed by a library name to add it to, as with --library. Blank lines and
+lines starting with "#" are ignored. An API that cannot be added does not
+stop the others; a summary of the added and failed APIs is printed at the
+end, and the command fails if any API could not be added.
+
 Examples:
 
 	librarian add google/cloud/secretmanager/v1
 	librarian add preview/google/cloud/secretmanager/v1beta
+	librarian add --library secretmanager google/cloud/secretmanager/v1 google/cloud/secretmanager/v1beta2
+	librarian add --api-path-file new-apis.txt
 
 A typical librarian workflow for adding a new client library is:
 
 	librarian add <api>            # onboard a new API into librarian.yaml
 	librarian generate <library>   # generate the client library
 
+Flags:
+
+	--library name         add the APIs to the library name, creating it if needed
+	--api-path-file file   add each API listed in this file, with an optional library name
+	--copyright-year year  copyright year of new libraries; defaults to the current year
+
 # Generate a client library
 
 Usage:
@@ -106,20 +137,139 @@ generate produces client library code from the APIs configured in
 librarian.yaml.
 
 The library name argument selects a single library to regenerate. Use the
---all flag to regenerate every library in the workspace instead. Exactly
-one of <library> or --all must be provided.
+--all flag to regenerate every library in the workspace instead, or the
+--library-filter flag to regenerate every library whose name matches a
+regular expression. Exactly one of <library>, --all or --library-filter
+must be provided.
+
+The --exclude-library flag skips the named library when generating with
+--all or --library-filter, for example to work around a library that is
+temporarily broken. It can be repeated, and each name must match a library
+in librarian.yaml exactly. Unlike skip_generate, it only affects a single
+run.
 
 Generation is delegated to the language-specific tooling configured in
 librarian.yaml. Libraries marked with skip_generate are skipped.
 
+The --dry-run flag prints the libraries that would be generated, with their
+APIs and the files that cleaning would remove, and changes nothing. The
+googleapis checkout is not updated by --update-source in a dry run.
+
+The output directories of the libraries are copied to a temporary directory
+before they are cleaned. If cleaning or generation fails, they are restored
+from that copy, so a failed run does not leave half-cleaned libraries behind.
+The copy costs as much disk space and I/O as the outputs themselves, which is
+significant when generating every library of a large repository. The
+--no-rollback flag skips it when another mechanism, such as git, is used to
+discard a failed run. Without the copy, a failed run leaves the libraries as
+they are, and noop_ignore_patterns are not applied.
+
+After a library is generated, the shell commands in post_generate_hooks, of
+the default section and then of the library, are run with sh -c in its
+output directory, with LIBRARIAN_LIBRARY and LIBRARIAN_VERSION set to the
+name and version of the library. A failing hook fails generation like a
+failing generator, and the library is rolled back.
+
+Libraries with noop_ignore_patterns in librarian.yaml are left unchanged if
+the only lines changed by generation match those patterns, such as a
+generation date stamped into each file. No provenance file is written for
+them.
+
+The --since flag skips libraries whose APIs have not changed since the
+given commit. It requires sources.googleapis.dir to point at a git
+checkout of googleapis, and the commit must be in the history of that
+checkout.
+
+The --api-source flag generates from a local googleapis directory instead of
+the source configured in librarian.yaml, which is left unchanged. The
+directory is treated as a read-only source tree and does not need to be a
+git repository, which is useful when iterating on unmerged proto changes.
+No source commit is recorded in the --summary-file for such runs. The flag
+also accepts a path or URL ending in .tar.gz or .tgz, such as a pinned
+googleapis archive from GitHub, which is extracted to a temporary directory
+and used the same way. As with GitHub archives, the files in the tarball must
+be wrapped in a single top-level directory.
+
+The --update-source flag fetches the upstream branch of a local googleapis
+checkout, set with sources.googleapis.dir or --api-source, and fast-forwards
+it before generating, so that a stale checkout does not silently produce
+output from old protos. The checkout must be a git repository with a clean
+working tree, on a branch that tracks a remote branch. Generate fails
+without updating it if the branch has diverged from its upstream branch.
+
+The --fail-on-no-changes flag makes generate fail if the git working tree is
+clean after generation, which lets scheduled CI jobs detect that a
+regeneration changed nothing.
+
+The --checkpoint-file flag records the libraries that were generated
+successfully, as the run progresses. Rerunning with --resume skips those
+libraries, so an interrupted run over many libraries does not start from
+scratch. Completed libraries are kept if the run fails. The checkpoint is
+ignored if it was written for another googleapis commit, or when the
+commit is unknown, such as with --api-source.
+
+The --provenance-dir flag writes a <library>.json file for each generated
+library to the given directory, recording the googleapis commit, the
+librarian version and the time of the run. Pointing it at a directory in
+the repository, such as .librarian/provenance, keeps the provenance of each
+library next to its code. Libraries that are rolled back after a failure
+keep their previous provenance file.
+
+The --summary-file flag writes a JSON summary of the run, with the time spent
+on each library and its status: generated, unchanged when its changes were
+reverted as no-op, checkpointed when it was kept by --checkpoint-file after a
+failure, failed, with its own error and the exit status of the failed
+command, rolled-back when another library failed, incomplete when another
+library failed with --no-rollback, or not-attempted when the run failed
+before reaching it.
+
+The --metrics-pushgateway flag pushes metrics about the run to a Prometheus
+Pushgateway, as the librarian_generate job: the number of libraries
+generated, unchanged, failed and skipped, the time spent on each library,
+the duration of the run, and whether it succeeded.
+Pushing is best effort: a failure is logged and does not fail generate.
+
+The --no-clean flag skips cleaning the output directories of the libraries
+before generating them. Generated files are added or overwritten, and
+every other file is left in place, including files that cleaning would
+delete because they are not listed in keep. Stale generated files are also
+left behind, so this is only intended for migrations, and a warning is
+logged when it is used.
+
+The --patch-file flag writes the changes made by a successful run to the
+given file, as a unified diff of the git working tree against HEAD that
+includes new and deleted files but not files ignored by .gitignore. The
+patch can be kept as a CI artifact for review, and applied elsewhere with
+git apply.
+
 Examples:
 
-	librarian generate <library>   # regenerate one library
-	librarian generate --all       # regenerate every library
+	librarian generate <library>         # regenerate one library
+	librarian generate --all             # regenerate every library
+	librarian generate --all --dry-run   # list libraries without generating
+	librarian generate --library-filter='^google-cloud-bigtable-'
+	librarian generate --api-source=../googleapis <library>
+	librarian generate --all --checkpoint-file=generate.json --resume
 
 Flags:
 
-	--all       generate all libraries
+	--all                                              generate all libraries
+	--dry-run                                          print the libraries that would be generated without generating them
+	--library-filter string                            generate all libraries whose name matches this regular expression
+	--exclude-library name [ --exclude-library name ]  skip the library name when generating with --all or --library-filter; can be repeated
+	--api-source DIR                                   generate from this local googleapis DIR, which need not be a git repository, or from a .tar.gz file or URL
+	--update-source                                    fast-forward the local googleapis checkout to its upstream branch before generating
+	--since string                                     only generate libraries whose APIs changed since this googleapis commit
+	--summary-file string                              write a JSON summary of the run to this path
+	--max-concurrency int                              maximum number of libraries to generate concurrently; 0 uses the number of CPUs (default: 0)
+	--fail-on-no-changes                               fail if the git working tree is clean after generation
+	--checkpoint-file FILE                             record the libraries completed by the run in this FILE
+	--resume                                           skip libraries recorded as completed in --checkpoint-file
+	--provenance-dir DIR                               write a provenance file for each generated library to this DIR
+	--metrics-pushgateway URL                          push metrics to the Prometheus Pushgateway at this URL
+	--no-clean                                         do not delete existing files in library outputs before generating
+	--no-rollback                                      do not copy library outputs to restore them if generation fails
+	--patch-file FILE                                  write a unified diff of the changes made by generation to this FILE
 
 A typical librarian workflow for regenerating every library against the
 latest API definitions is:
@@ -158,6 +308,41 @@ with a canonical formatting.
 Run tidy after editing librarian.yaml by hand, or as a quick check that
 the configuration is well-formed.
 
+# Validate librarian.yaml without modifying it
+
+Usage:
+
+	librarian validate
+
+validate reads librarian.yaml and reports every problem it finds,
+without modifying any files. It runs the same checks as librarian tidy,
+and additionally checks that:
+
+  - source directories configured with dir exist
+  - library roots name a source configured in librarian.yaml
+  - postprocess replace_regex patterns are valid regular expressions
+  - libraries in depends_on exist and do not form a cycle
+  - default tag_format, if set, contains {name} and {version}
+
+validate exits with a non-zero status if any problem is found.
+
+# Print the JSON Schema for librarian.yaml
+
+Usage:
+
+	librarian schema
+
+schema prints a JSON Schema document describing librarian.yaml, which
+editors can use to validate and autocomplete the file.
+
+The schema is derived from the yaml struct tags of the librarian
+configuration types. Fields without omitempty are always written by
+librarian, and are therefore listed as required.
+
+Examples:
+
+	librarian schema > librarian.schema.json
+
 # Update sources or version to the latest version
 
 Usage:
@@ -179,6 +364,14 @@ Supported targets:
 
 At least one target must be specified.
 
+Sources are looked up with the GitHub API. To avoid its rate limit for
+unauthenticated requests, provide a GitHub token with one of, in order of
+precedence:
+
+  - the LIBRARIAN_GITHUB_TOKEN environment variable
+  - a file named by the LIBRARIAN_GITHUB_TOKEN_FILE environment variable
+  - the GitHub CLI, if logged in (gh auth token)
+
 Examples:
 
 	librarian update sources.googleapis
@@ -195,12 +388,24 @@ latest API definitions is:
 
 Usage:
 
-	librarian version
+	librarian version [--output-format=text|json|yaml]
 
 version prints the librarian binary version and exits. The version is
 embedded at build time and follows the conventions described at
 https://go.dev/ref/mod#versions.
 
+The --output-format flag selects json or yaml output for scripts, which
+also includes the git commit and date of the build and the Go version used.
+
+Examples:
+
+	librarian version
+	librarian version --output-format=json
+
+Flags:
+
+	--output-format format  output format: text, json or yaml (default: "text")
+
 # Various debugging commands
 
 Usage:
@@ -216,5 +421,24 @@ Usage:
 env prints the librarian interpretation of the environment it is run in.
 This includes the resolved LIBRARIAN_CACHE and LIBRARIAN_BIN paths,
 as well as the language-specific tool installation directories.
+
+# Check the local environment for common problems
+
+Usage:
+
+	librarian doctor
+
+doctor checks that the local environment can run librarian, and prints
+a checklist of the results. It checks that:
+
+  - git is installed
+  - the current directory contains a valid librarian.yaml
+  - the GitHub API is reachable, and accepts the GitHub token if one is
+    found in LIBRARIAN_GITHUB_TOKEN, LIBRARIAN_GITHUB_TOKEN_FILE or gh
+  - a container runtime (docker) is installed
+
+The GitHub API and container runtime checks are optional: their failures
+are reported as warnings. doctor exits with a non-zero status if any
+required check fails.
 */
 package main
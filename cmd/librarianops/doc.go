@@ -36,20 +36,91 @@ Specify a repository name to clone and process, or use -C to work in a specific
 directory (repo name is inferred from the directory basename).
 
 For each repository, librarianops will:
- 1. Clone the repository to a temporary directory (or use existing directory with -C)
- 2. Create a branch: librarianops-generateall-YYYY-MM-DD
+ 1. Clone the repository to a temporary directory (or use existing directory with -C,
+    or reuse a clone in the --cache-dir directory)
+ 2. Create a branch: librarianops-generateall-YYYYMMDDTHHMMSSZ, or
+    librarianops-generateall with --update-existing-pr
  3. Run librarian tidy
  4. Run librarian update for configured sources (sources.discovery, sources.googleapis)
  5. Run librarian generate --all
  6. Run cargo update --workspace (google-cloud-rust only)
- 7. Commit changes
- 8. Create a pull request
+ 7. Commit changes, or commit each changed library separately with
+    --commit-per-library
+ 8. Create a pull request, or one pull request per changed library with
+    --pr-per-library. With --update-existing-pr, an open pull request from
+    the same branch is updated instead
+
+With --update-existing-pr, the branch is force-pushed with a lease: it is
+only replaced if it has not changed on the remote since the run started, so
+that commits pushed to it in the meantime are not overwritten.
+
+The --branch-template flag names the branch instead, once librarian update
+has run. It is rendered with {date} (YYYYMMDD), {timestamp}
+(YYYYMMDDTHHMMSSZ), {shortsha} (the first 7 characters of the googleapis
+commit) and {library}, such as librarianops/generate/{date}/{shortsha}.
+{library} is "all" for a single pull request. With --pr-per-library, a
+template with {library} names each library branch; otherwise the library
+name is appended to the branch name. The result must be a valid git branch
+name.
+
+The branch is pushed to origin, or to the remote named by --push-remote,
+such as a fork. The pull request is always opened against the repository
+of origin, from the branch of the push remote.
+
+GitHub authentication is selected with --github-auth. With the default,
+token, gh and git push use their own credentials, such as the GH_TOKEN
+environment variable. With app, librarianops authenticates as a GitHub App
+installation, given --github-app-id, --github-app-installation-id and
+--github-app-private-key-file: it mints an installation token, passes it to
+gh and git push, and mints a new one before it expires.
+
+The --allowed-image-registry flag restricts the images that --docker runs to
+those under the given registry prefixes, such as
+us-docker.pkg.dev/my-project/librarian. It applies both to --docker-image
+and to the image derived from the version in librarian.yaml, so that an edit
+to librarian.yaml cannot point generation at an untrusted image. A prefix
+matches whole path components, and images must be named in full, including
+docker.io for Docker Hub. librarianops fails before pulling or running an
+image that is not allowed.
+
+Containers run as the uid and gid of the user running librarianops, so that
+the files they generate are owned by that user rather than by root. The
+--container-user flag runs them as another user[:group] instead, such as
+1000:1000. Set it together with --host-mount when librarianops itself runs
+in a container as a different user, such as root, from the host user who
+owns the mounted directory: --host-mount only translates the mounted paths,
+not the ownership of the files written to them.
 
 Flags:
 
-	-C directory  work in directory (repo name inferred from basename)
-	-v            run librarian with verbose output
-	--docker      run librarian in Docker
+	-C directory                                                         work in directory (repo name inferred from basename)
+	-v                                                                   run librarian with verbose output
+	--cache-dir directory                                                reuse clones of repositories kept in directory instead of cloning them again [$LIBRARIANOPS_CACHE_DIR]
+	--keep-working-dir                                                   do not remove the temporary clone of the repository, and log its path if generation fails
+	--clone-depth n                                                      clone only the latest n commits of the default branch; 0 clones the full history (default: 1)
+	--docker                                                             run librarian in Docker
+	--docker-image string                                                Docker image to run librarian in, optionally pinned by digest; implies --docker
+	--docker-image-digest string                                         expected sha256 digest of the Docker image, verified before running; implies --docker
+	--container-runtime command                                          container runtime command used with --docker, such as docker, podman or nerdctl (default: "docker") [$LIBRARIANOPS_CONTAINER_RUNTIME]
+	--host-mount host-dir:local-dir                                      when running in a container, host-dir:local-dir describing where a host directory is mounted, so that --docker mounts host paths [$LIBRARIANOPS_HOST_MOUNT]
+	--allowed-image-registry prefix [ --allowed-image-registry prefix ]  only run images under this registry prefix with --docker; can be repeated [$LIBRARIANOPS_ALLOWED_IMAGE_REGISTRIES]
+	--container-user user[:group]                                        run librarian containers as user[:group], such as 1000:1000, instead of the current uid and gid; implies --docker
+	--container-timeout duration                                         kill each librarian container that runs longer than duration, such as 30m; implies --docker (default: 0s)
+	--draft                                                              create the pull request as a draft
+	--reviewer user [ --reviewer user ]                                  request a review of the pull request from a GitHub user or org/team
+	--label label [ --label label ]                                      add a label to the pull request
+	--pr-per-library                                                     create one pull request per changed library instead of a single pull request
+	--update-existing-pr                                                 push to a fixed branch, and update its open pull request instead of creating another one
+	--branch-template template                                           name the branch with this template, using {date}, {timestamp}, {shortsha} and {library}
+	--push-remote remote                                                 push the branch to the git remote named, such as a fork, instead of origin
+	--commit-per-library                                                 create one commit per changed library in the pull request instead of a single commit
+	--commit-message-template template                                   Go template for the commit message, with fields .Repo, .LibrarianVersion and .GoogleapisCommit
+	--signing-key key                                                    sign the commit with key (a GPG key ID, or an SSH key path with --signing-mode=ssh)
+	--signing-mode string                                                signature format for --signing-key: openpgp, ssh or x509
+	--github-auth string                                                 how gh and git push authenticate to GitHub: token uses gh's own credentials, app mints GitHub App installation tokens (default: "token") [$LIBRARIANOPS_GITHUB_AUTH]
+	--github-app-id id                                                   GitHub App id, with --github-auth=app [$LIBRARIANOPS_GITHUB_APP_ID]
+	--github-app-installation-id id                                      GitHub App installation id, with --github-auth=app [$LIBRARIANOPS_GITHUB_APP_INSTALLATION_ID]
+	--github-app-private-key-file path                                   path of the GitHub App private key in PEM format, with --github-auth=app [$LIBRARIANOPS_GITHUB_APP_PRIVATE_KEY_FILE]
 
 # Upgrade librarian version in librarian.yaml
 
@@ -24,6 +24,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 const (
@@ -106,6 +107,58 @@ func RunStreamingInDir(ctx context.Context, dir, command string, arg ...string)
 	return nil
 }
 
+// RunStreamingWithOutputTail executes a program (with arguments) and streams
+// its output like [RunStreaming]. On error, the last n bytes of the combined
+// stdout and stderr are included in the error message, so that the error is
+// actionable without the streamed output.
+func RunStreamingWithOutputTail(ctx context.Context, n int, command string, arg ...string) error {
+	cmd := buildCmd(ctx, "", nil, command, arg...)
+	tail := &tailWriter{limit: n}
+	cmd.Stderr = io.MultiWriter(stderr, tail)
+	cmd.Stdout = io.MultiWriter(stdout, tail)
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if output := tail.String(); output != "" {
+		return fmt.Errorf("%s: %w\n%s", cmd, err, output)
+	}
+	return fmt.Errorf("%s: %w", cmd, err)
+}
+
+// tailWriter is an [io.Writer] that keeps the last limit bytes written to it.
+// It is safe for concurrent use, as stdout and stderr of a command are copied
+// by separate goroutines.
+type tailWriter struct {
+	mu        sync.Mutex
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	if over := len(w.buf) - w.limit; over > 0 {
+		w.buf = append(w.buf[:0], w.buf[over:]...)
+		w.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the kept output, prefixed with "..." if earlier output was
+// dropped.
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	output := strings.TrimSpace(string(w.buf))
+	if w.truncated && output != "" {
+		return "..." + output
+	}
+	return output
+}
+
 // Output executes a program (with arguments) and returns stdout. It is a
 // convenience wrapper around OutputWithEnv.
 func Output(ctx context.Context, command string, arg ...string) (string, error) {
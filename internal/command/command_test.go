@@ -254,6 +254,84 @@ func TestRunStreaming_Error(t *testing.T) {
 	}
 }
 
+func TestRunStreamingWithOutputTail(t *testing.T) {
+	t.Cleanup(func() {
+		stdout = os.Stdout
+		stderr = os.Stderr
+	})
+	var outBuf, errBuf bytes.Buffer
+	stdout = &outBuf
+	stderr = &errBuf
+	err := RunStreamingWithOutputTail(t.Context(), 11, "/bin/sh", "-c", "echo first-line && echo >&2 last-error && exit 1")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("RunStreamingWithOutputTail() error = %v, want type *exec.ExitError", err)
+	}
+	if !strings.HasSuffix(err.Error(), "\n...last-error") {
+		t.Errorf("err.Error() should end with the output tail; got %q", err.Error())
+	}
+	if diff := cmp.Diff("first-line\n", outBuf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("last-error\n", errBuf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunStreamingWithOutputTail_Success(t *testing.T) {
+	t.Cleanup(func() {
+		stdout = os.Stdout
+	})
+	var outBuf bytes.Buffer
+	stdout = &outBuf
+	if err := RunStreamingWithOutputTail(t.Context(), 10, "/bin/sh", "-c", "echo test-output"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("test-output\n", outBuf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTailWriter(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{
+			name: "empty",
+			want: "",
+		},
+		{
+			name:   "within limit",
+			writes: []string{"abc", "de\n"},
+			want:   "abcde",
+		},
+		{
+			name:   "truncated",
+			writes: []string{"abcdef", "ghijkl"},
+			want:   "...efghijkl",
+		},
+		{
+			name:   "single large write",
+			writes: []string{"abcdefghijkl"},
+			want:   "...efghijkl",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := &tailWriter{limit: 8}
+			for _, s := range test.writes {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(test.want, w.String()); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestLookPath(t *testing.T) {
 	tmpDir := t.TempDir()
 	exeName := "test-exe"
@@ -41,15 +41,16 @@ func TestLibrarianUsage(t *testing.T) {
 		want string
 	}{
 		{"root", nil, "librarian [command]"},
-		{"add", []string{"add"}, "librarian add <api>"},
+		{"add", []string{"add"}, "librarian add [--library <name>] <api>..."},
 		{"generate", []string{"generate"}, "librarian generate <library>"},
-		{"bump", []string{"bump"}, "librarian bump <library>"},
+		{"bump", []string{"bump"}, "librarian bump <library>..."},
 		{"tidy", []string{"tidy"}, "librarian tidy"},
 		{"update", []string{"update"}, "librarian update <version | source>..."},
-		{"version", []string{"version"}, "librarian version"},
+		{"version", []string{"version"}, "librarian version [--output-format=text|json|yaml]"},
 		{"publish", []string{"publish"}, "librarian publish"},
 		{"tag", []string{"tag"}, "librarian tag"},
 		{"config", []string{"config"}, "librarian config [get|set] [path] [value]"},
+		{"doctor", []string{"doctor"}, "librarian doctor"},
 	} {
 		t.Run(test.desc, func(t *testing.T) {
 			got := runUsage(t, bin, test.args)
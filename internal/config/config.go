@@ -37,10 +37,18 @@ type Config struct {
 	// Version is the librarian tool version to use.
 	Version string `yaml:"version,omitempty"`
 
+	// SchemaVersion is the version of the librarian.yaml schema. A file
+	// without it is at version 1. Files at older versions are upgraded when
+	// read, and files at newer versions are rejected.
+	SchemaVersion int `yaml:"schema_version,omitempty"`
+
 	// Repo is the repository name, such as "googleapis/google-cloud-python".
 	// It is used for:
 	// - Providing to the Java GAPIC generator for observability features.
 	// - Generating the .repo-metadata.json.
+	//
+	// It may reference environment variables as ${NAME}, which are expanded
+	// when librarian.yaml is read. It is an error for NAME to be unset.
 	Repo string `yaml:"repo,omitempty"`
 
 	// Sources references external source repositories.
@@ -52,6 +60,9 @@ type Config struct {
 	// Default contains default settings for all libraries. They apply to all libraries unless overridden.
 	Default *Default `yaml:"default,omitempty"`
 
+	// Release contains settings for releasing libraries.
+	Release *Release `yaml:"release,omitempty"`
+
 	// Libraries contains configuration overrides for libraries that need
 	// special handling, and differ from default settings.
 	Libraries []*Library `yaml:"libraries,omitempty"`
@@ -82,6 +93,9 @@ type Source struct {
 
 	// Dir is a local directory path to use instead of fetching.
 	// If set, Commit and SHA256 are ignored.
+	//
+	// It may reference environment variables as ${NAME}, which are expanded
+	// when librarian.yaml is read. It is an error for NAME to be unset.
 	Dir string `yaml:"dir,omitempty"`
 
 	// SHA256 is the expected hash of the tarball for this commit.
@@ -252,6 +266,10 @@ type Default struct {
 	// this is src/generated.
 	Output string `yaml:"output,omitempty"`
 
+	// PostGenerateHooks lists shell commands run for every library after it
+	// is generated, before the library's own PostGenerateHooks.
+	PostGenerateHooks []string `yaml:"post_generate_hooks,omitempty"`
+
 	// TagFormat is the template for git tags, such as "{name}/v{version}".
 	TagFormat string `yaml:"tag_format,omitempty"`
 
@@ -285,6 +303,21 @@ type Default struct {
 	Swift *SwiftDefault `yaml:"swift,omitempty"`
 }
 
+// Release contains settings for releasing libraries.
+type Release struct {
+	// IgnoredCommits lists regular expressions matched against the subject
+	// of each commit, such as "^chore: update deps". Matching commits are
+	// ignored when deciding whether and how to release a library.
+	IgnoredCommits []string `yaml:"ignored_commits,omitempty"`
+
+	// ReleasableTypes maps conventional commit types, such as "feat" or
+	// "fix", to the level of version bump they trigger: "patch", "minor" or
+	// "major". When set, commits of other types do not trigger a release.
+	// Breaking changes always trigger a major bump. When not set, any commit
+	// triggers a minor bump.
+	ReleasableTypes map[string]string `yaml:"releasable_types,omitempty"`
+}
+
 // Library represents a library configuration.
 type Library struct {
 	// Note: Properties should typically be added in alphabetical order, but
@@ -323,6 +356,11 @@ type Library struct {
 	// CopyrightYear is the copyright year for the library.
 	CopyrightYear string `yaml:"copyright_year,omitempty"`
 
+	// DependsOn lists the names of libraries in the same repository that
+	// this library depends on. When generating several libraries, these are
+	// generated before this library.
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
 	// TitleOverride overrides the title used in README generation.
 	TitleOverride string `yaml:"title_override,omitempty"`
 
@@ -333,16 +371,40 @@ type Library struct {
 	// markdown generation and release tracking passes.
 	Keep []string `yaml:"keep,omitempty"`
 
+	// NoopIgnorePatterns lists regular expressions matching generated lines
+	// that change on every run, such as a generation date or a generator
+	// version. If the only changes made by generation to the files in Output
+	// are to lines that match one of these patterns, both before and after
+	// generation, the changes are discarded and the library is left unchanged.
+	NoopIgnorePatterns []string `yaml:"noop_ignore_patterns,omitempty"`
+
 	// Output is the directory where code is written. This overrides
 	// Default.Output.
 	Output string `yaml:"output,omitempty"`
 
+	// OutputAllowlist lists gitignore-style patterns, relative to Output, of
+	// the files that generation may write. If set, generation fails if it
+	// leaves any other file in Output, other than files in Keep and files
+	// matched by .librarianignore.
+	OutputAllowlist []string `yaml:"output_allowlist,omitempty"`
+
+	// PostGenerateHooks lists shell commands run with sh -c in Output after
+	// the library is generated and formatted. The LIBRARIAN_LIBRARY and
+	// LIBRARIAN_VERSION environment variables are set to the name and
+	// version of the library. A failing command fails generation.
+	PostGenerateHooks []string `yaml:"post_generate_hooks,omitempty"`
+
 	// Postprocess contains post-processing operations executed after code generation.
 	Postprocess *Postprocess `yaml:"postprocess,omitempty"`
 
 	// Roots specifies the source roots to use for generation. Defaults to googleapis.
 	Roots []string `yaml:"roots,omitempty"`
 
+	// Scopes lists conventional commit scopes, such as "bigtable" in
+	// "feat(bigtable): ...", that attribute a commit to this library when
+	// releasing, even if the commit does not change files in Output.
+	Scopes []string `yaml:"scopes,omitempty"`
+
 	// SkipGenerate disables code generation for this library.
 	SkipGenerate bool `yaml:"skip_generate,omitempty"`
 
@@ -354,6 +354,13 @@ type PythonPackage struct {
 	// Example: {"google/cloud/secrets/v1beta": ["python-gapic-name=secretmanager"]}
 	OptArgsByAPI map[string][]string `yaml:"opt_args_by_api,omitempty"`
 
+	// TransportByAPI overrides the transport of the generated client for
+	// individual APIs. In each entry, the key is the API path and the value
+	// is grpc, rest or grpc+rest. APIs not listed use the transport from
+	// librarian's built-in API list.
+	// Example: {"google/cloud/secrets/v1beta": "rest"}
+	TransportByAPI map[string]string `yaml:"transport_by_api,omitempty"`
+
 	// ProtoOnlyAPIs contains the list of API paths which are proto-only, so
 	// should use regular protoc Python generation instead of GAPIC.
 	ProtoOnlyAPIs []string `yaml:"proto_only_apis,omitempty"`
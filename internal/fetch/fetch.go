@@ -24,9 +24,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,12 +39,23 @@ const (
 	// DefaultBranchMaster represents the default git branch "master".
 	DefaultBranchMaster = "master"
 	maxDownloadRetries  = 3
+	// maxRateLimitAttempts is the maximum number of attempts for a GitHub
+	// request that is rejected by a rate limit.
+	maxRateLimitAttempts = 3
+	// defaultRateLimitWait is the time to wait for a rate limited request
+	// that does not say when to retry, as recommended by the GitHub docs.
+	defaultRateLimitWait = time.Minute
+	// maxRateLimitWait is the longest time to wait for a rate limit. An
+	// exhausted primary rate limit can take up to an hour to reset, and it
+	// is better to fail than to stall a run for that long.
+	maxRateLimitWait = 5 * time.Minute
 )
 
 var (
 	errAbsSymlinks         = errors.New("absolute symlinks are not allowed")
 	errChecksumMismatch    = errors.New("checksum mismatch")
 	errMissingSHA256       = errors.New("must provide expected SHA256")
+	errRateLimitWait       = errors.New("GitHub rate limit wait is too long")
 	errSymlinkEscape       = errors.New("symlinks are not allowed to escape destination")
 	errUnsupportedFileType = errors.New("unsupported file type")
 	defaultBackoff         = 10 * time.Second
@@ -55,6 +68,10 @@ type Endpoints struct {
 
 	// Download defines the endpoint to download tarballs.
 	Download string
+
+	// Token, if set, authenticates API calls, which raises the GitHub rate
+	// limit.
+	Token string
 }
 
 // RepoRef represents a GitHub repository name.
@@ -99,6 +116,7 @@ type RepoRef struct {
 //
 // Cache lookup order:
 //  1. Check if extracted directory exists and contains files. If so, return it.
+//     The directory is only created from a verified tarball.
 //  2. Check if tarball exists. Verify its SHA256 matches expectedSHA256. If yes,
 //     extract tarball and return the directory. If the hash mismatches, fall
 //     through to step 3.
@@ -132,6 +150,9 @@ func Repo(ctx context.Context, repo, commit, expectedSHA256 string) (string, err
 					return outDir, nil
 				}
 			}
+			if sha != expectedSHA256 {
+				slog.Warn("discarding cached tarball with unexpected checksum", "path", tgz, "expected", expectedSHA256, "got", sha)
+			}
 			if err := os.Remove(tgz); err != nil {
 				return "", fmt.Errorf("failed to remove %q: %w", tgz, err)
 			}
@@ -219,8 +240,12 @@ func repoFromArchiveLink(githubDownload, archiveLink string) (*RepoRef, error) {
 
 // urlSha256 downloads the content from the given URL and returns its SHA256
 // checksum as a hex string.
-func urlSha256(query string) (string, error) {
-	response, err := http.Get(query)
+func urlSha256(ctx context.Context, query string) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, query, nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := DoWithRateLimitRetry(http.DefaultClient, request)
 	if err != nil {
 		return "", err
 	}
@@ -238,15 +263,18 @@ func urlSha256(query string) (string, error) {
 }
 
 // latestSha fetches the latest commit SHA from the GitHub API for the given
-// repository URL.
-func latestSha(query string) (string, error) {
+// repository URL. If token is set, it is used to authenticate the request.
+func latestSha(ctx context.Context, query, token string) (string, error) {
 	client := &http.Client{}
-	request, err := http.NewRequest(http.MethodGet, query, nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, query, nil)
 	if err != nil {
 		return "", err
 	}
 	request.Header.Set("Accept", "application/vnd.github.VERSION.sha")
-	response, err := client.Do(request)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := DoWithRateLimitRetry(client, request)
 	if err != nil {
 		return "", err
 	}
@@ -261,17 +289,77 @@ func latestSha(query string) (string, error) {
 	return string(contents), nil
 }
 
+// DoWithRateLimitRetry sends request with client, waiting and retrying up to
+// maxRateLimitAttempts times while GitHub rejects it with a rate limit. The
+// wait is interrupted if the context of request is canceled, and it fails
+// instead of waiting longer than maxRateLimitWait. A request with a body must
+// set GetBody so that the body can be sent again, as [http.NewRequest] does.
+func DoWithRateLimitRetry(client *http.Client, request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	for attempt := 1; ; attempt++ {
+		response, err := client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		wait, limited := rateLimitWait(response, time.Now())
+		if !limited || attempt == maxRateLimitAttempts {
+			return response, nil
+		}
+		response.Body.Close()
+		if wait > maxRateLimitWait {
+			return nil, fmt.Errorf("%w: %s %s asks to wait %v, more than %v", errRateLimitWait, request.Method, request.URL, wait, maxRateLimitWait)
+		}
+		if request.GetBody != nil {
+			if request.Body, err = request.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		slog.Info("waiting for GitHub rate limit", "url", request.URL.String(), "wait", wait, "attempt", attempt)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimitWait reports whether response is a GitHub rate limit rejection
+// and, if so, how long to wait before retrying. It uses the Retry-After
+// header for secondary rate limits, and the x-ratelimit-reset header when
+// the primary rate limit is exhausted. See
+// https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api.
+func rateLimitWait(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response.StatusCode != http.StatusForbidden && response.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if response.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+	// A 403 without rate limit headers is a permission error.
+	if response.StatusCode == http.StatusTooManyRequests {
+		return defaultRateLimitWait, true
+	}
+	return 0, false
+}
+
 // LatestCommitAndChecksum fetches the latest commit SHA and the SHA256 of the tarball for that
 // commit from the GitHub API for the given repository.
-func LatestCommitAndChecksum(endpoints *Endpoints, repo *RepoRef) (commit, sha256 string, err error) {
+func LatestCommitAndChecksum(ctx context.Context, endpoints *Endpoints, repo *RepoRef) (commit, sha256 string, err error) {
 	apiURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s", endpoints.API, repo.Org, repo.Name, repo.Branch)
-	commit, err = latestSha(apiURL)
+	commit, err = latestSha(ctx, apiURL, endpoints.Token)
 	if err != nil {
 		return "", "", err
 	}
 
 	tarballURL := tarballLink(endpoints.Download, repo, commit)
-	sha256, err = urlSha256(tarballURL)
+	sha256, err = urlSha256(ctx, tarballURL)
 	if err != nil {
 		return "", "", err
 	}
@@ -289,13 +377,23 @@ func tarballLink(githubDownload string, repo *RepoRef, sha string) string {
 // Download downloads a file from the given url to the target path, verifying
 // its SHA256 checksum matches expectedSHA256. It retries up to
 // maxDownloadRetries times with exponential backoff on failure.
+//
+// If target already exists, it is not downloaded again, but its checksum is
+// still verified.
 func Download(ctx context.Context, target, url, expectedSHA256 string) error {
-	if fileExists(target) {
-		return nil
-	}
 	if expectedSHA256 == "" {
 		return errMissingSHA256
 	}
+	if fileExists(target) {
+		sha, err := computeSHA256(target)
+		if err != nil {
+			return err
+		}
+		if sha != expectedSHA256 {
+			return fmt.Errorf("%w: %s: expected=%s, got=%s", errChecksumMismatch, target, expectedSHA256, sha)
+		}
+		return nil
+	}
 	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
 		return err
 	}
@@ -325,22 +423,53 @@ func Download(ctx context.Context, target, url, expectedSHA256 string) error {
 	return os.Rename(tempPath, target)
 }
 
+// Tarball extracts the gzipped tarball at source, a local path or an http or
+// https URL, into destDir. As with the repository archives downloaded by
+// [Repo], the files must be wrapped in a single top-level directory, which is
+// not extracted. Unlike [Repo], the tarball is not cached and its checksum is
+// not verified.
+func Tarball(ctx context.Context, source, destDir string) error {
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+		if err := extractTarball(source, destDir); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", source, err)
+		}
+		return nil
+	}
+	tempFile, err := os.CreateTemp("", "librarian-tarball-")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	_ = tempFile.Close()
+	defer os.Remove(tempPath)
+	if err := downloadFile(ctx, tempPath, source); err != nil {
+		return fmt.Errorf("failed to download %s: %w", source, err)
+	}
+	if err := extractTarball(tempPath, destDir); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", source, err)
+	}
+	return nil
+}
+
 // downloadFile downloads a file from the given source URL to the target path.
-// It retries up to maxDownloadRetries times with exponential backoff on failure.
+// It retries transient failures up to maxDownloadRetries times with
+// exponential backoff.
 func downloadFile(ctx context.Context, target, source string) error {
 	var err error
+	backoff := defaultBackoff
 	for i := range maxDownloadRetries {
 		if i > 0 {
+			slog.Warn("retrying download", "url", source, "attempt", i+1, "error", err)
 			select {
-			case <-time.After(defaultBackoff):
-				defaultBackoff *= 2
+			case <-time.After(backoff):
+				backoff *= 2
 			case <-ctx.Done():
 				return ctx.Err()
 			}
 		}
 
 		if err = downloadAttempt(ctx, target, source); err != nil {
-			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			if !isTransient(err) {
 				return err
 			}
 			continue
@@ -350,6 +479,32 @@ func downloadFile(ctx context.Context, target, source string) error {
 	return fmt.Errorf("download failed after %d attempts, last error=%w", maxDownloadRetries, err)
 }
 
+// httpStatusError is returned by a download attempt that receives a
+// non-success HTTP status.
+type httpStatusError struct {
+	statusCode int
+	status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http error in download %s", e.status)
+}
+
+// isTransient reports whether a failed download attempt is worth retrying.
+// Server errors, rate limiting and network errors are transient. Other HTTP
+// errors, such as 404, local file errors and context cancellation are not.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= http.StatusInternalServerError || statusErr.statusCode == http.StatusTooManyRequests
+	}
+	var pathErr *fs.PathError
+	return !errors.As(err, &pathErr)
+}
+
 func downloadAttempt(ctx context.Context, target, source string) (err error) {
 	file, err := os.Create(target)
 	if err != nil {
@@ -376,7 +531,7 @@ func downloadAttempt(ctx context.Context, target, source string) (err error) {
 	}
 	defer response.Body.Close()
 	if response.StatusCode >= 300 {
-		return fmt.Errorf("http error in download %s", response.Status)
+		return &httpStatusError{statusCode: response.StatusCode, status: response.Status}
 	}
 	if _, err := io.Copy(file, response.Body); err != nil {
 		return err
@@ -29,7 +29,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -316,7 +318,7 @@ func TestSha256(t *testing.T) {
 	}))
 	defer server.Close()
 
-	got, err := urlSha256(server.URL + tarballPath)
+	got, err := urlSha256(t.Context(), server.URL+tarballPath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -347,7 +349,7 @@ func TestSha256Error(t *testing.T) {
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			if _, err := urlSha256(test.url); err == nil {
+			if _, err := urlSha256(t.Context(), test.url); err == nil {
 				t.Error("expected an error from Sha256()")
 			}
 		})
@@ -374,7 +376,7 @@ func TestLatestSha(t *testing.T) {
 	}))
 	defer server.Close()
 
-	got, err := latestSha(server.URL + getLatestShaPath)
+	got, err := latestSha(t.Context(), server.URL+getLatestShaPath, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -405,13 +407,207 @@ func TestLatestShaError(t *testing.T) {
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			if _, err := latestSha(test.url); err == nil {
+			if _, err := latestSha(t.Context(), test.url, ""); err == nil {
 				t.Error("expected an error from LatestSha()")
 			}
 		})
 	}
 }
 
+func TestLatestSha_Token(t *testing.T) {
+	const expectedCommitSha = "5d5b1bf126485b0e2c972bac41b376438601e266"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer test-token"; got != want {
+			t.Errorf("mismatched Authorization header, got=%q, want=%q", got, want)
+		}
+		w.Write([]byte(expectedCommitSha))
+	}))
+	defer server.Close()
+
+	got, err := latestSha(t.Context(), server.URL+"/test", "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != expectedCommitSha {
+		t.Errorf("latestSha() = %q, want %q", got, expectedCommitSha)
+	}
+}
+
+func TestLatestSha_RateLimitRetry(t *testing.T) {
+	const expectedCommitSha = "5d5b1bf126485b0e2c972bac41b376438601e266"
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(expectedCommitSha))
+	}))
+	defer server.Close()
+
+	got, err := latestSha(t.Context(), server.URL+"/test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != expectedCommitSha {
+		t.Errorf("latestSha() = %q, want %q", got, expectedCommitSha)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+}
+
+func TestLatestSha_RateLimitExhausted(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	if _, err := latestSha(t.Context(), server.URL+"/test", ""); err == nil {
+		t.Error("expected an error from latestSha()")
+	}
+	if requests != maxRateLimitAttempts {
+		t.Errorf("got %d requests, want %d", requests, maxRateLimitAttempts)
+	}
+}
+
+func TestLatestSha_RateLimitWaitTooLong(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := latestSha(t.Context(), server.URL+"/test", "")
+	if !errors.Is(err, errRateLimitWait) {
+		t.Errorf("latestSha() error = %v, want %v", err, errRateLimitWait)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+}
+
+func TestLatestSha_RateLimitCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	_, err := latestSha(ctx, server.URL+"/test", "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("latestSha() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDoWithRateLimitRetry_Body(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL, strings.NewReader("content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := DoWithRateLimitRetry(http.DefaultClient, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if diff := cmp.Diff([]string{"content", "content"}, bodies); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, test := range []struct {
+		name        string
+		statusCode  int
+		header      map[string]string
+		wantWait    time.Duration
+		wantLimited bool
+	}{
+		{
+			name:       "success",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			header:     map[string]string{"Retry-After": "5"},
+		},
+		{
+			name:        "secondary rate limit",
+			statusCode:  http.StatusForbidden,
+			header:      map[string]string{"Retry-After": "30"},
+			wantWait:    30 * time.Second,
+			wantLimited: true,
+		},
+		{
+			name:       "primary rate limit",
+			statusCode: http.StatusForbidden,
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "1700000042",
+			},
+			wantWait:    42 * time.Second,
+			wantLimited: true,
+		},
+		{
+			name:       "primary rate limit already reset",
+			statusCode: http.StatusTooManyRequests,
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "1699999999",
+			},
+			wantLimited: true,
+		},
+		{
+			name:        "too many requests without headers",
+			statusCode:  http.StatusTooManyRequests,
+			wantWait:    defaultRateLimitWait,
+			wantLimited: true,
+		},
+		{
+			name:       "forbidden without headers",
+			statusCode: http.StatusForbidden,
+			header:     map[string]string{"X-RateLimit-Remaining": "10"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			response := &http.Response{StatusCode: test.statusCode, Header: http.Header{}}
+			for k, v := range test.header {
+				response.Header.Set(k, v)
+			}
+			gotWait, gotLimited := rateLimitWait(response, now)
+			if gotWait != test.wantWait || gotLimited != test.wantLimited {
+				t.Errorf("rateLimitWait() = (%v, %v), want (%v, %v)", gotWait, gotLimited, test.wantWait, test.wantLimited)
+			}
+		})
+	}
+}
+
 func TestTarballLink(t *testing.T) {
 	for _, test := range []struct {
 		githubDownload string
@@ -587,6 +783,67 @@ func TestExtractTarball(t *testing.T) {
 	}
 }
 
+func TestTarball(t *testing.T) {
+	tarballData := createTestTarball(t, "googleapis-abc123", map[string]string{
+		"google/api/annotations.proto": "syntax = \"proto3\";",
+	})
+	tarballPath := path.Join(t.TempDir(), "googleapis.tar.gz")
+	if err := os.WriteFile(tarballPath, tarballData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/googleapis.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(tarballData)
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		name   string
+		source string
+	}{
+		{"local file", tarballPath},
+		{"url", server.URL + "/googleapis.tar.gz"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			if err := Tarball(t.Context(), test.source, destDir); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path.Join(destDir, "google/api/annotations.proto"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(`syntax = "proto3";`, string(got)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTarball_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		name   string
+		source string
+	}{
+		{"missing file", path.Join(t.TempDir(), "missing.tar.gz")},
+		{"not found", server.URL + "/missing.tar.gz"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Tarball(t.Context(), test.source, t.TempDir()); err == nil {
+				t.Errorf("Tarball(%q) succeeded, want error", test.source)
+			}
+		})
+	}
+}
+
 func createTestTarball(t *testing.T, topLevelDir string, files map[string]string) []byte {
 	t.Helper()
 
@@ -877,6 +1134,18 @@ func TestDownload_EmptySha(t *testing.T) {
 	}
 }
 
+func TestDownload_TgzExistsMismatch(t *testing.T) {
+	target := path.Join(t.TempDir(), "existing-file")
+	if err := os.WriteFile(target, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tarball := makeTestContents(t)
+	err := Download(t.Context(), target, "https://unused/placeholder.tar.gz", tarball.Sha256)
+	if !errors.Is(err, errChecksumMismatch) {
+		t.Errorf("expected errChecksumMismatch, got: %v", err)
+	}
+}
+
 func TestLatestCommitAndChecksum(t *testing.T) {
 	const (
 		expectedMasterCommit          = "testcommit123"
@@ -956,7 +1225,7 @@ func TestLatestCommitAndChecksum(t *testing.T) {
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			gotCommit, gotSha256, err := LatestCommitAndChecksum(endpoints, test.repo)
+			gotCommit, gotSha256, err := LatestCommitAndChecksum(t.Context(), endpoints, test.repo)
 			if err != nil {
 				t.Fatalf("LatestCommitAndChecksum() error = %v, wantErr %v", err, nil)
 			}
@@ -993,6 +1262,51 @@ func TestDownload_RetryErrorIncludesLastFailure(t *testing.T) {
 	}
 }
 
+func TestDownload_NoRetryOnClientError(t *testing.T) {
+	defaultBackoff = time.Millisecond
+	t.Cleanup(func() {
+		defaultBackoff = 10 * time.Second
+	})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	target := path.Join(t.TempDir(), "target-file")
+	err := Download(t.Context(), target, server.URL+"/test.tar.gz", "any-sha")
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("got error %v, want *httpStatusError", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &httpStatusError{statusCode: http.StatusBadGateway}, true},
+		{"rate limited", &httpStatusError{statusCode: http.StatusTooManyRequests}, true},
+		{"not found", &httpStatusError{statusCode: http.StatusNotFound}, false},
+		{"network error", errors.New("connection reset by peer"), true},
+		{"canceled", context.Canceled, false},
+		{"deadline exceeded", fmt.Errorf("wrapped: %w", context.DeadlineExceeded), false},
+		{"file error", &fs.PathError{Op: "open", Path: "target", Err: fs.ErrPermission}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isTransient(test.err); got != test.want {
+				t.Errorf("isTransient(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
 func TestDownload_RetrySucceeds(t *testing.T) {
 	defaultBackoff = time.Millisecond
 	t.Cleanup(func() {
@@ -1045,7 +1359,7 @@ func TestLatestCommitAndChecksumFailure(t *testing.T) {
 		endpoints := &Endpoints{API: server.URL, Download: server.URL}
 		repo := &RepoRef{Org: testOrg, Name: testRepo}
 
-		_, _, err := LatestCommitAndChecksum(endpoints, repo)
+		_, _, err := LatestCommitAndChecksum(t.Context(), endpoints, repo)
 		if err == nil {
 			t.Error("expected an error when LatestSha fails, but got nil")
 		}
@@ -1067,7 +1381,7 @@ func TestLatestCommitAndChecksumFailure(t *testing.T) {
 		endpoints := &Endpoints{API: server.URL, Download: server.URL}
 		repo := &RepoRef{Org: testOrg, Name: testRepo}
 
-		_, _, err := LatestCommitAndChecksum(endpoints, repo)
+		_, _, err := LatestCommitAndChecksum(t.Context(), endpoints, repo)
 		if err == nil {
 			t.Error("expected an error when Sha256 fails, but got nil")
 		}
@@ -16,7 +16,9 @@
 package filesystem
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -119,13 +121,29 @@ func MoveAndMergeWithKeep(sourceDir, targetDir, libraryRoot string, keepFunc fun
 	return nil
 }
 
-// CopyFile copies a file from src to dest.
+// CopyFile copies a file from src to dest. The permission bits of src,
+// including the executable bits, are copied to dest, except that dest is
+// always writable by its owner so that it can be overwritten by a later copy.
+// If dest already has the same content as src, it is not rewritten, which
+// preserves its modification time.
 func CopyFile(src, dest string) error {
 	in, err := os.Open(src)
 	if err != nil {
 		return err
 	}
 	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm() | 0o200
+	same, err := sameContent(in, info.Size(), dest)
+	if err != nil {
+		return err
+	}
+	if same {
+		return os.Chmod(dest, mode)
+	}
 	out, err := os.Create(dest)
 	if err != nil {
 		return err
@@ -134,7 +152,87 @@ func CopyFile(src, dest string) error {
 		out.Close()
 		return err
 	}
-	return out.Close()
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// os.Create does not change the mode of an existing file, and applies the
+	// umask to a new one, so set the mode explicitly.
+	return os.Chmod(dest, mode)
+}
+
+// CopyDir copies the directory tree at src to dest, creating dest if needed.
+// Regular files are copied with [CopyFile] and symbolic links are recreated.
+// Files in dest that are not in src are left in place.
+func CopyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return CopyFile(path, target)
+		default:
+			return fmt.Errorf("cannot copy %s: unsupported file type %s", path, d.Type())
+		}
+	})
+}
+
+// sameContent reports whether the file at dest exists and has the same
+// content as in, whose size is size, by comparing their SHA-256 hashes. in is
+// rewound to its start before returning.
+func sameContent(in *os.File, size int64, dest string) (bool, error) {
+	info, err := os.Stat(dest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.Mode().IsRegular() || info.Size() != size {
+		return false, nil
+	}
+	srcHash, err := hashReader(in)
+	if err != nil {
+		return false, err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	f, err := os.Open(dest)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	destHash, err := hashReader(f)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(srcHash, destHash), nil
+}
+
+// hashReader returns the SHA-256 hash of the content of r.
+func hashReader(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
 }
 
 // Unzip unzips the src archive into dest directory using the system unzip command.
@@ -24,6 +24,7 @@ import (
 	"slices"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/googleapis/librarian/internal/testhelper"
@@ -277,6 +278,149 @@ func TestCopyFile_Success(t *testing.T) {
 	}
 }
 
+func TestCopyFile_Mode(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		name     string
+		mode     fs.FileMode
+		existing bool
+		want     fs.FileMode
+	}{
+		{"executable", 0o755, false, 0o755},
+		{"executable overwrites existing", 0o755, true, 0o755},
+		{"regular", 0o644, false, 0o644},
+		{"read only", 0o444, false, 0o644},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			tmp := t.TempDir()
+			src := filepath.Join(tmp, "src.sh")
+			dst := filepath.Join(tmp, "dst.sh")
+			if err := os.WriteFile(src, []byte("#!/bin/sh\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(src, test.mode); err != nil {
+				t.Fatal(err)
+			}
+			if test.existing {
+				if err := os.WriteFile(dst, []byte("old"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := CopyFile(src, dst); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != test.want {
+				t.Errorf("mode = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCopyFile_Unchanged(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src.txt")
+	dst := filepath.Join(tmp, "dst.txt")
+	if err := os.WriteFile(src, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(dst, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("CopyFile() rewrote identical file, mtime = %v, want %v", info.ModTime(), mtime)
+	}
+
+	// A changed file of the same size is still copied.
+	if err := os.WriteFile(src, []byte("hello there"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("hello there", string(got)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	t.Parallel()
+	src := filepath.Join(t.TempDir(), "src")
+	dest := filepath.Join(t.TempDir(), "dest")
+	for name, content := range map[string]string{
+		"a.txt":       "a",
+		"sub/b.txt":   "b",
+		"sub/c/d.txt": "d",
+	} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	// Files only in dest are kept, and existing symbolic links replaced.
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "extra.txt"), []byte("extra"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("extra.txt", filepath.Join(dest, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyDir(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"a.txt":       "a",
+		"sub/b.txt":   "b",
+		"sub/c/d.txt": "d",
+		"extra.txt":   "extra",
+		"link":        "a",
+	} {
+		got, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCopyDir_Error(t *testing.T) {
+	t.Parallel()
+	if err := CopyDir(filepath.Join(t.TempDir(), "missing"), t.TempDir()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("CopyDir() error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
 func TestCopyFile_Error(t *testing.T) {
 	t.Parallel()
 	tmp := t.TempDir()
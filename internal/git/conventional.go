@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidConventionalCommit is reported when a commit message does
+	// not start with a conventional commit header.
+	ErrInvalidConventionalCommit = errors.New("not a conventional commit")
+
+	// conventionalHeaderRegexp matches the header of a conventional commit,
+	// such as "feat(storage)!: remove a method".
+	conventionalHeaderRegexp = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+	// footerRegexp matches the first line of a footer, such as
+	// "PiperOrigin-RevId: 123", "Fixes #45" or "BREAKING CHANGE: ...".
+	footerRegexp = regexp.MustCompile(`^([\w-]+|BREAKING CHANGE)(?:: | #)(.*)$`)
+)
+
+// ConventionalCommit is a commit message parsed according to the
+// Conventional Commits specification, https://www.conventionalcommits.org.
+type ConventionalCommit struct {
+	// Type is the type of the change, such as "feat" or "fix".
+	Type string
+	// Scope is the optional scope of the change, such as "storage" in
+	// "feat(storage): ...".
+	Scope string
+	// Subject is the description after the type and scope.
+	Subject string
+	// Body is the free-form text between the header and the footers, with
+	// surrounding blank lines removed.
+	Body string
+	// Footers maps each footer token, such as "PiperOrigin-RevId" or
+	// "BREAKING CHANGE", to its value, and is nil if there are no footers.
+	// Values that continue over several lines are joined with newlines. If
+	// a token is repeated, the last value is kept.
+	Footers map[string]string
+	// IsBreaking reports whether the commit is a breaking change, marked by
+	// "!" after the type or scope, or by a BREAKING CHANGE or
+	// BREAKING-CHANGE footer.
+	IsBreaking bool
+}
+
+// ParseConventionalCommit parses a commit message. The first line must be a
+// conventional commit header, or ErrInvalidConventionalCommit is returned.
+//
+// Footers are read from the last paragraph of the message, if its first line
+// is a footer. Lines in that paragraph that do not start a footer continue
+// the value of the previous one. A "BREAKING CHANGE:" line anywhere else is
+// part of the body, and does not make the commit breaking.
+func ParseConventionalCommit(message string) (*ConventionalCommit, error) {
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+	header, rest, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	m := conventionalHeaderRegexp.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidConventionalCommit, header)
+	}
+	commit := &ConventionalCommit{
+		Type:       m[1],
+		Scope:      m[2],
+		Subject:    m[4],
+		IsBreaking: m[3] == "!",
+	}
+	rest = strings.Trim(rest, "\n")
+	body, footers := "", rest
+	if i := strings.LastIndex(rest, "\n\n"); i >= 0 {
+		body, footers = rest[:i], rest[i+2:]
+	}
+	first, _, _ := strings.Cut(footers, "\n")
+	if !footerRegexp.MatchString(first) {
+		body, footers = rest, ""
+	}
+	commit.Body = strings.Trim(body, "\n")
+	if footers == "" {
+		return commit, nil
+	}
+	commit.Footers = map[string]string{}
+	var key string
+	for line := range strings.SplitSeq(footers, "\n") {
+		if m := footerRegexp.FindStringSubmatch(line); m != nil {
+			key = m[1]
+			commit.Footers[key] = m[2]
+			continue
+		}
+		commit.Footers[key] += "\n" + line
+	}
+	_, breaking := commit.Footers["BREAKING CHANGE"]
+	_, breakingHyphen := commit.Footers["BREAKING-CHANGE"]
+	commit.IsBreaking = commit.IsBreaking || breaking || breakingHyphen
+	return commit, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		message string
+		want    *ConventionalCommit
+	}{
+		{
+			name:    "header only",
+			message: "feat: add a method",
+			want:    &ConventionalCommit{Type: "feat", Subject: "add a method"},
+		},
+		{
+			name:    "scope",
+			message: "fix(storage): handle retries\n",
+			want:    &ConventionalCommit{Type: "fix", Scope: "storage", Subject: "handle retries"},
+		},
+		{
+			name:    "breaking with exclamation",
+			message: "feat(storage)!: remove a method",
+			want:    &ConventionalCommit{Type: "feat", Scope: "storage", Subject: "remove a method", IsBreaking: true},
+		},
+		{
+			name:    "multi-line body",
+			message: "docs: update README\n\nFirst paragraph.\n\nSecond paragraph\nover two lines.\n",
+			want: &ConventionalCommit{
+				Type:    "docs",
+				Subject: "update README",
+				Body:    "First paragraph.\n\nSecond paragraph\nover two lines.",
+			},
+		},
+		{
+			name: "multiple footers",
+			message: `feat: add an API
+
+Adds the API.
+
+BREAKING CHANGE: the old API is removed
+and replaced.
+Fixes #123
+PiperOrigin-RevId: 456789`,
+			want: &ConventionalCommit{
+				Type:    "feat",
+				Subject: "add an API",
+				Body:    "Adds the API.",
+				Footers: map[string]string{
+					"BREAKING CHANGE":   "the old API is removed\nand replaced.",
+					"Fixes":             "123",
+					"PiperOrigin-RevId": "456789",
+				},
+				IsBreaking: true,
+			},
+		},
+		{
+			name:    "footers without body",
+			message: "chore: update googleapis\n\nPiperOrigin-RevId: 1\nSource-Link: https://github.com/googleapis/googleapis/commit/abc",
+			want: &ConventionalCommit{
+				Type:    "chore",
+				Subject: "update googleapis",
+				Footers: map[string]string{
+					"PiperOrigin-RevId": "1",
+					"Source-Link":       "https://github.com/googleapis/googleapis/commit/abc",
+				},
+			},
+		},
+		{
+			name:    "breaking footer with hyphen",
+			message: "fix: a bug\n\nBREAKING-CHANGE: a behavior changed",
+			want: &ConventionalCommit{
+				Type:       "fix",
+				Subject:    "a bug",
+				Footers:    map[string]string{"BREAKING-CHANGE": "a behavior changed"},
+				IsBreaking: true,
+			},
+		},
+		{
+			name:    "repeated footer",
+			message: "fix: a bug\n\nReviewed-by: a\nReviewed-by: b",
+			want: &ConventionalCommit{
+				Type:    "fix",
+				Subject: "a bug",
+				Footers: map[string]string{"Reviewed-by": "b"},
+			},
+		},
+		{
+			name:    "footer-like line in body",
+			message: "docs: explain footers\n\nBREAKING CHANGE: starts a footer\nwhen it is in the last paragraph.\n\nThis is the last paragraph.",
+			want: &ConventionalCommit{
+				Type:    "docs",
+				Subject: "explain footers",
+				Body:    "BREAKING CHANGE: starts a footer\nwhen it is in the last paragraph.\n\nThis is the last paragraph.",
+			},
+		},
+		{
+			name:    "windows line endings",
+			message: "fix: a bug\r\n\r\nPiperOrigin-RevId: 1\r\n",
+			want: &ConventionalCommit{
+				Type:    "fix",
+				Subject: "a bug",
+				Footers: map[string]string{"PiperOrigin-RevId": "1"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseConventionalCommit(test.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseConventionalCommit_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		message string
+	}{
+		{"empty", ""},
+		{"no type", "add a method"},
+		{"no space after colon", "feat:add a method"},
+		{"empty subject", "feat: "},
+		{"unclosed scope", "feat(storage: add a method"},
+		{"space in type", "new feature: add a method"},
+		{"merge commit", "Merge pull request #1 from googleapis/branch"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseConventionalCommit(test.message); !errors.Is(err, ErrInvalidConventionalCommit) {
+				t.Errorf("ParseConventionalCommit(%q) error = %v, want %v", test.message, err, ErrInvalidConventionalCommit)
+			}
+		})
+	}
+}
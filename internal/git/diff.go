@@ -0,0 +1,73 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/command"
+)
+
+// Diff returns the unified diff between HEAD and the working tree of the
+// current directory's repository, including new, modified and deleted files.
+// Files ignored by .gitignore are not included. Binary files are included as
+// git binary patches, so the result can be applied with git apply.
+//
+// The diff is computed with a temporary copy of the index, so the
+// repository's index and working tree are left unchanged.
+func Diff(ctx context.Context, gitExe string) (string, error) {
+	indexPath, err := command.Output(ctx, gitExe, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	indexPath, err = filepath.Abs(strings.TrimSpace(indexPath))
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp("", "librarian-index-")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	index, err := os.ReadFile(indexPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		tmp.Close()
+		return "", err
+	}
+	if _, err := tmp.Write(index); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if len(index) == 0 {
+		// git rejects an empty file as an index, but accepts a missing one.
+		if err := os.Remove(tmpPath); err != nil {
+			return "", err
+		}
+	}
+	env := map[string]string{"GIT_INDEX_FILE": tmpPath}
+	if err := command.RunWithEnv(ctx, env, gitExe, "add", "--all"); err != nil {
+		return "", fmt.Errorf("failed to stage changes in temporary index: %w", err)
+	}
+	return command.OutputWithEnv(ctx, env, gitExe, "diff", "--cached", "--binary", "HEAD")
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+var diffHeaderRegexp = regexp.MustCompile(`(?m)^diff --git a/(\S+) b/\S+\n(new|deleted)?`)
+
+func TestDiff(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	testhelper.SetupRepo(t)
+	if err := os.WriteFile("deleted.txt", []byte("deleted\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", "deleted.txt")
+	testhelper.RunGit(t, "commit", "-m", "add deleted.txt")
+
+	for name, content := range map[string]string{
+		testhelper.ReadmeFile: "modified\n",
+		"new.txt":             "new\n",
+		".gitignore":          "ignored.txt\n",
+		"ignored.txt":         "ignored\n",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove("deleted.txt"); err != nil {
+		t.Fatal(err)
+	}
+	statusBefore, err := command.Output(t.Context(), command.Git, "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Diff(t.Context(), command.Git)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, m := range diffHeaderRegexp.FindAllStringSubmatch(got, -1) {
+		files[m[1]] = m[2]
+	}
+	want := map[string]string{
+		".gitignore":          "new",
+		testhelper.ReadmeFile: "",
+		"deleted.txt":         "deleted",
+		"new.txt":             "new",
+	}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	statusAfter, err := command.Output(t.Context(), command.Git, "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(statusBefore, statusAfter); diff != "" {
+		t.Errorf("Diff() changed git status (-before +after):\n%s", diff)
+	}
+}
+
+func TestDiff_Clean(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	testhelper.SetupRepo(t)
+	got, err := Diff(t.Context(), command.Git)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("Diff() = %q, want empty", got)
+	}
+}
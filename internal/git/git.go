@@ -19,6 +19,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os/exec"
+	"path"
 	"slices"
 	"strings"
 
@@ -33,6 +35,26 @@ var (
 	// ErrGitStatusUnclean reported when the git status reports uncommitted
 	// changes.
 	ErrGitStatusUnclean = errors.New("git working directory is not clean")
+
+	// ErrShallowHistory is reported when a revision is not found in a
+	// shallow clone, as it may be older than the history that was fetched.
+	ErrShallowHistory = errors.New("revision is not in the history of the shallow clone; fetch more history, for example with git fetch --unshallow")
+
+	// ErrUnknownRevision is reported when a revision does not name a commit
+	// in the repository.
+	ErrUnknownRevision = errors.New("revision does not name a known commit")
+
+	// ErrInvalidTagFormat is reported when a tag format does not contain
+	// both the {name} and {version} placeholders.
+	ErrInvalidTagFormat = errors.New("tag format must contain {name} and {version}")
+
+	// ErrNoUpstream is reported when the current branch does not track a
+	// remote branch.
+	ErrNoUpstream = errors.New("current branch has no upstream branch")
+
+	// ErrBranchDiverged is reported when the current branch cannot be
+	// fast-forwarded to its upstream branch.
+	ErrBranchDiverged = errors.New("current branch has diverged from its upstream branch")
 )
 
 // AssertGitStatusClean returns an error if the git working directory has uncommitted changes.
@@ -70,6 +92,55 @@ func Tag(ctx context.Context, gitExe, tagName, revision string) error {
 	return nil
 }
 
+// TagRef is a tag and the commit it points to.
+type TagRef struct {
+	// Name is the tag name, such as "google-cloud-storage/v1.2.3".
+	Name string
+	// Commit is the hash of the commit the tag points to. For annotated tags
+	// this is the tagged commit, not the tag object.
+	Commit string
+}
+
+// Tags returns the tags of the repository, sorted by name.
+func Tags(ctx context.Context, gitExe string) ([]TagRef, error) {
+	// %(*objectname) is the peeled object of annotated tags, and empty for
+	// lightweight tags.
+	output, err := command.Output(ctx, gitExe, "for-each-ref", "--sort=refname",
+		"--format=%(refname:strip=2) %(objectname) %(*objectname)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	var tags []TagRef
+	for line := range strings.Lines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		tag := TagRef{Name: fields[0], Commit: fields[1]}
+		if len(fields) == 3 {
+			tag.Commit = fields[2]
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// ValidateTagFormat returns an error if tagFormat does not contain both the
+// {name} and {version} placeholders.
+func ValidateTagFormat(tagFormat string) error {
+	if !strings.Contains(tagFormat, "{name}") || !strings.Contains(tagFormat, "{version}") {
+		return fmt.Errorf("%w: %q", ErrInvalidTagFormat, tagFormat)
+	}
+	return nil
+}
+
+// FormatTag returns the name of the tag for the given library name and
+// version, by substituting them for the {name} and {version} placeholders of
+// tagFormat.
+func FormatTag(tagFormat, name, version string) string {
+	return strings.NewReplacer("{name}", name, "{version}", version).Replace(tagFormat)
+}
+
 // GetCommitHash returns the commit hash pointed at by the given revision,
 // which could be a tag name, a branch name, a relative revision (e.g. "HEAD~").
 func GetCommitHash(ctx context.Context, gitExe, revision string) (string, error) {
@@ -77,6 +148,22 @@ func GetCommitHash(ctx context.Context, gitExe, revision string) (string, error)
 	return strings.TrimSpace(output), err
 }
 
+// TagExists reports whether the repository has a tag named tagName. An error
+// is returned only if git fails for another reason than the tag being missing.
+func TagExists(ctx context.Context, gitExe, tagName string) (bool, error) {
+	err := command.Run(ctx, gitExe, "show-ref", "--verify", "--quiet", "refs/tags/"+tagName)
+	if err == nil {
+		return true, nil
+	}
+	// show-ref --verify exits with status 1 when the ref does not exist, and
+	// with another status if it fails.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check whether tag %s exists: %w", tagName, err)
+}
+
 // FilesChangedSince returns the files changed since the given git ref.
 func FilesChangedSince(ctx context.Context, gitExe, ref string, ignoredChanges []string) ([]string, error) {
 	output, err := command.Output(ctx, gitExe, "diff", "--name-only", ref)
@@ -86,6 +173,106 @@ func FilesChangedSince(ctx context.Context, gitExe, ref string, ignoredChanges [
 	return filesFilter(ignoredChanges, strings.Split(output, "\n")), nil
 }
 
+// FilesChangedSinceInDir returns the files matching the given path patterns
+// that changed between the given git ref and HEAD, in the repository at dir.
+// Changes in the working tree are ignored. If patterns is empty, all changed
+// files are returned.
+//
+// Patterns use [path.Match] syntax, extended so that a "**" element matches
+// zero or more path elements. A pattern that matches a directory also matches
+// every file below it, so plain paths such as "google/cloud/foo/v1" select
+// everything under that directory.
+func FilesChangedSinceInDir(ctx context.Context, gitExe, dir, ref string, patterns []string) ([]string, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+	}
+	args := append([]string{"-C", dir, "diff", "-z", "--name-only", ref, "HEAD", "--"}, pathspecs(patterns)...)
+	output, err := command.Output(ctx, gitExe, args...)
+	if err != nil {
+		if shallowErr := checkShallowHistory(ctx, gitExe, dir, ref); shallowErr != nil {
+			return nil, shallowErr
+		}
+		return nil, fmt.Errorf("failed to get files changed since ref %s in %s: %w", ref, dir, err)
+	}
+	files := []string{}
+	for name := range strings.SplitSeq(output, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	if len(patterns) == 0 {
+		return files, nil
+	}
+	return slices.DeleteFunc(files, func(name string) bool {
+		return !slices.ContainsFunc(patterns, func(pattern string) bool {
+			return matchPath(pattern, name)
+		})
+	}), nil
+}
+
+// checkShallowHistory returns an error wrapping [ErrShallowHistory] if the
+// repository at dir is a shallow clone that does not contain ref.
+func checkShallowHistory(ctx context.Context, gitExe, dir, ref string) error {
+	output, err := command.Output(ctx, gitExe, "-C", dir, "rev-parse", "--is-shallow-repository")
+	if err != nil || strings.TrimSpace(output) != "true" {
+		return nil
+	}
+	if err := command.Run(ctx, gitExe, "-C", dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrShallowHistory, ref)
+}
+
+// pathspecs returns the literal directory prefixes of patterns, which are
+// used to narrow the git diff before the patterns are matched. It returns nil
+// if any pattern starts with a wildcard, as the whole tree must be considered.
+func pathspecs(patterns []string) []string {
+	var specs []string
+	for _, pattern := range patterns {
+		var literal []string
+		for _, elem := range strings.Split(strings.Trim(pattern, "/"), "/") {
+			if strings.ContainsAny(elem, `*?[\`) {
+				break
+			}
+			literal = append(literal, elem)
+		}
+		if len(literal) == 0 {
+			return nil
+		}
+		specs = append(specs, strings.Join(literal, "/"))
+	}
+	return specs
+}
+
+// matchPath reports whether name, or one of its parent directories, matches
+// pattern. See [FilesChangedSinceInDir] for the pattern syntax.
+func matchPath(pattern, name string) bool {
+	return matchElems(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := range len(name) + 1 {
+			if matchElems(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchElems(pattern[1:], name[1:])
+}
+
 func filesFilter(ignoredChanges []string, files []string) []string {
 	var patterns []gitignore.Pattern
 	for _, p := range ignoredChanges {
@@ -112,6 +299,12 @@ func IsNewFile(ctx context.Context, gitExe, ref, name string) bool {
 	return strings.HasPrefix(output, " create mode ")
 }
 
+// IsWorkTree reports whether dir is inside a git working tree.
+func IsWorkTree(ctx context.Context, gitExe, dir string) bool {
+	output, err := command.Output(ctx, gitExe, "-C", dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(output) == "true"
+}
+
 // CheckVersion checks that the git version command can run.
 func CheckVersion(ctx context.Context, gitExe string) error {
 	return command.Run(ctx, gitExe, "--version")
@@ -164,6 +357,25 @@ func FindCommitsForPath(ctx context.Context, gitExe, path string) ([]string, err
 	return strings.Fields(output), nil
 }
 
+// CommitMessagesSince returns the full messages of the commits after ref that
+// affect the given path, latest commit first.
+func CommitMessagesSince(ctx context.Context, gitExe, ref, path string) ([]string, error) {
+	output, err := command.Output(ctx, gitExe, "log", "--format=%B%x00", ref+"..HEAD", "--", path)
+	if err != nil {
+		if shallowErr := checkShallowHistory(ctx, gitExe, ".", ref); shallowErr != nil {
+			return nil, shallowErr
+		}
+		return nil, fmt.Errorf("failed to get commit messages since ref %s for path %s: %w", ref, path, err)
+	}
+	var messages []string
+	for _, message := range strings.Split(output, "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
 // Checkout checks out the given revision. If revision is a commit rather than a
 // branch, this will leave the repository with a detached head. If revision is the
 // name of a valid path, that file is checked out instead. (Git does not provide a
@@ -185,3 +397,63 @@ func GetCommitSubject(ctx context.Context, gitExe, revision string) (string, err
 	}
 	return strings.TrimSuffix(output, "\n"), nil
 }
+
+// IsAncestor reports whether the commit ancestor is reachable from the commit
+// descendant in the repository at dir. A commit counts as its own ancestor. If
+// either revision is not a known commit, an error wrapping
+// [ErrUnknownRevision] is returned, or one wrapping [ErrShallowHistory] if the
+// repository is a shallow clone that may be missing it.
+func IsAncestor(ctx context.Context, gitExe, dir, ancestor, descendant string) (bool, error) {
+	for _, revision := range []string{ancestor, descendant} {
+		if err := command.Run(ctx, gitExe, "-C", dir, "rev-parse", "--verify", "--quiet", revision+"^{commit}"); err != nil {
+			if shallowErr := checkShallowHistory(ctx, gitExe, dir, revision); shallowErr != nil {
+				return false, shallowErr
+			}
+			return false, fmt.Errorf("%w: %s in %s", ErrUnknownRevision, revision, dir)
+		}
+	}
+	err := command.Run(ctx, gitExe, "-C", dir, "merge-base", "--is-ancestor", ancestor, descendant)
+	if err == nil {
+		return true, nil
+	}
+	// merge-base --is-ancestor exits with status 1 when the answer is no,
+	// and with another status if it fails.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check whether %s is an ancestor of %s: %w", ancestor, descendant, err)
+}
+
+// FastForward fetches the upstream branch of the current branch of the
+// repository in dir and fast-forwards the current branch to it. The working
+// tree must be clean. It returns [ErrBranchDiverged] if the current branch has
+// commits that are not in its upstream branch; a branch that is only ahead of
+// its upstream branch is left unchanged.
+func FastForward(ctx context.Context, gitExe, dir string) error {
+	output, err := command.Output(ctx, gitExe, "-C", dir, "status", "--porcelain")
+	if err != nil {
+		return fmt.Errorf("failed to check git status: %w", err)
+	}
+	if len(output) > 0 {
+		return fmt.Errorf("%w: %s", ErrGitStatusUnclean, dir)
+	}
+	upstream, err := command.Output(ctx, gitExe, "-C", dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNoUpstream, dir)
+	}
+	upstream = strings.TrimSpace(upstream)
+	if err := command.Run(ctx, gitExe, "-C", dir, "fetch"); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", upstream, err)
+	}
+	if command.Run(ctx, gitExe, "-C", dir, "merge-base", "--is-ancestor", "@{upstream}", "HEAD") == nil {
+		return nil
+	}
+	if command.Run(ctx, gitExe, "-C", dir, "merge-base", "--is-ancestor", "HEAD", "@{upstream}") != nil {
+		return fmt.Errorf("%w: %s in %s", ErrBranchDiverged, upstream, dir)
+	}
+	if err := command.Run(ctx, gitExe, "-C", dir, "merge", "--ff-only", "@{upstream}"); err != nil {
+		return fmt.Errorf("failed to fast-forward to %s: %w", upstream, err)
+	}
+	return nil
+}
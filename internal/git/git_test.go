@@ -19,6 +19,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -123,6 +124,141 @@ func TestFilesBadRef(t *testing.T) {
 	}
 }
 
+func TestFilesChangedSinceInDir(t *testing.T) {
+	const wantTag = "release-2003-04-05"
+	remoteDir := testhelper.SetupRepoWithChange(t, wantTag)
+	spaced := path.Join("src", "storage", "read me.md")
+	if err := os.WriteFile(spaced, []byte("# storage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", spaced)
+	testhelper.RunGit(t, "commit", "-m", "docs: add readme")
+	// Uncommitted changes are not reported.
+	uncommitted := path.Join("src", "generated", "cloud", "secretmanager", "v1", "Cargo.toml")
+	if err := os.WriteFile(uncommitted, []byte("# changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	for _, test := range []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name:  "changed path",
+			paths: []string{"src/storage"},
+			want:  []string{spaced, path.Join("src", "storage", "src", "lib.rs")},
+		},
+		{
+			name:  "all paths",
+			paths: nil,
+			want:  []string{spaced, path.Join("src", "storage", "src", "lib.rs")},
+		},
+		{
+			name:  "unchanged path",
+			paths: []string{"src/generated"},
+			want:  []string{},
+		},
+		{
+			name:  "double star pattern",
+			paths: []string{"src/**/lib.rs"},
+			want:  []string{path.Join("src", "storage", "src", "lib.rs")},
+		},
+		{
+			name:  "wildcard directory",
+			paths: []string{"*/storage"},
+			want:  []string{spaced, path.Join("src", "storage", "src", "lib.rs")},
+		},
+		{
+			name:  "unmatched pattern",
+			paths: []string{"src/*/Cargo.toml"},
+			want:  []string{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := FilesChangedSinceInDir(t.Context(), command.Git, remoteDir, wantTag, test.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilesChangedSinceInDir_Error(t *testing.T) {
+	remoteDir := testhelper.SetupRepo(t)
+	if got, err := FilesChangedSinceInDir(t.Context(), command.Git, remoteDir, "--invalid--", nil); err == nil {
+		t.Errorf("expected an error with invalid ref, got=%v", got)
+	}
+	if got, err := FilesChangedSinceInDir(t.Context(), command.Git, remoteDir, "HEAD", []string{"src/["}); err == nil {
+		t.Errorf("expected an error with invalid pattern, got=%v", got)
+	}
+}
+
+func TestFilesChangedSinceInDir_ShallowHistory(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	const wantTag = "release-2003-04-05"
+	remoteDir := testhelper.SetupRepoWithChange(t, wantTag)
+	before, err := GetCommitHash(t.Context(), command.Git, wantTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	testhelper.RunGit(t, "clone", "--depth=1", "file://"+remoteDir, cloneDir)
+	_, err = FilesChangedSinceInDir(t.Context(), command.Git, cloneDir, before, nil)
+	if !errors.Is(err, ErrShallowHistory) {
+		t.Errorf("want error %v, got %v", ErrShallowHistory, err)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"google/cloud/foo", "google/cloud/foo/v1/foo.proto", true},
+		{"google/cloud/foo/", "google/cloud/foo/v1/foo.proto", true},
+		{"google/cloud/foo", "google/cloud/foobar/v1/foo.proto", false},
+		{"google/cloud/foo/**", "google/cloud/foo/v1/foo.proto", true},
+		{"google/cloud/foo/**", "google/cloud/bar/v1/bar.proto", false},
+		{"google/**/v1", "google/cloud/foo/v1/foo.proto", true},
+		{"google/**/v1", "google/v1/foo.proto", true},
+		{"google/**/v1", "google/cloud/foo/v2/foo.proto", false},
+		{"google/cloud/*/v1", "google/cloud/foo/v1/foo.proto", true},
+		{"google/cloud/*/v1", "google/cloud/foo/bar/v1/foo.proto", false},
+		{"**/*.proto", "google/cloud/foo/v1/foo.proto", true},
+		{"**/*.proto", "google/cloud/foo/v1/BUILD.bazel", false},
+	} {
+		t.Run(test.pattern+"_"+test.name, func(t *testing.T) {
+			if got := matchPath(test.pattern, test.name); got != test.want {
+				t.Errorf("matchPath(%q, %q) = %v, want %v", test.pattern, test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPathspecs(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"literal", []string{"google/cloud/foo"}, []string{"google/cloud/foo"}},
+		{"glob", []string{"google/cloud/*/v1", "google/api/**"}, []string{"google/cloud", "google/api"}},
+		{"leading wildcard", []string{"google/cloud/foo", "**/*.proto"}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := pathspecs(test.patterns)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestFilterNoFilter(t *testing.T) {
 	t.Parallel()
 	input := []string{
@@ -333,6 +469,26 @@ func TestShowFileAtRevision_Error(t *testing.T) {
 	}
 }
 
+func TestIsWorkTree(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	repoDir := testhelper.SetupRepo(t)
+	for _, test := range []struct {
+		name string
+		dir  string
+		want bool
+	}{
+		{name: "repository", dir: repoDir, want: true},
+		{name: "plain directory", dir: t.TempDir(), want: false},
+		{name: "missing directory", dir: filepath.Join(t.TempDir(), "missing"), want: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsWorkTree(t.Context(), command.Git, test.dir); got != test.want {
+				t.Errorf("IsWorkTree(%q) = %v, want %v", test.dir, got, test.want)
+			}
+		})
+	}
+}
+
 func TestCheckVersion(t *testing.T) {
 	t.Parallel()
 	testhelper.RequireCommand(t, command.Git)
@@ -418,6 +574,58 @@ func TestFindCommitsForPath_Error(t *testing.T) {
 	}
 }
 
+func TestCommitMessagesSince(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	const tagName = "v1.0.0"
+	testhelper.Setup(t, testhelper.SetupOptions{
+		Tags:        []string{tagName},
+		WithChanges: []string{testhelper.ReadmeFile},
+	})
+	if err := os.WriteFile("other.txt", []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", "other.txt")
+	testhelper.RunGit(t, "commit", "-m", "fix: other\n\nBREAKING CHANGE: removed something")
+	for _, test := range []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "README file with changes",
+			path: testhelper.ReadmeFile,
+			want: []string{"feat: changed file(s)"},
+		},
+		{
+			name: "whole repository",
+			path: ".",
+			want: []string{"fix: other\n\nBREAKING CHANGE: removed something", "feat: changed file(s)"},
+		},
+		{
+			name: "unchanged path",
+			path: "this/path/does/not/exist",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CommitMessagesSince(t.Context(), command.Git, tagName, test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommitMessagesSince_Error(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	testhelper.SetupRepo(t)
+	if _, err := CommitMessagesSince(t.Context(), command.Git, "invalid-ref", "."); err == nil {
+		t.Errorf("expected error for a non-existent ref, but did not get one")
+	}
+}
+
 func TestCheckout(t *testing.T) {
 	testhelper.RequireCommand(t, command.Git)
 	opts := testhelper.SetupOptions{
@@ -513,6 +721,101 @@ func TestTag_Error(t *testing.T) {
 	}
 }
 
+func TestTags(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	testhelper.Setup(t, testhelper.SetupOptions{
+		WithChanges: []string{testhelper.ReadmeFile},
+	})
+	first, err := GetCommitHash(t.Context(), command.Git, "HEAD~")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := GetCommitHash(t.Context(), command.Git, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "tag", "lightweight", first)
+	testhelper.RunGit(t, "tag", "-a", "-m", "annotated tag", "annotated", head)
+
+	got, err := Tags(t.Context(), command.Git)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TagRef{
+		{Name: "annotated", Commit: head},
+		{Name: "lightweight", Commit: first},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTagExists(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	testhelper.Setup(t, testhelper.SetupOptions{
+		Tags: []string{"lib/v1.0.0"},
+	})
+	testhelper.RunGit(t, "branch", "lib/v2.0.0")
+	for _, test := range []struct {
+		tagName string
+		want    bool
+	}{
+		{tagName: "lib/v1.0.0", want: true},
+		{tagName: "lib/v1.1.0", want: false},
+		// Only tags count, not branches of the same name.
+		{tagName: "lib/v2.0.0", want: false},
+	} {
+		t.Run(test.tagName, func(t *testing.T) {
+			got, err := TagExists(t.Context(), command.Git, test.tagName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("TagExists(%q) = %t, want %t", test.tagName, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTagExists_Error(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	t.Chdir(t.TempDir())
+	if _, err := TagExists(t.Context(), command.Git, "lib/v1.0.0"); err == nil {
+		t.Error("TagExists() outside a repository: wanted an error; got none")
+	}
+}
+
+func TestFormatTag(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		want   string
+	}{
+		{format: "{name}/v{version}", want: "google-cloud/storage/v1.2.3"},
+		{format: "{name}-{version}", want: "google-cloud/storage-1.2.3"},
+		{format: "v{version}-{name}", want: "v1.2.3-google-cloud/storage"},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			if err := ValidateTagFormat(test.format); err != nil {
+				t.Fatal(err)
+			}
+			got := FormatTag(test.format, "google-cloud/storage", "1.2.3")
+			if got != test.want {
+				t.Errorf("FormatTag(%q) = %q, want %q", test.format, got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateTagFormat_Error(t *testing.T) {
+	for _, format := range []string{"", "v{version}", "{name}", "{name}/v{ver}"} {
+		t.Run(format, func(t *testing.T) {
+			if err := ValidateTagFormat(format); !errors.Is(err, ErrInvalidTagFormat) {
+				t.Errorf("ValidateTagFormat(%q) error = %v, want %v", format, err, ErrInvalidTagFormat)
+			}
+		})
+	}
+}
+
 func TestGetCommitHash(t *testing.T) {
 	testhelper.RequireCommand(t, command.Git)
 	opts := testhelper.SetupOptions{
@@ -597,3 +900,165 @@ func TestGetCommitSubject_Error(t *testing.T) {
 		t.Fatal("wanted an error; got none")
 	}
 }
+
+func TestIsAncestor(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	const wantTag = "release-2003-04-05"
+	testhelper.SetupRepoWithChange(t, wantTag)
+	testhelper.RunGit(t, "checkout", "-b", "other", wantTag)
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "other change")
+	for _, test := range []struct {
+		name       string
+		ancestor   string
+		descendant string
+		want       bool
+	}{
+		{name: "ancestor", ancestor: wantTag, descendant: "main", want: true},
+		{name: "same commit", ancestor: "main", descendant: "main", want: true},
+		{name: "descendant", ancestor: "main", descendant: wantTag, want: false},
+		{name: "diverged", ancestor: "other", descendant: "main", want: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := IsAncestor(t.Context(), command.Git, ".", test.ancestor, test.descendant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("IsAncestor(%q, %q) = %t, want %t", test.ancestor, test.descendant, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsAncestor_Error(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	const wantTag = "release-2003-04-05"
+	remoteDir := testhelper.SetupRepoWithChange(t, wantTag)
+	before, err := GetCommitHash(t.Context(), command.Git, wantTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	testhelper.RunGit(t, "clone", "--depth=1", "file://"+remoteDir, cloneDir)
+	for _, test := range []struct {
+		name       string
+		dir        string
+		ancestor   string
+		descendant string
+		wantErr    error
+	}{
+		{
+			name:       "unknown ancestor",
+			dir:        remoteDir,
+			ancestor:   "0123456789abcdef0123456789abcdef01234567",
+			descendant: "HEAD",
+			wantErr:    ErrUnknownRevision,
+		},
+		{
+			name:       "unknown descendant",
+			dir:        remoteDir,
+			ancestor:   "HEAD",
+			descendant: "does-not-exist",
+			wantErr:    ErrUnknownRevision,
+		},
+		{
+			name:       "shallow clone",
+			dir:        cloneDir,
+			ancestor:   before,
+			descendant: "HEAD",
+			wantErr:    ErrShallowHistory,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := IsAncestor(t.Context(), command.Git, test.dir, test.ancestor, test.descendant)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("IsAncestor() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		remoteCommit bool
+		localCommit  bool
+	}{
+		{name: "behind", remoteCommit: true},
+		{name: "up to date"},
+		{name: "ahead", localCommit: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			remoteDir := testhelper.SetupRepo(t)
+			testhelper.CloneRepository(t, remoteDir)
+			if test.remoteCommit {
+				testhelper.RunGit(t, "-C", remoteDir, "commit", "--allow-empty", "-m", "remote change")
+			}
+			if test.localCommit {
+				testhelper.RunGit(t, "commit", "--allow-empty", "-m", "local change")
+			}
+			want, err := GetCommitHash(t.Context(), command.Git, "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.remoteCommit {
+				if want, err = command.Output(t.Context(), command.Git, "-C", remoteDir, "rev-parse", "HEAD"); err != nil {
+					t.Fatal(err)
+				}
+				want = strings.TrimSpace(want)
+			}
+			if err := FastForward(t.Context(), command.Git, "."); err != nil {
+				t.Fatal(err)
+			}
+			got, err := GetCommitHash(t.Context(), command.Git, "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("HEAD = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFastForward_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		setup   func(t *testing.T, remoteDir string)
+		wantErr error
+	}{
+		{
+			name: "diverged",
+			setup: func(t *testing.T, remoteDir string) {
+				testhelper.RunGit(t, "-C", remoteDir, "commit", "--allow-empty", "-m", "remote change")
+				testhelper.RunGit(t, "commit", "--allow-empty", "-m", "local change")
+			},
+			wantErr: ErrBranchDiverged,
+		},
+		{
+			name: "dirty",
+			setup: func(t *testing.T, remoteDir string) {
+				if err := os.WriteFile("untracked.txt", []byte("untracked\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrGitStatusUnclean,
+		},
+		{
+			name: "detached",
+			setup: func(t *testing.T, remoteDir string) {
+				testhelper.RunGit(t, "checkout", "--detach")
+			},
+			wantErr: ErrNoUpstream,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			remoteDir := testhelper.SetupRepo(t)
+			testhelper.CloneRepository(t, remoteDir)
+			test.setup(t, remoteDir)
+			if err := FastForward(t.Context(), command.Git, "."); !errors.Is(err, test.wantErr) {
+				t.Errorf("FastForward() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/command"
+)
+
+// ErrStaleLease is returned by [PushWithLease] when the remote branch no
+// longer points to the expected commit.
+var ErrStaleLease = errors.New("remote branch changed since it was last read")
+
+// RemoteBranches returns the commit of each branch of remote, keyed by branch
+// name. The commands run with the environment variables in env added to the
+// environment of the calling process.
+func RemoteBranches(ctx context.Context, gitExe string, env map[string]string, remote string) (map[string]string, error) {
+	output, err := command.OutputWithEnv(ctx, env, gitExe, "ls-remote", "--heads", remote)
+	if err != nil {
+		return nil, err
+	}
+	branches := map[string]string{}
+	for line := range strings.Lines(output) {
+		hash, ref, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		branches[strings.TrimPrefix(ref, "refs/heads/")] = hash
+	}
+	return branches, nil
+}
+
+// PushWithLease pushes HEAD to branch of remote, and sets it as the upstream
+// branch of the current branch. The remote branch is replaced, even if HEAD
+// does not descend from it, but only if it still points to expected; an empty
+// expected requires that the remote branch does not exist. This avoids
+// overwriting commits pushed to the branch by someone else. If the remote
+// branch has moved, [ErrStaleLease] is returned.
+func PushWithLease(ctx context.Context, gitExe string, env map[string]string, remote, branch, expected string) error {
+	ref := "refs/heads/" + branch
+	err := command.RunWithEnv(ctx, env, gitExe, "push", "--set-upstream",
+		fmt.Sprintf("--force-with-lease=%s:%s", ref, expected), remote, "HEAD:"+ref)
+	if err == nil {
+		return nil
+	}
+	branches, lsErr := RemoteBranches(ctx, gitExe, env, remote)
+	if lsErr == nil && branches[branch] != expected {
+		return fmt.Errorf("%w: %s of %s is at %q, want %q", ErrStaleLease, branch, remote, branches[branch], expected)
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+const testBranch = "update"
+
+// setupPushRepo creates a repository with one commit and a bare repository
+// as its origin remote, and returns the path of the bare repository.
+func setupPushRepo(t *testing.T) string {
+	t.Helper()
+	testhelper.RequireCommand(t, command.Git)
+	remote := t.TempDir()
+	testhelper.RunGit(t, "init", "--bare", remote)
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	testhelper.RunGit(t, "remote", "add", "origin", remote)
+	testhelper.RunGit(t, "checkout", "-b", testBranch)
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "first run")
+	return remote
+}
+
+func remoteSubject(t *testing.T, remote string) string {
+	t.Helper()
+	got, err := command.Output(t.Context(), command.Git, "-C", remote, "log", "-1", "--format=%s", testBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(got)
+}
+
+func TestRemoteBranches(t *testing.T) {
+	remote := setupPushRepo(t)
+	got, err := RemoteBranches(t.Context(), command.Git, nil, "origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	testhelper.RunGit(t, "push", "origin", testBranch)
+	head, err := GetCommitHash(t.Context(), command.Git, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = RemoteBranches(t.Context(), command.Git, nil, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{testBranch: head}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPushWithLease(t *testing.T) {
+	remote := setupPushRepo(t)
+	if err := PushWithLease(t.Context(), command.Git, nil, "origin", testBranch, ""); err != nil {
+		t.Fatal(err)
+	}
+	branches, err := RemoteBranches(t.Context(), command.Git, nil, "origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A later run starts the branch again, so it does not descend from the
+	// pushed commit.
+	testhelper.RunGit(t, "commit", "--amend", "--allow-empty", "-m", "second run")
+	if err := PushWithLease(t.Context(), command.Git, nil, "origin", testBranch, branches[testBranch]); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("second run", remoteSubject(t, remote)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	upstream, err := command.Output(t.Context(), command.Git, "rev-parse", "--abbrev-ref", "@{upstream}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("origin/"+testBranch, strings.TrimSpace(upstream)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPushWithLease_Error(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected func(t *testing.T) string
+	}{
+		{
+			name: "remote branch moved",
+			expected: func(t *testing.T) string {
+				lease, err := GetCommitHash(t.Context(), command.Git, "HEAD")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return lease
+			},
+		},
+		{
+			name:     "remote branch created",
+			expected: func(t *testing.T) string { return "" },
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			remote := setupPushRepo(t)
+			expected := test.expected(t)
+			// Someone else pushes to the branch in the meantime.
+			testhelper.RunGit(t, "commit", "--allow-empty", "-m", "concurrent change")
+			testhelper.RunGit(t, "push", "origin", testBranch)
+			testhelper.RunGit(t, "reset", "--hard", "HEAD~1")
+			testhelper.RunGit(t, "commit", "--amend", "--allow-empty", "-m", "second run")
+
+			err := PushWithLease(t.Context(), command.Git, nil, "origin", testBranch, expected)
+			if !errors.Is(err, ErrStaleLease) {
+				t.Fatalf("PushWithLease() error = %v, want %v", err, ErrStaleLease)
+			}
+			if diff := cmp.Diff("concurrent change", remoteSubject(t, remote)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
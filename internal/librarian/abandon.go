@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	errMissingPullRequest  = errors.New("--pr is required")
+	errPullRequestIsMerged = errors.New("pull request is already merged")
+)
+
+func abandonCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "abandon",
+		Hidden:    true,
+		Usage:     "close a stale release pull request and delete its branch",
+		UsageText: "librarian abandon --pr=<url>",
+		Description: `abandon cleans up a release pull request that will not be merged, for
+example because a newer release pull request supersedes it. It closes the
+pull request named by --pr and deletes its head branch. With --remove-label,
+the release:pending label is also removed, so that the pull request is not
+mistaken for a pending release.
+
+abandon refuses to act on a merged pull request, as its release has already
+happened and must be tagged instead. It also refuses to act on a pull
+request without the release:pending label, so that a mistyped --pr does not
+close an unrelated pull request and delete its branch; --force skips this
+check. A pull request that is already closed, or whose branch is already
+deleted, is left as is, so abandon can be re-run safely.
+
+A GitHub token with write access to the repository is required; see
+librarian doctor for how it is found.
+
+Examples:
+
+	librarian abandon --pr=https://github.com/googleapis/google-cloud-go/pull/123
+	librarian abandon --pr=https://github.com/googleapis/google-cloud-go/pull/123 --remove-label`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "pr",
+				Usage: "`URL` of the release pull request to abandon",
+			},
+			&cli.BoolFlag{
+				Name:  "remove-label",
+				Usage: "remove the " + releasePendingLabel + " label from the pull request",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "abandon the pull request even if it does not have the " + releasePendingLabel + " label",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			url := cmd.String("pr")
+			if url == "" {
+				return errMissingPullRequest
+			}
+			return abandon(ctx, url, cmd.Bool("remove-label"), cmd.Bool("force"))
+		},
+	}
+}
+
+// abandon closes the pull request at url and deletes its head branch, and
+// removes its release:pending label if removeLabel is set. Unless force is
+// set, the pull request must have the release:pending label.
+func abandon(ctx context.Context, url string, removeLabelFlag, force bool) error {
+	ref, err := parsePullRequestURL(url)
+	if err != nil {
+		return err
+	}
+	pr, err := getPullRequest(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if pr.Merged {
+		return fmt.Errorf("%w: #%d", errPullRequestIsMerged, pr.Number)
+	}
+	if !force && !slices.Contains(pr.Labels, releasePendingLabel) {
+		return fmt.Errorf("%w: #%d does not have the %q label, use --force to abandon it anyway", errPullRequestNotReleased, pr.Number, releasePendingLabel)
+	}
+	if pr.State == "open" {
+		if err := closePullRequest(ctx, ref); err != nil {
+			return err
+		}
+		slog.Info("closed pull request", "number", pr.Number)
+	}
+	if pr.HeadRepo == "" {
+		slog.Info("head repository of pull request no longer exists", "number", pr.Number)
+	} else {
+		deleted, err := deleteBranch(ctx, pr.HeadRepo, pr.HeadRef)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			slog.Info("deleted branch", "repo", pr.HeadRepo, "branch", pr.HeadRef)
+		}
+	}
+	if removeLabelFlag && slices.Contains(pr.Labels, releasePendingLabel) {
+		if err := removeLabel(ctx, ref, releasePendingLabel); err != nil {
+			return err
+		}
+		slog.Info("removed label", "number", pr.Number, "label", releasePendingLabel)
+	}
+	return nil
+}
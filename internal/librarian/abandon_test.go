@@ -0,0 +1,197 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// abandonPRURL is on the host of the test server that stands in for the
+// GitHub API, as pull request URLs must be on the host of githubAPI.
+const abandonPRURL = "https://127.0.0.1/googleapis/google-cloud-go/pull/123"
+
+// setupAbandonServer serves body for the pull request of abandonPRURL, and
+// records every request that changes something as "METHOD path body".
+// Branches are reported as missing unless branchExists is set.
+func setupAbandonServer(t *testing.T, body string, branchExists bool) *[]string {
+	t.Helper()
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.URL.Path != "/repos/googleapis/google-cloud-go/pulls/123" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(body))
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath()+" "+string(data))
+		if r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/git/refs/") {
+			if !branchExists {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	original := githubAPI
+	t.Cleanup(func() { githubAPI = original })
+	githubAPI = ts.URL
+	t.Setenv(envGitHubToken, "test-token")
+	return &requests
+}
+
+func TestAbandon(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		body         string
+		branchExists bool
+		removeLabel  bool
+		force        bool
+		want         []string
+	}{
+		{
+			name: "open",
+			body: `{"number": 123, "state": "open", "labels": [{"name": "release:pending"}],
+				"head": {"ref": "librarian/release/main", "repo": {"full_name": "googleapis/google-cloud-go"}}}`,
+			branchExists: true,
+			want: []string{
+				`PATCH /repos/googleapis/google-cloud-go/pulls/123 {"state":"closed"}`,
+				"DELETE /repos/googleapis/google-cloud-go/git/refs/heads/librarian/release/main ",
+			},
+		},
+		{
+			name: "remove label",
+			body: `{"number": 123, "state": "open", "labels": [{"name": "release:pending"}],
+				"head": {"ref": "release", "repo": {"full_name": "octocat/google-cloud-go"}}}`,
+			branchExists: true,
+			removeLabel:  true,
+			want: []string{
+				`PATCH /repos/googleapis/google-cloud-go/pulls/123 {"state":"closed"}`,
+				"DELETE /repos/octocat/google-cloud-go/git/refs/heads/release ",
+				"DELETE /repos/googleapis/google-cloud-go/issues/123/labels/release:pending ",
+			},
+		},
+		{
+			name: "already closed",
+			body: `{"number": 123, "state": "closed", "labels": [{"name": "release:pending"}],
+				"head": {"ref": "release", "repo": {"full_name": "googleapis/google-cloud-go"}}}`,
+			want: []string{
+				"DELETE /repos/googleapis/google-cloud-go/git/refs/heads/release ",
+			},
+		},
+		{
+			name: "force without label",
+			body: `{"number": 123, "state": "closed", "labels": [],
+				"head": {"ref": "release", "repo": {"full_name": "googleapis/google-cloud-go"}}}`,
+			removeLabel: true,
+			force:       true,
+			want: []string{
+				"DELETE /repos/googleapis/google-cloud-go/git/refs/heads/release ",
+			},
+		},
+		{
+			name: "head repository deleted",
+			body: `{"number": 123, "state": "open", "labels": [{"name": "release:pending"}], "head": {"ref": "release", "repo": null}}`,
+			want: []string{
+				`PATCH /repos/googleapis/google-cloud-go/pulls/123 {"state":"closed"}`,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			requests := setupAbandonServer(t, test.body, test.branchExists)
+			if err := abandon(t.Context(), abandonPRURL, test.removeLabel, test.force); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, *requests); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAbandon_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		url     string
+		body    string
+		noToken bool
+		wantErr error
+	}{
+		{
+			name:    "invalid url",
+			url:     "not-a-url",
+			wantErr: errInvalidPullRequestURL,
+		},
+		{
+			name:    "not found",
+			url:     "https://127.0.0.1/googleapis/google-cloud-go/pull/456",
+			wantErr: errPullRequestNotFound,
+		},
+		{
+			name:    "merged",
+			url:     abandonPRURL,
+			body:    `{"number": 123, "state": "closed", "merged": true, "head": {"ref": "release"}}`,
+			wantErr: errPullRequestIsMerged,
+		},
+		{
+			name:    "not a release pull request",
+			url:     abandonPRURL,
+			body:    `{"number": 123, "state": "open", "labels": [{"name": "bug"}], "head": {"ref": "fix"}}`,
+			wantErr: errPullRequestNotReleased,
+		},
+		{
+			name:    "no token",
+			url:     abandonPRURL,
+			body:    `{"number": 123, "state": "open", "labels": [{"name": "release:pending"}], "head": {"ref": "release"}}`,
+			noToken: true,
+			wantErr: errMissingGitHubToken,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			requests := setupAbandonServer(t, test.body, true)
+			if test.noToken {
+				t.Setenv(envGitHubToken, "")
+				t.Setenv(envGitHubTokenFile, "")
+				t.Setenv("PATH", "")
+			}
+			err := abandon(t.Context(), test.url, true, false)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("abandon() error = %v, want %v", err, test.wantErr)
+			}
+			if len(*requests) != 0 {
+				t.Errorf("abandon() sent %q, want no changes", *requests)
+			}
+		})
+	}
+}
+
+func TestAbandonCommand_Error(t *testing.T) {
+	if err := Run(t.Context(), "librarian", "abandon"); !errors.Is(err, errMissingPullRequest) {
+		t.Errorf("Run() error = %v, want %v", err, errMissingPullRequest)
+	}
+}
@@ -18,6 +18,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -34,8 +38,8 @@ import (
 	"github.com/googleapis/librarian/internal/librarian/rust"
 	"github.com/googleapis/librarian/internal/librarian/swift"
 	"github.com/googleapis/librarian/internal/semver"
+	"github.com/googleapis/librarian/internal/serviceconfig"
 	"github.com/googleapis/librarian/internal/sources"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
@@ -44,20 +48,34 @@ var (
 	errLibraryAlreadyExists   = errors.New("library already exists in config")
 	errPreviewAlreadyExists   = errors.New("preview library config already exists")
 	errPreviewRequiresLibrary = errors.New("only APIs with an existing Library can have a Preview")
-	errWrongAPICount          = errors.New("must provide exactly one API path")
+	errPreviewWithLibraryFlag = errors.New("preview APIs cannot be added with --library")
+	errWrongAPICount          = errors.New("must provide exactly one API path, or several with --library")
+	errAPIPathFileWithArgs    = errors.New("--api-path-file cannot be used with API paths or --library")
+	errInvalidAPIPathFile     = errors.New("invalid --api-path-file")
+	errAddFromFileFailed      = errors.New("failed to add APIs from --api-path-file")
+	errServiceConfigNotFound  = errors.New("no service config found")
+	errInvalidCopyrightYear   = errors.New("--copyright-year must be a four-digit year")
+	// copyrightYearRegexp matches a valid --copyright-year.
+	copyrightYearRegexp = regexp.MustCompile(`^\d{4}$`)
 )
 
 func addCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "add",
 		Usage:     "add a new client library",
-		UsageText: "librarian add <api>",
-		Description: `add registers a single API in librarian.yaml.
+		UsageText: "librarian add [--library <name>] <api>...",
+		Description: `add registers a single API in librarian.yaml, or several APIs in one
+library with --library.
 
 The <api> is a path within the configured googleapis source, such as
 "google/cloud/secretmanager/v1". The library name and other defaults are
 derived from the first API path using language-specific rules.
 
+Unless the API is configured in librarian's built-in API list, its directory
+in googleapis must contain a service config, a YAML file with
+"type: google.api.Service". add fails before changing librarian.yaml if it
+is missing.
+
 If the API path should naturally be included in an existing library, and if the
 language supports doing so, that library is modified. Otherwise, a new library
 is created.
@@ -69,46 +87,211 @@ library.
 To add a preview client of an existing library, prefix the API path with
 "preview/".
 
+With --library, all the given APIs are added to the named library, which is
+created if it does not exist. This is used for libraries that aggregate
+several API versions. Adding APIs to an existing library is only supported
+by languages whose libraries may contain several APIs.
+
+New libraries record the current year as their copyright year, which is
+used in the license headers of generated files. The --copyright-year flag
+records another year instead, for example when onboarding a library whose
+code already exists. The year of existing libraries is never changed.
+
+With --api-path-file, the APIs listed in the file are added one at a time,
+as if add was run for each of them. Each line holds an API path, optionally
+followed by a library name to add it to, as with --library. Blank lines and
+lines starting with "#" are ignored. An API that cannot be added does not
+stop the others; a summary of the added and failed APIs is printed at the
+end, and the command fails if any API could not be added.
+
 Examples:
 
 	librarian add google/cloud/secretmanager/v1
 	librarian add preview/google/cloud/secretmanager/v1beta
+	librarian add --library secretmanager google/cloud/secretmanager/v1 google/cloud/secretmanager/v1beta2
+	librarian add --api-path-file new-apis.txt
 
 A typical librarian workflow for adding a new client library is:
 
 	librarian add <api>            # onboard a new API into librarian.yaml
 	librarian generate <library>   # generate the client library`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "library",
+				Usage: "add the APIs to the library `name`, creating it if needed",
+			},
+			&cli.StringFlag{
+				Name:  "api-path-file",
+				Usage: "add each API listed in this `file`, with an optional library name",
+			},
+			&cli.StringFlag{
+				Name:  "copyright-year",
+				Usage: "copyright `year` of new libraries; defaults to the current year",
+			},
+		},
 		Action: func(ctx context.Context, c *cli.Command) error {
 			apis := c.Args().Slice()
-			if len(apis) != 1 {
+			library := c.String("library")
+			copyrightYear := c.String("copyright-year")
+			if copyrightYear != "" && !copyrightYearRegexp.MatchString(copyrightYear) {
+				return fmt.Errorf("%w: %q", errInvalidCopyrightYear, copyrightYear)
+			}
+			if path := c.String("api-path-file"); path != "" {
+				if len(apis) != 0 || library != "" {
+					return errAPIPathFileWithArgs
+				}
+				return runAddFromFile(ctx, c.Root().Writer, c.String("config-file"), path, copyrightYear)
+			}
+			if len(apis) == 0 || len(apis) > 1 && library == "" {
 				return errWrongAPICount
 			}
-			cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			configPath := c.String("config-file")
+			cfg, err := readConfig(configPath)
 			if err != nil {
 				return err
 			}
-			return runAdd(ctx, cfg, apis[0])
+			_, err = runAdd(ctx, configPath, cfg, library, copyrightYear, apis)
+			return err
 		},
 	}
 }
 
-func runAdd(ctx context.Context, cfg *config.Config, api string) error {
-	name, cfg, err := addLibrary(cfg, api)
+// runAdd adds the given APIs to cfg, writes it to configPath, and returns the
+// name of the library they were added to. If library is empty, there must be exactly one API, whose
+// library is derived from its path. Otherwise, the APIs are added to the named
+// library. A new library records copyrightYear, or the current year if it is
+// empty.
+func runAdd(ctx context.Context, configPath string, cfg *config.Config, library, copyrightYear string, apis []string) (string, error) {
+	if err := checkServiceConfigs(ctx, cfg, apis); err != nil {
+		return "", err
+	}
+	var (
+		name string
+		err  error
+	)
+	if library == "" {
+		name, cfg, err = addLibrary(cfg, apis[0], copyrightYear)
+	} else {
+		name, cfg, err = addAPIsToLibrary(cfg, library, copyrightYear, apis)
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
 	cfg, err = resolveDependencies(ctx, cfg, name)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if cfg.Language == config.LanguageGo || cfg.Language == config.LanguagePython || cfg.Language == config.LanguageNodejs {
 		if hasReleasePleaseConfigs(".", cfg) {
 			if err := syncToReleasePlease(".", cfg, name); err != nil {
+				return "", err
+			}
+		}
+	}
+	return name, tidyAndWriteConfig(configPath, cfg)
+}
+
+// checkServiceConfigs returns an error wrapping errServiceConfigNotFound if
+// an API in apis has no service config in googleapis. APIs configured in
+// sdk.yaml, which may legitimately have no service config, and preview APIs,
+// which are checked when their stable API is added, are skipped. googleapis
+// is only fetched if an API needs to be checked.
+func checkServiceConfigs(ctx context.Context, cfg *config.Config, apis []string) error {
+	var googleapisDir string
+	for _, api := range apis {
+		if strings.HasPrefix(api, "preview/") || serviceconfig.HasAPIPath(api, cfg.Language) {
+			continue
+		}
+		if googleapisDir == "" {
+			if cfg.Sources == nil {
+				return ErrMissingGoogleapisSource
+			}
+			dir, err := fetchSource(ctx, cfg.Sources.Googleapis, googleapisRepo)
+			if err != nil {
 				return err
 			}
+			if dir == "" {
+				return ErrMissingGoogleapisSource
+			}
+			googleapisDir = dir
+		}
+		found, err := serviceconfig.Find(googleapisDir, api, cfg.Language)
+		if err != nil {
+			return err
+		}
+		if found.ServiceConfig == "" {
+			return fmt.Errorf("%w for API %s; expected a *.yaml with type: google.api.Service in %s", errServiceConfigNotFound, api, filepath.Join(googleapisDir, api))
+		}
+	}
+	return nil
+}
+
+// apiPathEntry is an API listed in an --api-path-file, with the library to
+// add it to, if any.
+type apiPathEntry struct {
+	api     string
+	library string
+}
+
+// readAPIPathFile reads the API paths and optional library names listed in
+// the file at path, one per line.
+func readAPIPathFile(path string) ([]apiPathEntry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []apiPathEntry
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			entries = append(entries, apiPathEntry{api: fields[0]})
+		case 2:
+			entries = append(entries, apiPathEntry{api: fields[0], library: fields[1]})
+		default:
+			return nil, fmt.Errorf("%w: %s:%d: want an API path and an optional library name, got %q", errInvalidAPIPathFile, path, i+1, line)
 		}
 	}
-	return RunTidyOnConfig(ctx, ".", cfg)
+	return entries, nil
+}
+
+// runAddFromFile adds each API listed in the file at path, reading
+// librarian.yaml afresh for each so that an API that fails to be added does
+// not affect the others. New libraries record copyrightYear, as in [runAdd].
+// It writes a summary of the results to w, and returns an error if any API
+// could not be added.
+func runAddFromFile(ctx context.Context, w io.Writer, configPath, path, copyrightYear string) error {
+	entries, err := readAPIPathFile(path)
+	if err != nil {
+		return err
+	}
+	var failed []error
+	var b strings.Builder
+	for _, entry := range entries {
+		cfg, err := readConfig(configPath)
+		if err != nil {
+			return err
+		}
+		name, err := runAdd(ctx, configPath, cfg, entry.library, copyrightYear, []string{entry.api})
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", entry.api, err))
+			fmt.Fprintf(&b, "failed: %s: %v\n", entry.api, err)
+			continue
+		}
+		fmt.Fprintf(&b, "added: %s to %s\n", entry.api, name)
+	}
+	fmt.Fprintf(&b, "%d added, %d failed\n", len(entries)-len(failed), len(failed))
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %d of %d: %w", errAddFromFileFailed, len(failed), len(entries), errors.Join(failed...))
+	}
+	return nil
 }
 
 func resolveDependencies(ctx context.Context, cfg *config.Config, name string) (*config.Config, error) {
@@ -172,8 +355,9 @@ func deriveLibraryName(language string, api string) string {
 // addLibrary adds a new library to the config based on the provided API.
 // It returns the name of the new or updated library, the updated config, and an
 // error if the API cannot be added (e.g. because it already exists, or the new
-// API is a preview and there is no corresponding stable library).
-func addLibrary(cfg *config.Config, apiPath string) (string, *config.Config, error) {
+// API is a preview and there is no corresponding stable library). A new
+// library records copyrightYear, as in [addNewLibrary].
+func addLibrary(cfg *config.Config, apiPath, copyrightYear string) (string, *config.Config, error) {
 	stablePath, isPreview := strings.CutPrefix(apiPath, "preview/")
 	api := &config.API{Path: stablePath}
 	existingLib := findExistingLibraryForAPI(cfg, stablePath)
@@ -186,7 +370,47 @@ func addLibrary(cfg *config.Config, apiPath string) (string, *config.Config, err
 	if existingLib != nil {
 		return updateExistingLibrary(cfg, existingLib, api)
 	}
-	return addNewLibrary(cfg, api)
+	return addNewLibrary(cfg, "", copyrightYear, api)
+}
+
+// addAPIsToLibrary adds the APIs with the given paths to the library with the
+// given name, creating the library if it does not exist. It returns the name
+// of the library and the updated config. A new library records
+// copyrightYear, as in [addNewLibrary].
+func addAPIsToLibrary(cfg *config.Config, name, copyrightYear string, apiPaths []string) (string, *config.Config, error) {
+	var apis []*config.API
+	for _, apiPath := range apiPaths {
+		if strings.HasPrefix(apiPath, "preview/") {
+			return "", nil, fmt.Errorf("%w: %s", errPreviewWithLibraryFlag, apiPath)
+		}
+		if slices.ContainsFunc(apis, func(a *config.API) bool { return a.Path == apiPath }) {
+			return "", nil, fmt.Errorf("%w: %s given more than once", errAPIAlreadyExists, apiPath)
+		}
+		apis = append(apis, &config.API{Path: apiPath})
+	}
+	existingLib := findLibraryByName(cfg, name)
+	if existingLib == nil {
+		return addNewLibrary(cfg, name, copyrightYear, apis...)
+	}
+	for _, api := range apis {
+		var err error
+		if _, cfg, err = updateExistingLibrary(cfg, existingLib, api); err != nil {
+			return "", nil, err
+		}
+	}
+	return existingLib.Name, cfg, nil
+}
+
+// findLibraryByName returns the library with the given name in cfg, or nil
+// if there is none.
+func findLibraryByName(cfg *config.Config, name string) *config.Library {
+	// Not using FindLibrary as the error handling becomes awkward.
+	for _, library := range cfg.Libraries {
+		if library.Name == name {
+			return library
+		}
+	}
+	return nil
 }
 
 // findExistingLibraryForAPI determines if an existing library in cfg is
@@ -202,14 +426,7 @@ func findExistingLibraryForAPI(cfg *config.Config, apiPath string) *config.Libra
 	case config.LanguagePython:
 		return python.FindExistingLibraryForNewAPI(cfg.Libraries, apiPath)
 	default:
-		name := deriveLibraryName(cfg.Language, apiPath)
-		// Not using FindLibrary as the error handling becomes awkward.
-		for _, library := range cfg.Libraries {
-			if library.Name == name {
-				return library
-			}
-		}
-		return nil
+		return findLibraryByName(cfg, deriveLibraryName(cfg.Language, apiPath))
 	}
 }
 
@@ -234,13 +451,20 @@ func addPreviewLibrary(cfg *config.Config, lib *config.Library, api *config.API)
 	return lib.Name, cfg, nil
 }
 
-// addNewLibrary adds a new library to the config.
-func addNewLibrary(cfg *config.Config, api *config.API) (string, *config.Config, error) {
-	name := deriveLibraryName(cfg.Language, api.Path)
+// addNewLibrary adds a new library with the given APIs to the config. If name
+// is empty, it is derived from the first API. The library records
+// copyrightYear, or the current year if it is empty.
+func addNewLibrary(cfg *config.Config, name, copyrightYear string, apis ...*config.API) (string, *config.Config, error) {
+	if name == "" {
+		name = deriveLibraryName(cfg.Language, apis[0].Path)
+	}
+	if copyrightYear == "" {
+		copyrightYear = strconv.Itoa(time.Now().Year())
+	}
 	lib := &config.Library{
 		Name:          name,
-		CopyrightYear: strconv.Itoa(time.Now().Year()),
-		APIs:          []*config.API{api},
+		CopyrightYear: copyrightYear,
+		APIs:          apis,
 	}
 	switch cfg.Language {
 	case config.LanguageGo:
@@ -15,7 +15,9 @@
 package librarian
 
 import (
+	"bytes"
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -106,7 +108,7 @@ func TestAddLibraryCommand(t *testing.T) {
 			if err := yaml.Write(config.LibrarianYAML, cfg); err != nil {
 				t.Fatal(err)
 			}
-			err = runAdd(t.Context(), cfg, test.apiPath)
+			_, err = runAdd(t.Context(), config.LibrarianYAML, cfg, "", "", []string{test.apiPath})
 			if test.wantError != nil {
 				if !errors.Is(err, test.wantError) {
 					t.Errorf("expected error %v, got %v", test.wantError, err)
@@ -154,6 +156,15 @@ func TestAddCommand(t *testing.T) {
 			args:     []string{"google/cloud/secretmanager/v1"},
 			wantName: "google-cloud-secretmanager-v1",
 		},
+		{
+			name: "multiple args with library",
+			args: []string{
+				"--library", "secretmanager",
+				"google/cloud/secretmanager/v1",
+				"google/cloud/secretmanager/v1beta2",
+			},
+			wantName: "secretmanager",
+		},
 		{
 			name: "multiple args",
 			args: []string{
@@ -204,6 +215,276 @@ func TestAddCommand(t *testing.T) {
 	}
 }
 
+func TestAddCommand_ConfigFile(t *testing.T) {
+	googleapisDir, err := filepath.Abs("../testdata/googleapis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	configFile := "custom.yaml"
+	cfg := sample.Config()
+	cfg.Default.Output = "output"
+	cfg.Libraries = nil
+	cfg.Sources.Googleapis.Dir = googleapisDir
+	if err := yaml.Write(configFile, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "--config-file", configFile, "add", "google/cloud/secretmanager/v1"); err != nil {
+		t.Fatal(err)
+	}
+	gotCfg, err := yaml.Read[config.Config](configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindLibrary(gotCfg, "google-cloud-secretmanager-v1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(config.LibrarianYAML); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %s to not be created, got %v", config.LibrarianYAML, err)
+	}
+}
+
+func TestAddCommand_CopyrightYear(t *testing.T) {
+	googleapisDir, err := filepath.Abs("../testdata/googleapis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name             string
+		language         string
+		initialLibraries []*config.Library
+		args             []string
+		wantName         string
+		wantYear         string
+	}{
+		{
+			name:     "default",
+			args:     []string{"google/cloud/secretmanager/v1"},
+			wantName: "google-cloud-secretmanager-v1",
+			wantYear: strconv.Itoa(time.Now().Year()),
+		},
+		{
+			name:     "override",
+			args:     []string{"--copyright-year=2019", "google/cloud/secretmanager/v1"},
+			wantName: "google-cloud-secretmanager-v1",
+			wantYear: "2019",
+		},
+		{
+			// Fake libraries contain a single API, so use a language that
+			// supports adding APIs to an existing library.
+			name:     "existing library keeps its year",
+			language: config.LanguageNodejs,
+			initialLibraries: []*config.Library{
+				{
+					Name:          "secretmanager",
+					CopyrightYear: "2021",
+					APIs:          []*config.API{{Path: "google/cloud/secretmanager/v1"}},
+				},
+			},
+			args:     []string{"--copyright-year=2019", "--library=secretmanager", "google/cloud/secretmanager/v1beta2"},
+			wantName: "secretmanager",
+			wantYear: "2021",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			cfg := sample.Config()
+			cfg.Default.Output = "output"
+			cfg.Libraries = test.initialLibraries
+			cfg.Sources.Googleapis.Dir = googleapisDir
+			if test.language != "" {
+				cfg.Language = test.language
+			}
+			if err := yaml.Write(config.LibrarianYAML, cfg); err != nil {
+				t.Fatal(err)
+			}
+			args := append([]string{"librarian", "add"}, test.args...)
+			if err := Run(t.Context(), args...); err != nil {
+				t.Fatal(err)
+			}
+			gotCfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lib, err := FindLibrary(gotCfg, test.wantName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.wantYear, lib.CopyrightYear); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddCommand_CopyrightYear_Error(t *testing.T) {
+	for _, year := range []string{"19", "twenty", "20190"} {
+		t.Run(year, func(t *testing.T) {
+			err := Run(t.Context(), "librarian", "add", "--copyright-year="+year, "google/cloud/secretmanager/v1")
+			if !errors.Is(err, errInvalidCopyrightYear) {
+				t.Errorf("want error %v, got %v", errInvalidCopyrightYear, err)
+			}
+		})
+	}
+}
+
+func TestRunAddFromFile(t *testing.T) {
+	googleapisDir, err := filepath.Abs("../testdata/googleapis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	cfg := sample.Config()
+	cfg.Default.Output = "output"
+	cfg.Libraries = nil
+	cfg.Sources.Googleapis.Dir = googleapisDir
+	if err := yaml.Write(config.LibrarianYAML, cfg); err != nil {
+		t.Fatal(err)
+	}
+	content := `# APIs to onboard
+google/cloud/secretmanager/v1
+google/cloud/secretmanager/v1
+
+google/cloud/secrets/v1beta1 secrets
+`
+	if err := os.WriteFile("apis.txt", []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = runAddFromFile(t.Context(), &out, config.LibrarianYAML, "apis.txt", "")
+	if !errors.Is(err, errAddFromFileFailed) || !errors.Is(err, errLibraryAlreadyExists) {
+		t.Errorf("want errors %v and %v, got %v", errAddFromFileFailed, errLibraryAlreadyExists, err)
+	}
+	want := `added: google/cloud/secretmanager/v1 to google-cloud-secretmanager-v1
+failed: google/cloud/secretmanager/v1: ` + errLibraryAlreadyExists.Error() + `: google-cloud-secretmanager-v1
+added: google/cloud/secrets/v1beta1 to secrets
+2 added, 1 failed
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	gotCfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"google-cloud-secretmanager-v1", "secrets"} {
+		if _, err := FindLibrary(gotCfg, name); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestAddCommand_APIPathFile_Error(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("invalid.txt", []byte("google/cloud/secretmanager/v1 secretmanager extra\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{
+			name:    "with API path",
+			args:    []string{"--api-path-file", "apis.txt", "google/cloud/secretmanager/v1"},
+			wantErr: errAPIPathFileWithArgs,
+		},
+		{
+			name:    "with library",
+			args:    []string{"--api-path-file", "apis.txt", "--library", "secretmanager"},
+			wantErr: errAPIPathFileWithArgs,
+		},
+		{
+			name:    "too many fields",
+			args:    []string{"--api-path-file", "invalid.txt"},
+			wantErr: errInvalidAPIPathFile,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"--api-path-file", "missing.txt"},
+			wantErr: fs.ErrNotExist,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"librarian", "add"}, test.args...)
+			if err := Run(t.Context(), args...); !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckServiceConfigs(t *testing.T) {
+	googleapisDir := t.TempDir()
+	t.Chdir(googleapisDir)
+	writeFiles(t, map[string]string{
+		"google/cloud/newapi/v1/newapi_v1.yaml":     "type: google.api.Service\nname: newapi.googleapis.com\n",
+		"google/cloud/newapi/v1/newapi_gapic.yaml":  "type: com.google.api.codegen.ConfigProto\n",
+		"google/cloud/newapi/v1/newapi.proto":       "syntax = \"proto3\";\n",
+		"google/cloud/orgpolicy/v1/orgpolicy.proto": "syntax = \"proto3\";\n",
+	})
+	cfg := &config.Config{
+		Language: config.LanguageFake,
+		Sources:  &config.Sources{Googleapis: &config.Source{Dir: googleapisDir}},
+	}
+	for _, test := range []struct {
+		name string
+		apis []string
+	}{
+		{"service config", []string{"google/cloud/newapi/v1"}},
+		{"configured without service config", []string{"google/cloud/orgpolicy/v1"}},
+		{"preview", []string{"preview/google/cloud/newapi/v1beta"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkServiceConfigs(t.Context(), cfg, test.apis); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCheckServiceConfigs_Error(t *testing.T) {
+	googleapisDir := t.TempDir()
+	t.Chdir(googleapisDir)
+	writeFiles(t, map[string]string{
+		"google/cloud/newapi/v1/newapi_v1.yaml":     "type: google.api.Service\nname: newapi.googleapis.com\n",
+		"google/cloud/noconfig/v1/noconfig.proto":   "syntax = \"proto3\";\n",
+		"google/cloud/noconfig/v1/noconfig_v1.yaml": "type: com.google.api.codegen.ConfigProto\n",
+	})
+	for _, test := range []struct {
+		name    string
+		sources *config.Sources
+		apis    []string
+		wantErr error
+	}{
+		{
+			name:    "no service config",
+			sources: &config.Sources{Googleapis: &config.Source{Dir: googleapisDir}},
+			apis:    []string{"google/cloud/noconfig/v1"},
+			wantErr: errServiceConfigNotFound,
+		},
+		{
+			name:    "missing API directory",
+			sources: &config.Sources{Googleapis: &config.Source{Dir: googleapisDir}},
+			apis:    []string{"google/cloud/newapi/v1", "google/cloud/missing/v1"},
+			wantErr: errServiceConfigNotFound,
+		},
+		{
+			name:    "no googleapis source",
+			apis:    []string{"google/cloud/newapi/v1"},
+			wantErr: ErrMissingGoogleapisSource,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{Language: config.LanguageFake, Sources: test.sources}
+			err := checkServiceConfigs(t.Context(), cfg, test.apis)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestAddLibrary(t *testing.T) {
 	for _, test := range []struct {
 		name     string
@@ -239,7 +520,7 @@ func TestAddLibrary(t *testing.T) {
 			if err := yaml.Write(config.LibrarianYAML, cfg); err != nil {
 				t.Fatal(err)
 			}
-			gotName, cfg, err := addLibrary(cfg, test.apiPath)
+			gotName, cfg, err := addLibrary(cfg, test.apiPath, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -418,7 +699,7 @@ func TestAddLibrary_ExistingLibrary(t *testing.T) {
 			if err := yaml.Write(config.LibrarianYAML, test.cfg); err != nil {
 				t.Fatal(err)
 			}
-			gotName, gotCfg, err := addLibrary(test.cfg, test.apiPath)
+			gotName, gotCfg, err := addLibrary(test.cfg, test.apiPath, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -467,7 +748,107 @@ func TestAddLibrary_ExistingLibrary_Error(t *testing.T) {
 			if err := yaml.Write(config.LibrarianYAML, test.cfg); err != nil {
 				t.Fatal(err)
 			}
-			_, _, err := addLibrary(test.cfg, test.apiPath)
+			_, _, err := addLibrary(test.cfg, test.apiPath, "")
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAddAPIsToLibrary(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		library  string
+		apiPaths []string
+		cfg      *config.Config
+		want     []*config.API
+	}{
+		{
+			name:     "new library",
+			library:  "secretmanager",
+			apiPaths: []string{"google/cloud/secretmanager/v1", "google/cloud/secretmanager/v1beta2"},
+			cfg:      &config.Config{Language: config.LanguageRust},
+			want: []*config.API{
+				{Path: "google/cloud/secretmanager/v1"},
+				{Path: "google/cloud/secretmanager/v1beta2"},
+			},
+		},
+		{
+			name:     "existing library",
+			library:  "secretmanager",
+			apiPaths: []string{"google/cloud/secretmanager/v1beta1", "google/cloud/secretmanager/v1beta2"},
+			cfg: &config.Config{
+				Language: config.LanguageNodejs,
+				Libraries: []*config.Library{
+					{
+						Name: "secretmanager",
+						APIs: []*config.API{{Path: "google/cloud/secretmanager/v1"}},
+					},
+				},
+			},
+			want: []*config.API{
+				{Path: "google/cloud/secretmanager/v1"},
+				{Path: "google/cloud/secretmanager/v1beta1"},
+				{Path: "google/cloud/secretmanager/v1beta2"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			gotName, gotCfg, err := addAPIsToLibrary(test.cfg, test.library, "", test.apiPaths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotName != test.library {
+				t.Errorf("got library %q, want %q", gotName, test.library)
+			}
+			lib, err := FindLibrary(gotCfg, test.library)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, lib.APIs); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddAPIsToLibrary_Error(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		apiPaths []string
+		cfg      *config.Config
+		wantErr  error
+	}{
+		{
+			name:     "preview",
+			apiPaths: []string{"preview/google/cloud/secretmanager/v1beta2"},
+			cfg:      &config.Config{Language: config.LanguageGo},
+			wantErr:  errPreviewWithLibraryFlag,
+		},
+		{
+			name:     "repeated API",
+			apiPaths: []string{"google/cloud/secretmanager/v1", "google/cloud/secretmanager/v1"},
+			cfg:      &config.Config{Language: config.LanguageGo},
+			wantErr:  errAPIAlreadyExists,
+		},
+		{
+			name:     "existing library without multiple APIs",
+			apiPaths: []string{"google/cloud/secretmanager/v1beta2"},
+			cfg: &config.Config{
+				Language: config.LanguageRust,
+				Libraries: []*config.Library{
+					{
+						Name: "secretmanager",
+						APIs: []*config.API{{Path: "google/cloud/secretmanager/v1"}},
+					},
+				},
+			},
+			wantErr: errLibraryAlreadyExists,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := addAPIsToLibrary(test.cfg, "secretmanager", "", test.apiPaths)
 			if !errors.Is(err, test.wantErr) {
 				t.Fatalf("expected error %v, got %v", test.wantErr, err)
 			}
@@ -503,7 +884,7 @@ func TestAddLibrary_Preview(t *testing.T) {
 				Language:  config.LanguageGo,
 				Libraries: test.initialLibraries,
 			}
-			gotName, gotCfg, err := addLibrary(cfg, test.apiPath)
+			gotName, gotCfg, err := addLibrary(cfg, test.apiPath, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -557,7 +938,7 @@ func TestAddLibrary_Preview_Error(t *testing.T) {
 				Language:  config.LanguageGo,
 				Libraries: test.initialLibraries,
 			}
-			_, _, err := addLibrary(cfg, test.apiPath)
+			_, _, err := addLibrary(cfg, test.apiPath, "")
 			if !errors.Is(err, test.wantErr) {
 				t.Fatalf("expected error %v, got %v", test.wantErr, err)
 			}
@@ -624,7 +1005,7 @@ func TestAddLibraryCommand_Java(t *testing.T) {
 		t.Fatal(err)
 	}
 	// developerconnect has Locations mixin in its service.yaml
-	err = runAdd(t.Context(), cfg, "google/cloud/developerconnect/v1")
+	_, err = runAdd(t.Context(), config.LibrarianYAML, cfg, "", "", []string{"google/cloud/developerconnect/v1"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -710,7 +1091,7 @@ func TestAddLibrary_Swift(t *testing.T) {
 			if err := yaml.Write(config.LibrarianYAML, cfg); err != nil {
 				t.Fatal(err)
 			}
-			err = runAdd(t.Context(), cfg, "google/cloud/secretmanager/v1")
+			_, err = runAdd(t.Context(), config.LibrarianYAML, cfg, "", "", []string{"google/cloud/secretmanager/v1"})
 			if err != nil {
 				t.Fatal(err)
 			}
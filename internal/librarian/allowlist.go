@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+var errFileNotAllowed = errors.New("generated files not in output_allowlist")
+
+// checkOutputAllowlists checks the output allowlist of each library.
+func checkOutputAllowlists(libraries []*config.Library) error {
+	var errs []error
+	for _, library := range libraries {
+		if err := checkOutputAllowlist(library); err != nil {
+			errs = append(errs, &libraryError{library: library, err: fmt.Errorf("library %q: %w", library.Name, err)})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkOutputAllowlist returns an error listing the files in the output
+// directory of library that do not match its output allowlist. Files in the
+// keep list and files matched by .librarianignore are preserved by clean
+// rather than generated, so they are always allowed. It does nothing if the
+// library has no output allowlist.
+func checkOutputAllowlist(library *config.Library) error {
+	if len(library.OutputAllowlist) == 0 {
+		return nil
+	}
+	var patterns []gitignore.Pattern
+	for _, p := range library.OutputAllowlist {
+		patterns = append(patterns, gitignore.ParsePattern(p, nil))
+	}
+	allow := gitignore.NewMatcher(patterns)
+	ignore, err := readLibrarianIgnore(library.Output)
+	if err != nil {
+		return err
+	}
+	var stray []string
+	err = filepath.WalkDir(library.Output, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(library.Output, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		switch {
+		case rel == librarianIgnoreFile, isKept(library.Keep, rel):
+		case ignore != nil && ignore.Match(parts, false):
+		case allow.Match(parts, false):
+		default:
+			stray = append(stray, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if len(stray) > 0 {
+		return fmt.Errorf("%w: %s", errFileNotAllowed, strings.Join(stray, ", "))
+	}
+	return nil
+}
+
+// isKept reports whether rel is, or is in, one of the paths in keep.
+func isKept(keep []string, rel string) bool {
+	for _, k := range keep {
+		k = filepath.Clean(k)
+		if rel == k || strings.HasPrefix(rel, k+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestCheckOutputAllowlist(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		allowlist []string
+		keep      []string
+		ignore    string
+		wantErr   bool
+	}{
+		{
+			name: "no allowlist",
+		},
+		{
+			name:      "all allowed",
+			allowlist: []string{"*.go", "go.mod", "/internal/"},
+		},
+		{
+			name:      "stray file",
+			allowlist: []string{"*.go", "/internal/"},
+			wantErr:   true,
+		},
+		{
+			name:      "stray file in directory",
+			allowlist: []string{"*.go", "go.mod"},
+			wantErr:   true,
+		},
+		{
+			name:      "kept file",
+			allowlist: []string{"*.go", "/internal/"},
+			keep:      []string{"go.mod"},
+		},
+		{
+			name:      "kept directory",
+			allowlist: []string{"*.go", "go.mod"},
+			keep:      []string{"internal"},
+		},
+		{
+			name:      "ignored file",
+			allowlist: []string{"*.go", "/internal/"},
+			ignore:    "go.mod\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{"client.go", "go.mod", "internal/gen.txt"} {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.ignore != "" {
+				if err := os.WriteFile(filepath.Join(dir, librarianIgnoreFile), []byte(test.ignore), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			library := &config.Library{
+				Name:            "lib",
+				Output:          dir,
+				Keep:            test.keep,
+				OutputAllowlist: test.allowlist,
+			}
+			err := checkOutputAllowlist(library)
+			if test.wantErr != errors.Is(err, errFileNotAllowed) {
+				t.Errorf("checkOutputAllowlist() error = %v, wantErr %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateOutputAllowlist(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	// The fake generator writes STARTER.md for new libraries, which the
+	// allowlist does not cover.
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    output_allowlist:
+      - README.md
+      - VERSION
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(t.Context(), "librarian", "generate", "--api-source", googleapisDir, "speech")
+	if !errors.Is(err, errFileNotAllowed) {
+		t.Fatalf("want error %v, got %v", errFileNotAllowed, err)
+	}
+	if !strings.Contains(err.Error(), "STARTER.md") {
+		t.Errorf("error %q does not name the stray file", err)
+	}
+}
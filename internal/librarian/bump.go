@@ -18,7 +18,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/googleapis/librarian/internal/command"
@@ -38,7 +42,17 @@ const (
 
 var (
 	errBothVersionAndAllFlag = errors.New("cannot specify both --version and --all")
+	errVersionWithLibraries  = errors.New("--version can only be used with a single library")
+	errBothVersionAndFile    = errors.New("cannot specify both --version and --versions-file")
 	errReleaseCommitNotFound = errors.New("no release commit found")
+	errInvalidPrerelease     = errors.New("invalid prerelease identifier")
+	errNoReleasableChanges   = errors.New("no releasable changes")
+	errUnusedVersions        = errors.New("--versions-file lists libraries that are not bumped")
+	errInvalidReleasableType = errors.New("invalid change level in release.releasable_types")
+	errInvalidIgnoredCommit  = errors.New("invalid regular expression in release.ignored_commits")
+	// prereleaseRegexp matches a valid --prerelease identifier. Dots are not
+	// allowed, as the prerelease number is appended after a dot.
+	prereleaseRegexp = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
 	// languageVersioningOptions contains language-specific SemVer versioning
 	// options. Over time, languages should align on versioning semantics and
 	// this should be removed. If a language does not have specific needs, a
@@ -49,6 +63,13 @@ var (
 			DowngradePreGAChanges: true,
 		},
 	}
+	// releasableTypeLevels maps the change levels accepted in
+	// release.releasable_types to their [semver.ChangeLevel].
+	releasableTypeLevels = map[string]semver.ChangeLevel{
+		semver.Patch.String(): semver.Patch,
+		semver.Minor.String(): semver.Minor,
+		semver.Major.String(): semver.Major,
+	}
 	// IgnoredChanges defines the list of the files that are
 	// to be ignored as changes during the bump and publish commands.
 	// It is norm that a repository does not have all the files listed here.
@@ -63,17 +84,55 @@ func bumpCommand() *cli.Command {
 		Name:      "bump",
 		Hidden:    true,
 		Usage:     "bump version numbers and prepare release artifacts",
-		UsageText: "librarian bump <library>",
+		UsageText: "librarian bump <library>...",
 		Description: `bump updates version numbers and prepares the files needed for a new release.
 
-If a library name is given, only that library is updated. The --all flag updates every
-library in the workspace. When a library is specified explicitly, the --version flag can
-be used to override the new version.
+If library names are given, only those libraries are updated. The --all flag updates
+every library in the workspace. When a single library is specified explicitly, the
+--version flag can be used to override the new version.
+
+By default the minor version is bumped. If any commit affecting a library since
+its last release is a breaking change, marked by "!" after the conventional
+commit type or by a BREAKING CHANGE footer, the major version is bumped instead.
+Libraries before 1.0.0 bump the minor version for breaking changes.
+
+If release.releasable_types is set in librarian.yaml, each commit type listed
+there bumps the given level instead, and commits of other types are not
+releasable. With --all, libraries without releasable commits are skipped; a
+library named explicitly without releasable commits requires --version.
+
+Commits whose subject matches a regular expression in release.ignored_commits
+are not considered at all. They are logged when --verbose is set.
+
+The --prerelease flag produces a prerelease version with the given identifier,
+such as 2.1.0-rc.1. If the current version is already a prerelease with the same
+identifier, only the prerelease number is incremented. The --version flag takes
+precedence over --prerelease.
+
+The --versions-file flag names a YAML file mapping library names to explicit
+versions, for example:
+
+	google-cloud-secretmanager: 2.0.0
+	google-cloud-storage: 1.5.0
+
+Libraries listed in the file are bumped to the given version instead of the
+computed one, even if they have not changed since their last release, and
+other libraries are bumped as usual. Every listed version is checked before
+any library is bumped: if a library does not exist, is not named on the
+command line or has skip_release set, or its version is not greater than the
+current one, bump fails without changing anything.
 
 Examples:
 
-	librarian bump <library>           # update version for one library
-	librarian bump --all               # update versions for all libraries`,
+	librarian bump <library>                  # update version for one library
+	librarian bump <library1> <library2>      # update versions for two libraries
+	librarian bump --all                      # update versions for all libraries
+	librarian bump --prerelease=rc <library>  # release candidate for one library
+	librarian bump --all --versions-file=versions.yaml
+
+The --metrics-pushgateway flag pushes the new version of each bumped
+library to a Prometheus Pushgateway, as the librarian_bump job. Pushing is
+best effort: a failure is logged and does not fail bump.`,
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "all",
@@ -81,42 +140,95 @@ Examples:
 			},
 			&cli.StringFlag{
 				Name:  "version",
-				Usage: "specific version to update to; not valid with --all",
+				Usage: "specific version to update to; only valid with a single library",
+			},
+			&cli.StringFlag{
+				Name:  "versions-file",
+				Usage: "YAML `file` mapping library names to the versions to update to",
+			},
+			&cli.StringFlag{
+				Name:  "prerelease",
+				Usage: "produce a prerelease version with the given `identifier`, such as rc or beta",
+			},
+			&cli.StringFlag{
+				Name:  "metrics-pushgateway",
+				Usage: "push metrics to the Prometheus Pushgateway at this `URL`",
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			all := cmd.Bool("all")
-			libraryName := cmd.Args().First()
+			libraryNames := cmd.Args().Slice()
 			versionOverride := cmd.String("version")
-			if !all && libraryName == "" {
+			prerelease := cmd.String("prerelease")
+			if !all && len(libraryNames) == 0 {
 				return errMissingLibraryOrAllFlag
 			}
-			if all && libraryName != "" {
+			if all && len(libraryNames) != 0 {
 				return errBothLibraryAndAllFlag
 			}
 			if all && versionOverride != "" {
 				return errBothVersionAndAllFlag
 			}
-			cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			if len(libraryNames) > 1 && versionOverride != "" {
+				return errVersionWithLibraries
+			}
+			if versionOverride != "" && cmd.String("versions-file") != "" {
+				return errBothVersionAndFile
+			}
+			if prerelease != "" && !prereleaseRegexp.MatchString(prerelease) {
+				return fmt.Errorf("%w: %q", errInvalidPrerelease, prerelease)
+			}
+			versions := map[string]string{}
+			if versionOverride != "" {
+				versions[libraryNames[0]] = versionOverride
+			}
+			if path := cmd.String("versions-file"); path != "" {
+				fromFile, err := yaml.Read[map[string]string](path)
+				if err != nil {
+					return err
+				}
+				versions = *fromFile
+			}
+			configPath := cmd.String("config-file")
+			cfg, err := readConfig(configPath)
 			if err != nil {
 				return err
 			}
-			return runBump(ctx, cfg, all, libraryName, versionOverride)
+			before := map[string]string{}
+			for _, lib := range cfg.Libraries {
+				before[lib.Name] = lib.Version
+			}
+			if err := runBump(ctx, configPath, cfg, all, libraryNames, versions, prerelease); err != nil {
+				return err
+			}
+			pushMetrics(ctx, cmd.String("metrics-pushgateway"), "librarian_bump", bumpMetrics(cfg, before))
+			return nil
 		},
 	}
 }
 
 // runBump performs the actual work of the bump command, after all the command
-// lines arguments have been validated and the configuration loaded.
-func runBump(ctx context.Context, cfg *config.Config, all bool, libraryName, versionOverride string) error {
+// lines arguments have been validated and the configuration loaded from
+// configPath, which is written back. Libraries with an entry in versions are
+// bumped to that version.
+func runBump(ctx context.Context, configPath string, cfg *config.Config, all bool, libraryNames []string, versions map[string]string, prerelease string) error {
+	if err := validateTagFormat(cfg); err != nil {
+		return err
+	}
+	if err := validateVersionOverrides(cfg, versions); err != nil {
+		return err
+	}
+	if err := checkVersionsUsed(cfg, all, libraryNames, versions); err != nil {
+		return err
+	}
 	if err := git.AssertGitStatusClean(ctx, command.Git); err != nil {
 		return err
 	}
 	if cfg.Language == config.LanguageRust {
-		return legacyRustBump(ctx, cfg, all, libraryName, versionOverride)
+		return legacyRustBump(ctx, configPath, cfg, all, libraryNames, versions, prerelease)
 	}
 
-	librariesToBump, err := findLibrariesToBump(ctx, cfg, all, libraryName)
+	librariesToBump, err := findLibrariesToBump(ctx, cfg, all, libraryNames, versions)
 	if err != nil {
 		return err
 	}
@@ -127,7 +239,10 @@ func runBump(ctx context.Context, cfg *config.Config, all bool, libraryName, ver
 	}
 
 	for _, lib := range librariesToBump {
-		if err := bumpLibrary(cfg, lib, versionOverride); err != nil {
+		if err := bumpLibrary(ctx, cfg, lib, versions[lib.Name], prerelease); err != nil {
+			if all && errors.Is(err, errNoReleasableChanges) {
+				continue
+			}
 			return err
 		}
 	}
@@ -136,35 +251,75 @@ func runBump(ctx context.Context, cfg *config.Config, all bool, libraryName, ver
 		return err
 	}
 
-	return RunTidyOnConfig(ctx, ".", cfg)
+	return tidyAndWriteConfig(configPath, cfg)
 }
 
-// findLibrariesToBump determines which versions should be bumped based on
-// command line options.
-func findLibrariesToBump(ctx context.Context, cfg *config.Config, all bool, libraryName string) ([]*config.Library, error) {
-	if !all {
-		library, err := FindLibrary(cfg, libraryName)
+// validateVersionOverrides checks that each library in versions exists and
+// that its version is greater than the current one, so that no library is
+// bumped if any override is invalid.
+func validateVersionOverrides(cfg *config.Config, versions map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(versions)) {
+		lib, err := FindLibrary(cfg, name)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := semver.ValidateNext(lib.Version, versions[name]); err != nil {
+			return fmt.Errorf("library %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkVersionsUsed returns an error naming the libraries in versions that
+// would not be bumped: with all, those with skip_release set, and otherwise
+// those not in libraryNames.
+func checkVersionsUsed(cfg *config.Config, all bool, libraryNames []string, versions map[string]string) error {
+	var unused []string
+	for _, name := range slices.Sorted(maps.Keys(versions)) {
+		if all {
+			if lib, err := FindLibrary(cfg, name); err == nil && lib.SkipRelease {
+				unused = append(unused, name)
+			}
+		} else if !slices.Contains(libraryNames, name) {
+			unused = append(unused, name)
 		}
-		return []*config.Library{library}, nil
+	}
+	if len(unused) > 0 {
+		return fmt.Errorf("%w: %s", errUnusedVersions, strings.Join(unused, ", "))
+	}
+	return nil
+}
+
+// findLibrariesToBump determines which versions should be bumped based on
+// command line options. With all, libraries in versions are bumped even if
+// they have not changed.
+func findLibrariesToBump(ctx context.Context, cfg *config.Config, all bool, libraryNames []string, versions map[string]string) ([]*config.Library, error) {
+	if !all {
+		return findLibraries(cfg, libraryNames)
 	}
 
 	var librariesToBump []*config.Library
 	for _, lib := range cfg.Libraries {
-		if lib.SkipRelease || lib.Version == "" {
+		if lib.SkipRelease {
+			continue
+		}
+		if versions[lib.Name] != "" {
+			librariesToBump = append(librariesToBump, lib)
 			continue
 		}
-		lastReleaseTagName := formatTagName(cfg.Default.TagFormat, lib)
+		if lib.Version == "" {
+			continue
+		}
+		lastReleaseTagName := git.FormatTag(cfg.Default.TagFormat, lib.Name, lib.Version)
 		lastReleaseTagCommit, err := git.GetCommitHash(ctx, command.Git, lastReleaseTagName)
 		if err != nil {
 			return nil, fmt.Errorf("error retrieving commit for tag %s (from library %s version %s): %w", lastReleaseTagName, lib.Name, lib.Version, err)
 		}
-		filesChanged, err := git.FilesChangedSince(ctx, command.Git, lastReleaseTagCommit, IgnoredChanges)
+		changed, err := libraryChangedSince(ctx, cfg, lib, lastReleaseTagCommit)
 		if err != nil {
 			return nil, err
 		}
-		if !libraryChanged(cfg, lib, filesChanged) {
+		if !changed {
 			continue
 		}
 		librariesToBump = append(librariesToBump, lib)
@@ -172,6 +327,35 @@ func findLibrariesToBump(ctx context.Context, cfg *config.Config, all bool, libr
 	return librariesToBump, nil
 }
 
+// libraryChangedSince reports whether library has changed since ref, either
+// because files in its output changed or because a commit has one of the
+// library's scopes.
+func libraryChangedSince(ctx context.Context, cfg *config.Config, library *config.Library, ref string) (bool, error) {
+	filesChanged, err := git.FilesChangedSince(ctx, command.Git, ref, IgnoredChanges)
+	if err != nil {
+		return false, err
+	}
+	if libraryChanged(cfg, library, filesChanged) {
+		return true, nil
+	}
+	return hasScopedCommitsSince(ctx, library, ref)
+}
+
+// hasScopedCommitsSince reports whether any commit since ref has one of the
+// library's scopes.
+func hasScopedCommitsSince(ctx context.Context, library *config.Library, ref string) (bool, error) {
+	if len(library.Scopes) == 0 {
+		return false, nil
+	}
+	messages, err := git.CommitMessagesSince(ctx, command.Git, ref, ".")
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(messages, func(message string) bool {
+		return hasScope(message, library.Scopes)
+	}), nil
+}
+
 func libraryChanged(cfg *config.Config, library *config.Library, filesChanged []string) bool {
 	var (
 		output    string
@@ -205,15 +389,35 @@ func hasChangesIn(dir, exclusion string, filesChanged []string) bool {
 }
 
 // bumpLibrary determines the next version of a library (using versionOverride
-// if that is non-empty), and applies the language-specific version bump logic
-// to update manifests, version files etc.
-func bumpLibrary(cfg *config.Config, lib *config.Library, versionOverride string) error {
+// if that is non-empty, or a prerelease version if prerelease is non-empty),
+// and applies the language-specific version bump logic to update manifests,
+// version files etc.
+func bumpLibrary(ctx context.Context, cfg *config.Config, lib *config.Library, versionOverride, prerelease string) error {
 	opts := languageVersioningOptions[cfg.Language]
-	version, err := deriveNextVersion(lib, opts, versionOverride)
+	opts.Prerelease = prerelease
+	output := libraryOutput(cfg.Language, lib, cfg.Default)
+	changeLevel := semver.Minor
+	if versionOverride == "" && lib.Version != "" && cfg.Default != nil {
+		lastReleaseTagName := git.FormatTag(cfg.Default.TagFormat, lib.Name, lib.Version)
+		// A library bumped by name may not have a release tag yet, in which
+		// case there are no commits to inspect.
+		exists, err := git.TagExists(ctx, command.Git, lastReleaseTagName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if changeLevel, err = changeLevelSince(ctx, cfg, lib, lastReleaseTagName, output); err != nil {
+				return err
+			}
+			if changeLevel == semver.None {
+				return fmt.Errorf("%w for %s since %s; use --version to release it anyway", errNoReleasableChanges, lib.Name, lastReleaseTagName)
+			}
+		}
+	}
+	version, err := deriveNextVersion(lib, opts, versionOverride, changeLevel)
 	if err != nil {
 		return err
 	}
-	output := libraryOutput(cfg.Language, lib, cfg.Default)
 	lib.Version = version
 
 	switch cfg.Language {
@@ -239,7 +443,159 @@ func postBump(ctx context.Context, cfg *config.Config) error {
 	return nil
 }
 
-func deriveNextVersion(library *config.Library, opts semver.DeriveNextOptions, versionOverride string) (string, error) {
+// changeLevelSince returns the change level of the commits affecting output
+// since the given git ref. See [commitsChangeLevel].
+func changeLevelSince(ctx context.Context, cfg *config.Config, lib *config.Library, ref, output string) (semver.ChangeLevel, error) {
+	messages, err := libraryCommitMessages(ctx, lib, ref, output)
+	if err != nil {
+		return semver.None, err
+	}
+	changeLevel, _, err := releaseChangeLevel(cfg, messages)
+	return changeLevel, err
+}
+
+// libraryCommitMessages returns the messages of the commits since ref that
+// are attributed to lib: those that change files in output, and those with
+// one of the library's scopes.
+func libraryCommitMessages(ctx context.Context, lib *config.Library, ref, output string) ([]string, error) {
+	messages, err := git.CommitMessagesSince(ctx, command.Git, ref, output)
+	if err != nil || len(lib.Scopes) == 0 {
+		return messages, err
+	}
+	all, err := git.CommitMessagesSince(ctx, command.Git, ref, ".")
+	if err != nil {
+		return nil, err
+	}
+	// Skip the commits already attributed by path, counting duplicates, so
+	// that a scoped commit which also changes output is only counted once.
+	byPath := map[string]int{}
+	for _, message := range messages {
+		byPath[message]++
+	}
+	for _, message := range all {
+		if byPath[message] > 0 {
+			byPath[message]--
+			continue
+		}
+		if hasScope(message, lib.Scopes) {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// hasScope reports whether message is a conventional commit with one of the
+// given scopes.
+func hasScope(message string, scopes []string) bool {
+	commit, err := git.ParseConventionalCommit(message)
+	if err != nil {
+		return false
+	}
+	return commit.Scope != "" && slices.Contains(scopes, commit.Scope)
+}
+
+// releaseChangeLevel applies the release settings of cfg to the given commit
+// messages. It returns their change level, see [commitsChangeLevel], and the
+// number of messages not ignored by release.ignored_commits. If every message
+// is ignored, the change level is [semver.None].
+func releaseChangeLevel(cfg *config.Config, messages []string) (semver.ChangeLevel, int, error) {
+	releasable, err := releasableTypes(cfg)
+	if err != nil {
+		return semver.None, 0, err
+	}
+	ignored, err := ignoredCommits(cfg)
+	if err != nil {
+		return semver.None, 0, err
+	}
+	kept := filterIgnoredCommits(messages, ignored)
+	if len(messages) > 0 && len(kept) == 0 {
+		return semver.None, 0, nil
+	}
+	return commitsChangeLevel(kept, releasable), len(kept), nil
+}
+
+// ignoredCommits compiles the regular expressions in
+// release.ignored_commits.
+func ignoredCommits(cfg *config.Config) ([]*regexp.Regexp, error) {
+	if cfg.Release == nil {
+		return nil, nil
+	}
+	var ignored []*regexp.Regexp
+	for _, pattern := range cfg.Release.IgnoredCommits {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidIgnoredCommit, err)
+		}
+		ignored = append(ignored, re)
+	}
+	return ignored, nil
+}
+
+// filterIgnoredCommits returns the messages whose subject does not match any
+// of the ignored regular expressions. Each ignored message is logged at debug
+// level, so that no commit is dropped silently.
+func filterIgnoredCommits(messages []string, ignored []*regexp.Regexp) []string {
+	var kept []string
+	for _, message := range messages {
+		subject, _, _ := strings.Cut(message, "\n")
+		i := slices.IndexFunc(ignored, func(re *regexp.Regexp) bool { return re.MatchString(subject) })
+		if i >= 0 {
+			slog.Debug("ignoring commit", "subject", subject, "pattern", ignored[i].String())
+			continue
+		}
+		kept = append(kept, message)
+	}
+	return kept
+}
+
+// releasableTypes returns the change level of each commit type in
+// release.releasable_types, or nil if it is not set.
+func releasableTypes(cfg *config.Config) (map[string]semver.ChangeLevel, error) {
+	if cfg.Release == nil || len(cfg.Release.ReleasableTypes) == 0 {
+		return nil, nil
+	}
+	levels := make(map[string]semver.ChangeLevel, len(cfg.Release.ReleasableTypes))
+	for commitType, level := range cfg.Release.ReleasableTypes {
+		changeLevel, ok := releasableTypeLevels[level]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q for %q", errInvalidReleasableType, level, commitType)
+		}
+		levels[commitType] = changeLevel
+	}
+	return levels, nil
+}
+
+// commitsChangeLevel returns [semver.Major] if any of the given commit
+// messages describes a breaking change, either with a "!" after the
+// conventional commit type or with a BREAKING CHANGE footer. Messages that
+// are not conventional commits are ignored.
+//
+// If releasable is nil, any other set of messages returns [semver.Minor], the
+// default change level for a release. Otherwise, it returns the highest level
+// that releasable maps a commit type to, or [semver.None] if no commit has a
+// releasable type.
+func commitsChangeLevel(messages []string, releasable map[string]semver.ChangeLevel) semver.ChangeLevel {
+	level := semver.None
+	if releasable == nil {
+		level = semver.Minor
+	}
+	for _, message := range messages {
+		commit, err := git.ParseConventionalCommit(message)
+		if err != nil {
+			continue
+		}
+		if commit.IsBreaking {
+			return semver.Major
+		}
+		level = max(level, releasable[commit.Type])
+	}
+	return level
+}
+
+// deriveNextVersion determines the next version of library for a change of
+// the given level, unless versionOverride is set. Pre-1.0.0 versions treat a
+// [semver.Major] change as [semver.Minor].
+func deriveNextVersion(library *config.Library, opts semver.DeriveNextOptions, versionOverride string, changeLevel semver.ChangeLevel) (string, error) {
 	// If a version override has been specified, use it - but
 	// check that it's not a regression or a no-op.
 	if versionOverride != "" {
@@ -253,10 +609,24 @@ func deriveNextVersion(library *config.Library, opts semver.DeriveNextOptions, v
 	// have their own default starting version, set at add time. This is a
 	// fallback for the case where it wasn't.
 	if library.Version == "" {
+		if opts.Prerelease != "" {
+			return fmt.Sprintf("%s-%s.1", defaultVersion, opts.Prerelease), nil
+		}
 		return defaultVersion, nil
 	}
 
-	return semver.DeriveNext(semver.Minor, library.Version, opts)
+	version, err := semver.DeriveNext(changeLevel, library.Version, opts)
+	if err != nil {
+		return "", err
+	}
+	// Switching prerelease identifiers can go backwards, for example from
+	// 2.1.0-rc.1 to 2.1.0-beta.1.
+	if opts.Prerelease != "" {
+		if err := semver.ValidateNext(library.Version, version); err != nil {
+			return "", err
+		}
+	}
+	return version, nil
 }
 
 // findReleasedLibraries determines which libraries are released by the
@@ -303,9 +673,10 @@ func findReleasedLibraries(cfgBefore, cfgAfter *config.Config) ([]string, error)
 // of what it means for a commit to release a library.) Importantly, it does
 // this *without* using tags, as it's used in circumstances where the full
 // release process has not yet been completed (e.g. to find which commit
-// *should* be tagged).
-func findLatestReleaseCommitHash(ctx context.Context) (string, error) {
-	commits, err := git.FindCommitsForPath(ctx, command.Git, config.LibrarianYAML)
+// *should* be tagged). configPath is the path of the configuration file,
+// relative to the repository root.
+func findLatestReleaseCommitHash(ctx context.Context, configPath string) (string, error) {
+	commits, err := git.FindCommitsForPath(ctx, command.Git, configPath)
 	if err != nil {
 		return "", err
 	}
@@ -315,11 +686,11 @@ func findLatestReleaseCommitHash(ctx context.Context) (string, error) {
 	var candidateConfig *config.Config
 	candidateCommit := ""
 	for _, commit := range commits {
-		commitCfgContent, err := git.ShowFileAtRevision(ctx, command.Git, commit, config.LibrarianYAML)
+		commitCfgContent, err := git.ShowFileAtRevision(ctx, command.Git, commit, configPath)
 		if err != nil {
 			return "", err
 		}
-		commitCfg, err := yaml.Unmarshal[config.Config]([]byte(commitCfgContent))
+		commitCfg, err := parseConfig([]byte(commitCfgContent))
 		if err != nil {
 			return "", err
 		}
@@ -346,37 +717,39 @@ func findLatestReleaseCommitHash(ctx context.Context) (string, error) {
 // releasing. This is separated from the main logic to allow non-Rust languages
 // to work on the newer "tag-per-library" logic without interrupting Rust
 // releases. The "fake" language is still valid here, for testing purposes.
-func legacyRustBump(ctx context.Context, cfg *config.Config, all bool, libraryName, versionOverride string) error {
+func legacyRustBump(ctx context.Context, configPath string, cfg *config.Config, all bool, libraryNames []string, versions map[string]string, prerelease string) error {
 	lastTag, err := git.GetLastTag(ctx, command.Git, config.RemoteUpstream, config.BranchMain)
 	if err != nil {
 		return err
 	}
 
 	if all {
-		if err := legacyRustBumpAll(ctx, cfg, lastTag); err != nil {
+		if err := legacyRustBumpAll(ctx, cfg, lastTag, versions, prerelease); err != nil {
 			return err
 		}
 	} else {
-		lib, err := FindLibrary(cfg, libraryName)
+		libraries, err := findLibraries(cfg, libraryNames)
 		if err != nil {
 			return err
 		}
-		if err := legacyRustBumpLibrary(ctx, cfg, lib, lastTag, versionOverride); err != nil {
-			return err
+		for _, lib := range libraries {
+			if err := legacyRustBumpLibrary(ctx, cfg, lib, lastTag, versions[lib.Name], prerelease); err != nil {
+				return err
+			}
 		}
 	}
 
 	if err := postBump(ctx, cfg); err != nil {
 		return err
 	}
-	return RunTidyOnConfig(ctx, ".", cfg)
+	return tidyAndWriteConfig(configPath, cfg)
 }
 
 // legacyRustBumpAll applies the legacy (but still in use) "bump all" approach
 // of assuming a single tag for the latest release, and checking everything
 // since that tag. (Compare this with findLibrariesToBump, which expects each
 // library to have its own tag for its last release.)
-func legacyRustBumpAll(ctx context.Context, cfg *config.Config, lastTag string) error {
+func legacyRustBumpAll(ctx context.Context, cfg *config.Config, lastTag string, versions map[string]string, prerelease string) error {
 	filesChanged, err := git.FilesChangedSince(ctx, command.Git, lastTag, IgnoredChanges)
 	if err != nil {
 		return err
@@ -386,10 +759,19 @@ func legacyRustBumpAll(ctx context.Context, cfg *config.Config, lastTag string)
 			continue
 		}
 		output := libraryOutput(cfg.Language, lib, cfg.Default)
-		if !hasChangesIn(output, "", filesChanged) {
-			continue
+		if versions[lib.Name] == "" && !hasChangesIn(output, "", filesChanged) {
+			scoped, err := hasScopedCommitsSince(ctx, lib, lastTag)
+			if err != nil {
+				return err
+			}
+			if !scoped {
+				continue
+			}
 		}
-		if err := legacyRustBumpLibrary(ctx, cfg, lib, lastTag, ""); err != nil {
+		if err := legacyRustBumpLibrary(ctx, cfg, lib, lastTag, versions[lib.Name], prerelease); err != nil {
+			if errors.Is(err, errNoReleasableChanges) {
+				continue
+			}
 			return err
 		}
 	}
@@ -400,13 +782,24 @@ func legacyRustBumpAll(ctx context.Context, cfg *config.Config, lastTag string)
 // assuming a single tag for the latest release, and passing that tag into the
 // rust.Bump code. (Compare this with bumpLibrary, which only uses git to derive
 // the next version.)
-func legacyRustBumpLibrary(ctx context.Context, cfg *config.Config, lib *config.Library, lastTag, versionOverride string) error {
+func legacyRustBumpLibrary(ctx context.Context, cfg *config.Config, lib *config.Library, lastTag, versionOverride, prerelease string) error {
 	opts := languageVersioningOptions[cfg.Language]
-	version, err := deriveNextVersion(lib, opts, versionOverride)
+	opts.Prerelease = prerelease
+	output := libraryOutput(cfg.Language, lib, cfg.Default)
+	changeLevel := semver.Minor
+	if versionOverride == "" && lib.Version != "" && lastTag != "" {
+		var err error
+		if changeLevel, err = changeLevelSince(ctx, cfg, lib, lastTag, output); err != nil {
+			return err
+		}
+		if changeLevel == semver.None {
+			return fmt.Errorf("%w for %s since %s; use --version to release it anyway", errNoReleasableChanges, lib.Name, lastTag)
+		}
+	}
+	version, err := deriveNextVersion(lib, opts, versionOverride, changeLevel)
 	if err != nil {
 		return err
 	}
-	output := libraryOutput(cfg.Language, lib, cfg.Default)
 	switch cfg.Language {
 	case config.LanguageRust:
 		return rust.Bump(ctx, lib, output, version, command.Git, lastTag)
@@ -417,9 +810,3 @@ func legacyRustBumpLibrary(ctx context.Context, cfg *config.Config, lib *config.
 		return fmt.Errorf("%q should not be using legacyRustBumpLibrary", cfg.Language)
 	}
 }
-
-// formatTagName computes the name of the tag expected to be applied to the
-// commit that released the given library.
-func formatTagName(tagFormat string, lib *config.Library) string {
-	return strings.NewReplacer("{name}", lib.Name, "{version}", lib.Version).Replace(tagFormat)
-}
@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -48,6 +50,7 @@ func TestBumpCommand(t *testing.T) {
 		name         string
 		args         []string
 		withChanges  []string
+		versionsFile string
 		prBodyFile   string
 		wantPRBody   string
 		wantVersions map[string]string
@@ -64,6 +67,15 @@ func TestBumpCommand(t *testing.T) {
 			withChanges:  []string{lib1Change},
 			wantVersions: map[string]string{sample.Lib1Name: "1.2.3"},
 		},
+		{
+			name:        "multiple library names",
+			args:        []string{"librarian", "bump", sample.Lib1Name, sample.Lib2Name},
+			withChanges: []string{lib1Change},
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: sample.NextVersion,
+			},
+		},
 		{
 			name:        "all flag all have changes",
 			args:        []string{"librarian", "bump", "--all"},
@@ -87,8 +99,36 @@ func TestBumpCommand(t *testing.T) {
 			withChanges:  []string{lib1Change},
 			wantVersions: map[string]string{sample.Lib1Name: sample.NextVersion},
 		},
+		{
+			name:         "all flag and versions file for unchanged library",
+			args:         []string{"librarian", "bump", "--all"},
+			withChanges:  []string{lib1Change},
+			versionsFile: sample.Lib2Name + ": 2.0.0\n",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: "2.0.0",
+			},
+		},
+		{
+			name:         "all flag and versions file",
+			args:         []string{"librarian", "bump", "--all"},
+			withChanges:  []string{lib1Change, lib2Change},
+			versionsFile: sample.Lib2Name + ": 2.0.0\n",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: "2.0.0",
+			},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
+			args := test.args
+			if test.versionsFile != "" {
+				path := filepath.Join(t.TempDir(), "versions.yaml")
+				if err := os.WriteFile(path, []byte(test.versionsFile), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--versions-file="+path)
+			}
 			cfg := sample.Config()
 			opts := testhelper.SetupOptions{
 				Clone:       true,
@@ -98,7 +138,7 @@ func TestBumpCommand(t *testing.T) {
 			}
 			testhelper.Setup(t, opts)
 
-			if err := Run(t.Context(), test.args...); err != nil {
+			if err := Run(t.Context(), args...); err != nil {
 				t.Fatal(err)
 			}
 
@@ -146,6 +186,39 @@ func TestBumpCommandDeriveOutput(t *testing.T) {
 	}
 }
 
+func TestBumpCommand_ConfigFile(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+
+	testhelper.Setup(t, testhelper.SetupOptions{
+		Clone:       true,
+		Config:      sample.Config(),
+		Tags:        []string{sample.InitialLib1Tag},
+		WithChanges: []string{filepath.Join(sample.Lib1Output, "src", "lib.rs")},
+	})
+	configFile := "custom.yaml"
+	testhelper.RunGit(t, "mv", config.LibrarianYAML, configFile)
+	testhelper.RunGit(t, "commit", "-m", "chore: rename config")
+
+	if err := Run(t.Context(), "librarian", "--config-file", configFile, "bump", sample.Lib1Name); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := yaml.Read[config.Config](configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib, err := FindLibrary(got, sample.Lib1Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lib.Version != sample.NextVersion {
+		t.Errorf("got version %q, want %q", lib.Version, sample.NextVersion)
+	}
+	if _, err := os.Stat(config.LibrarianYAML); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %s to not be created, got %v", config.LibrarianYAML, err)
+	}
+}
+
 func TestBumpCommand_Error(t *testing.T) {
 	testhelper.RequireCommand(t, "git")
 
@@ -171,6 +244,21 @@ func TestBumpCommand_Error(t *testing.T) {
 			args:    []string{"librarian", "bump", "--version=1.2.3", "--all"},
 			wantErr: errBothVersionAndAllFlag,
 		},
+		{
+			name:    "version flag and multiple libraries",
+			args:    []string{"librarian", "bump", "--version=1.2.3", "foo", "bar"},
+			wantErr: errVersionWithLibraries,
+		},
+		{
+			name:    "version flag and versions file",
+			args:    []string{"librarian", "bump", "--version=1.2.3", "--versions-file=versions.yaml", "foo"},
+			wantErr: errBothVersionAndFile,
+		},
+		{
+			name:    "invalid prerelease identifier",
+			args:    []string{"librarian", "bump", "--prerelease=rc.1", "--all"},
+			wantErr: errInvalidPrerelease,
+		},
 		{
 			name:    "missing librarian yaml file",
 			args:    []string{"librarian", "bump", "--all"},
@@ -259,21 +347,43 @@ func TestRunBump_Error(t *testing.T) {
 	testhelper.RequireCommand(t, "git")
 
 	tests := []struct {
-		name            string
-		libraryName     string
-		versionOverride string
-		wantErr         error
+		name         string
+		all          bool
+		libraryNames []string
+		versions     map[string]string
+		wantErr      error
 	}{
 		{
-			name:            "invalid version override",
-			libraryName:     sample.Lib1Name,
-			versionOverride: "0.9.0",
-			wantErr:         semver.ErrInvalidNextVersion,
+			name:         "invalid version override",
+			libraryNames: []string{sample.Lib1Name},
+			versions:     map[string]string{sample.Lib1Name: "0.9.0"},
+			wantErr:      semver.ErrInvalidNextVersion,
 		},
 		{
-			name:        "library not found",
-			libraryName: "not-found",
-			wantErr:     ErrLibraryNotFound,
+			name:         "library not found",
+			libraryNames: []string{"not-found"},
+			wantErr:      ErrLibraryNotFound,
+		},
+		{
+			name: "one of several version overrides is invalid",
+			all:  true,
+			versions: map[string]string{
+				sample.Lib1Name: "2.0.0",
+				sample.Lib2Name: sample.InitialVersion,
+			},
+			wantErr: semver.ErrInvalidNextVersion,
+		},
+		{
+			name:     "version override for unknown library",
+			all:      true,
+			versions: map[string]string{"not-found": "2.0.0"},
+			wantErr:  ErrLibraryNotFound,
+		},
+		{
+			name:         "version override for library not named",
+			libraryNames: []string{sample.Lib1Name},
+			versions:     map[string]string{sample.Lib2Name: "2.0.0"},
+			wantErr:      errUnusedVersions,
 		},
 	}
 
@@ -286,10 +396,15 @@ func TestRunBump_Error(t *testing.T) {
 			}
 			testhelper.Setup(t, opts)
 
-			gotErr := runBump(t.Context(), cfg, false, test.libraryName, test.versionOverride)
+			gotErr := runBump(t.Context(), config.LibrarianYAML, cfg, test.all, test.libraryNames, test.versions, "")
 			if !errors.Is(gotErr, test.wantErr) {
 				t.Errorf("runBump() error = %v, wantErr %v", gotErr, test.wantErr)
 			}
+			for _, lib := range cfg.Libraries {
+				if lib.Version != sample.InitialVersion {
+					t.Errorf("library %s: got version %q, want unchanged %q", lib.Name, lib.Version, sample.InitialVersion)
+				}
+			}
 		})
 	}
 }
@@ -329,7 +444,7 @@ func TestBumpLibrary(t *testing.T) {
 			testhelper.Setup(t, opts)
 
 			targetLibCfg := test.cfg.Libraries[0]
-			err := bumpLibrary(test.cfg, targetLibCfg, test.versionOverride)
+			err := bumpLibrary(t.Context(), test.cfg, targetLibCfg, test.versionOverride, "")
 			if err != nil {
 				t.Fatalf("bumpLibrary() error = %v", err)
 			}
@@ -349,6 +464,470 @@ func TestBumpLibrary(t *testing.T) {
 	}
 }
 
+func TestBumpLibrary_ChangeLevel(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	for _, test := range []struct {
+		name        string
+		version     string
+		commits     []string
+		wantVersion string
+	}{
+		{
+			name:        "feat and fix",
+			version:     "1.2.3",
+			commits:     []string{"feat: add a feature", "fix: fix a bug"},
+			wantVersion: "1.3.0",
+		},
+		{
+			name:        "breaking change subject",
+			version:     "1.2.3",
+			commits:     []string{"fix: fix a bug", "feat!: remove a feature", "feat: add a feature"},
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "breaking change footer",
+			version:     "1.2.3",
+			commits:     []string{"fix(storage): change a default\n\nBREAKING CHANGE: the default changed"},
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "pre-1.0.0 feat and fix",
+			version:     "0.2.3",
+			commits:     []string{"feat: add a feature", "fix: fix a bug"},
+			wantVersion: "0.3.0",
+		},
+		{
+			name:        "pre-1.0.0 breaking change",
+			version:     "0.2.3",
+			commits:     []string{"fix: fix a bug", "feat(storage)!: remove a feature"},
+			wantVersion: "0.3.0",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := sample.Config()
+			lib := cfg.Libraries[0]
+			lib.Version = test.version
+			testhelper.Setup(t, testhelper.SetupOptions{
+				Clone:  true,
+				Config: cfg,
+				Tags:   []string{git.FormatTag(cfg.Default.TagFormat, lib.Name, lib.Version)},
+			})
+			for i, message := range test.commits {
+				path := filepath.Join(sample.Lib1Output, "src", "lib.rs")
+				writeFileAndCommit(t, path, []byte(fmt.Sprintf("change %d", i)), message)
+			}
+			if err := bumpLibrary(t.Context(), cfg, lib, "", ""); err != nil {
+				t.Fatal(err)
+			}
+			if lib.Version != test.wantVersion {
+				t.Errorf("got version %q, want %q", lib.Version, test.wantVersion)
+			}
+		})
+	}
+}
+
+func TestCommitsChangeLevel(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		messages []string
+		want     semver.ChangeLevel
+	}{
+		{"no commits", nil, semver.Minor},
+		{"feat", []string{"feat: add a feature"}, semver.Minor},
+		{"fix", []string{"fix: fix a bug"}, semver.Minor},
+		{"breaking type", []string{"fix: fix a bug", "feat!: remove a feature"}, semver.Major},
+		{"breaking type with scope", []string{"refactor(storage)!: rename a method"}, semver.Major},
+		{"breaking footer", []string{"feat: change a default\n\nBREAKING CHANGE: the default changed"}, semver.Major},
+		{"breaking footer with hyphen", []string{"fix: a bug\n\nBREAKING-CHANGE: a behavior changed"}, semver.Major},
+		{"breaking mention in body", []string{"docs: explain what a BREAKING CHANGE: footer means"}, semver.Minor},
+		{"exclamation in description", []string{"fix: handle \"!:\" in names"}, semver.Minor},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := commitsChangeLevel(test.messages, nil); got != test.want {
+				t.Errorf("commitsChangeLevel() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCommitsChangeLevel_ReleasableTypes(t *testing.T) {
+	releasable := map[string]semver.ChangeLevel{
+		"feat": semver.Minor,
+		"fix":  semver.Patch,
+		"perf": semver.Patch,
+	}
+	for _, test := range []struct {
+		name     string
+		messages []string
+		want     semver.ChangeLevel
+	}{
+		{"no commits", nil, semver.None},
+		{"fix", []string{"fix: fix a bug"}, semver.Patch},
+		{"perf", []string{"perf(storage): faster uploads"}, semver.Patch},
+		{"feat and fix", []string{"fix: fix a bug", "feat: add a feature"}, semver.Minor},
+		{"not releasable", []string{"docs: update README", "chore: update deps"}, semver.None},
+		{"not conventional", []string{"Update README"}, semver.None},
+		{"breaking type not releasable", []string{"chore!: drop support for an old runtime"}, semver.Major},
+		{"breaking footer", []string{"fix: change a default\n\nBREAKING CHANGE: the default changed"}, semver.Major},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := commitsChangeLevel(test.messages, releasable); got != test.want {
+				t.Errorf("commitsChangeLevel() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReleasableTypes(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		release *config.Release
+		want    map[string]semver.ChangeLevel
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:    "empty",
+			release: &config.Release{},
+		},
+		{
+			name: "levels",
+			release: &config.Release{ReleasableTypes: map[string]string{
+				"feat": "minor",
+				"fix":  "patch",
+				"perf": "patch",
+				"api":  "major",
+			}},
+			want: map[string]semver.ChangeLevel{
+				"feat": semver.Minor,
+				"fix":  semver.Patch,
+				"perf": semver.Patch,
+				"api":  semver.Major,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := releasableTypes(&config.Config{Release: test.release})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReleasableTypes_Error(t *testing.T) {
+	for _, level := range []string{"", "none", "Minor", "breaking"} {
+		t.Run(level, func(t *testing.T) {
+			cfg := &config.Config{Release: &config.Release{ReleasableTypes: map[string]string{"feat": level}}}
+			if _, err := releasableTypes(cfg); !errors.Is(err, errInvalidReleasableType) {
+				t.Errorf("releasableTypes() error = %v, want %v", err, errInvalidReleasableType)
+			}
+		})
+	}
+}
+
+func TestFilterIgnoredCommits(t *testing.T) {
+	ignored := []*regexp.Regexp{
+		regexp.MustCompile(`^chore\(deps\):`),
+		regexp.MustCompile(`^chore: update googleapis`),
+	}
+	messages := []string{
+		"feat: add a feature",
+		"chore(deps): update module cloud.google.com/go to v0.120.0",
+		"fix: fix a bug\n\nchore(deps): in the body is not ignored",
+		"chore: update googleapis\n\nPiperOrigin-RevId: 123",
+		"chore: update CI",
+	}
+	want := []string{
+		"feat: add a feature",
+		"fix: fix a bug\n\nchore(deps): in the body is not ignored",
+		"chore: update CI",
+	}
+	got := filterIgnoredCommits(messages, ignored)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReleaseChangeLevel(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		release     *config.Release
+		messages    []string
+		wantLevel   semver.ChangeLevel
+		wantCommits int
+	}{
+		{
+			name:        "no release settings",
+			messages:    []string{"chore: update deps", "fix: fix a bug"},
+			wantLevel:   semver.Minor,
+			wantCommits: 2,
+		},
+		{
+			name:        "ignored breaking change",
+			release:     &config.Release{IgnoredCommits: []string{"^chore"}},
+			messages:    []string{"chore!: update deps", "fix: fix a bug"},
+			wantLevel:   semver.Minor,
+			wantCommits: 1,
+		},
+		{
+			name:      "all ignored",
+			release:   &config.Release{IgnoredCommits: []string{"^chore: update deps$"}},
+			messages:  []string{"chore: update deps", "chore: update deps"},
+			wantLevel: semver.None,
+		},
+		{
+			name: "ignored and releasable types",
+			release: &config.Release{
+				IgnoredCommits:  []string{"^feat\\(generator\\):"},
+				ReleasableTypes: map[string]string{"feat": "minor", "fix": "patch"},
+			},
+			messages:    []string{"feat(generator): regenerate", "fix: fix a bug"},
+			wantLevel:   semver.Patch,
+			wantCommits: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			gotLevel, gotCommits, err := releaseChangeLevel(&config.Config{Release: test.release}, test.messages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotLevel != test.wantLevel || gotCommits != test.wantCommits {
+				t.Errorf("releaseChangeLevel() = (%v, %d), want (%v, %d)", gotLevel, gotCommits, test.wantLevel, test.wantCommits)
+			}
+		})
+	}
+}
+
+func TestReleaseChangeLevel_Error(t *testing.T) {
+	cfg := &config.Config{Release: &config.Release{IgnoredCommits: []string{"chore(deps"}}}
+	if _, _, err := releaseChangeLevel(cfg, []string{"fix: fix a bug"}); !errors.Is(err, errInvalidIgnoredCommit) {
+		t.Errorf("releaseChangeLevel() error = %v, want %v", err, errInvalidIgnoredCommit)
+	}
+}
+
+func TestRunBump_ReleasableTypes(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	for _, test := range []struct {
+		name         string
+		all          bool
+		libraryNames []string
+		versions     map[string]string
+		lib1Commit   string
+		lib2Commit   string
+		wantVersions map[string]string
+		wantErr      error
+	}{
+		{
+			name:       "all skips libraries without releasable commits",
+			all:        true,
+			lib1Commit: "docs: update README",
+			lib2Commit: "perf: faster uploads",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.InitialVersion,
+				sample.Lib2Name: "1.0.1",
+			},
+		},
+		{
+			name:       "all with feat and fix",
+			all:        true,
+			lib1Commit: "feat: add a feature",
+			lib2Commit: "fix: fix a bug",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: "1.0.1",
+			},
+		},
+		{
+			name:         "library without releasable commits",
+			libraryNames: []string{sample.Lib1Name},
+			lib1Commit:   "chore: update deps",
+			wantErr:      errNoReleasableChanges,
+		},
+		{
+			name:         "library without releasable commits and version",
+			libraryNames: []string{sample.Lib1Name},
+			versions:     map[string]string{sample.Lib1Name: "1.0.1"},
+			lib1Commit:   "chore: update deps",
+			wantVersions: map[string]string{sample.Lib1Name: "1.0.1"},
+		},
+		{
+			name:       "all with version for one library",
+			all:        true,
+			versions:   map[string]string{sample.Lib1Name: "2.0.0"},
+			lib1Commit: "fix: fix a bug",
+			lib2Commit: "fix: fix a bug",
+			wantVersions: map[string]string{
+				sample.Lib1Name: "2.0.0",
+				sample.Lib2Name: "1.0.1",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := sample.Config()
+			cfg.Release = &config.Release{ReleasableTypes: map[string]string{
+				"feat": "minor",
+				"fix":  "patch",
+				"perf": "patch",
+			}}
+			testhelper.Setup(t, testhelper.SetupOptions{
+				Clone:  true,
+				Config: cfg,
+				Tags:   []string{sample.InitialLib1Tag, sample.InitialLib2Tag},
+			})
+			if test.lib1Commit != "" {
+				writeFileAndCommit(t, filepath.Join(sample.Lib1Output, "src", "lib.rs"), []byte("lib1 change"), test.lib1Commit)
+			}
+			if test.lib2Commit != "" {
+				writeFileAndCommit(t, filepath.Join(sample.Lib2Output, "src", "lib.rs"), []byte("lib2 change"), test.lib2Commit)
+			}
+			err := runBump(t.Context(), config.LibrarianYAML, cfg, test.all, test.libraryNames, test.versions, "")
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("runBump() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+			got := map[string]string{}
+			for _, lib := range cfg.Libraries {
+				if _, ok := test.wantVersions[lib.Name]; ok {
+					got[lib.Name] = lib.Version
+				}
+			}
+			if diff := cmp.Diff(test.wantVersions, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunBump_Scopes(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	for _, test := range []struct {
+		name         string
+		sharedCommit string
+		lib2Commit   string
+		wantVersions map[string]string
+	}{
+		{
+			name:         "scoped commit outside output",
+			sharedCommit: "feat(lib1): add a shared feature",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: sample.InitialVersion,
+			},
+		},
+		{
+			name:         "unscoped commit outside output",
+			sharedCommit: "feat: add a shared feature",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.InitialVersion,
+				sample.Lib2Name: sample.InitialVersion,
+			},
+		},
+		{
+			name:         "other scope",
+			sharedCommit: "feat(lib2): add a shared feature",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.InitialVersion,
+				sample.Lib2Name: sample.InitialVersion,
+			},
+		},
+		{
+			name:         "scoped and path commits",
+			sharedCommit: "feat(lib1): add a shared feature",
+			lib2Commit:   "fix: fix a bug",
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: "1.0.1",
+			},
+		},
+		{
+			name:       "scoped commit in another library's output",
+			lib2Commit: "fix(lib1): fix a bug in both libraries",
+			wantVersions: map[string]string{
+				sample.Lib1Name: "1.0.1",
+				sample.Lib2Name: "1.0.1",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := sample.Config()
+			cfg.Release = &config.Release{ReleasableTypes: map[string]string{
+				"feat": "minor",
+				"fix":  "patch",
+			}}
+			cfg.Libraries[0].Scopes = []string{"lib1"}
+			testhelper.Setup(t, testhelper.SetupOptions{
+				Clone:  true,
+				Config: cfg,
+				Tags:   []string{sample.InitialLib1Tag, sample.InitialLib2Tag},
+			})
+			if test.sharedCommit != "" {
+				writeFileAndCommit(t, "shared.txt", []byte("shared change"), test.sharedCommit)
+			}
+			if test.lib2Commit != "" {
+				writeFileAndCommit(t, filepath.Join(sample.Lib2Output, "src", "lib.rs"), []byte("lib2 change"), test.lib2Commit)
+			}
+			if err := runBump(t.Context(), config.LibrarianYAML, cfg, true, nil, nil, ""); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, lib := range cfg.Libraries {
+				got[lib.Name] = lib.Version
+			}
+			if diff := cmp.Diff(test.wantVersions, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLibraryCommitMessages(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	cfg := sample.Config()
+	testhelper.Setup(t, testhelper.SetupOptions{
+		Clone:  true,
+		Config: cfg,
+		Tags:   []string{sample.InitialLib1Tag},
+	})
+	lib1File := filepath.Join(sample.Lib1Output, "src", "lib.rs")
+	writeFileAndCommit(t, lib1File, []byte("change 1"), "fix: path only")
+	writeFileAndCommit(t, "shared.txt", []byte("change 2"), "feat(lib1): scope only")
+	writeFileAndCommit(t, lib1File, []byte("change 3"), "feat(lib1): path and scope")
+	writeFileAndCommit(t, "shared.txt", []byte("change 4"), "feat(other): neither")
+	for _, test := range []struct {
+		name   string
+		scopes []string
+		want   []string
+	}{
+		{
+			name: "path only",
+			want: []string{"feat(lib1): path and scope", "fix: path only"},
+		},
+		{
+			name:   "path and scope",
+			scopes: []string{"lib1"},
+			want:   []string{"feat(lib1): path and scope", "fix: path only", "feat(lib1): scope only"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			lib := &config.Library{Name: sample.Lib1Name, Scopes: test.scopes}
+			got, err := libraryCommitMessages(t.Context(), lib, sample.InitialLib1Tag, sample.Lib1Output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestBumpLibrary_Error(t *testing.T) {
 	testhelper.RequireCommand(t, "git")
 
@@ -385,7 +964,7 @@ func TestBumpLibrary_Error(t *testing.T) {
 			testhelper.Setup(t, opts)
 
 			targetLibCfg := test.cfg.Libraries[0]
-			gotErr := bumpLibrary(test.cfg, targetLibCfg, test.versionOverride)
+			gotErr := bumpLibrary(t.Context(), test.cfg, targetLibCfg, test.versionOverride, "")
 			if gotErr == nil {
 				t.Fatal("expected error; got nil")
 			}
@@ -396,14 +975,27 @@ func TestBumpLibrary_Error(t *testing.T) {
 	}
 }
 
+func TestBumpLibrary_TagLookupError(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	// Outside a repository, looking up the release tag fails, which must not
+	// be mistaken for the tag being missing.
+	t.Chdir(t.TempDir())
+	cfg := sample.Config()
+	err := bumpLibrary(t.Context(), cfg, cfg.Libraries[0], "", "")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("bumpLibrary() error = %v, want a git failure", err)
+	}
+}
+
 func TestFindLibrariesToBump(t *testing.T) {
 	testhelper.RequireCommand(t, "git")
 	lib1Change := filepath.Join(sample.Lib1Output, "src", "lib.rs")
 	lib2Change := filepath.Join(sample.Lib2Output, "src", "lib.rs")
 	for _, test := range []struct {
-		name        string
-		all         bool
-		libraryName string
+		name         string
+		all          bool
+		libraryNames []string
 		// withChanges is a list of files to modify and then commit; this is
 		// used when that's all that's required.
 		withChanges []string
@@ -414,13 +1006,18 @@ func TestFindLibrariesToBump(t *testing.T) {
 		wantNames []string
 	}{
 		{
-			name:        "library specified directly",
-			libraryName: sample.Lib2Name,
-			wantNames:   []string{sample.Lib2Name},
+			name:         "library specified directly",
+			libraryNames: []string{sample.Lib2Name},
+			wantNames:    []string{sample.Lib2Name},
+		},
+		{
+			name:         "libraries specified directly",
+			libraryNames: []string{sample.Lib2Name, sample.Lib1Name, sample.Lib2Name},
+			wantNames:    []string{sample.Lib2Name, sample.Lib1Name},
 		},
 		{
-			name:        "library specified directly, ignored skip",
-			libraryName: sample.Lib2Name,
+			name:         "library specified directly, ignored skip",
+			libraryNames: []string{sample.Lib2Name},
 			setup: func(t *testing.T, cfg *config.Config) {
 				cfg.Libraries[1].SkipRelease = true
 				writeConfigAndCommit(t, cfg)
@@ -428,8 +1025,8 @@ func TestFindLibrariesToBump(t *testing.T) {
 			wantNames: []string{sample.Lib2Name},
 		},
 		{
-			name:        "library specified directly, ignored empty version",
-			libraryName: sample.Lib2Name,
+			name:         "library specified directly, ignored empty version",
+			libraryNames: []string{sample.Lib2Name},
 			setup: func(t *testing.T, cfg *config.Config) {
 				cfg.Libraries[1].Version = ""
 				writeConfigAndCommit(t, cfg)
@@ -496,7 +1093,7 @@ func TestFindLibrariesToBump(t *testing.T) {
 				// commit the config, tag it.
 				cfg.Libraries[1].Version = sample.NextVersion
 				writeConfigAndCommit(t, cfg)
-				tagName := formatTagName(cfg.Default.TagFormat, cfg.Libraries[1])
+				tagName := git.FormatTag(cfg.Default.TagFormat, cfg.Libraries[1].Name, cfg.Libraries[1].Version)
 				git.Tag(t.Context(), "git", tagName, "HEAD")
 			},
 		},
@@ -513,7 +1110,7 @@ func TestFindLibrariesToBump(t *testing.T) {
 				test.setup(t, cfg)
 			}
 
-			gotLibraries, err := findLibrariesToBump(t.Context(), cfg, test.all, test.libraryName)
+			gotLibraries, err := findLibrariesToBump(t.Context(), cfg, test.all, test.libraryNames, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -531,16 +1128,16 @@ func TestFindLibrariesToBump(t *testing.T) {
 func TestFindLibrariesToBump_Error(t *testing.T) {
 	testhelper.RequireCommand(t, "git")
 	for _, test := range []struct {
-		name        string
-		all         bool
-		libraryName string
-		setup       func(*testing.T, *config.Config)
-		wantErr     error
+		name         string
+		all          bool
+		libraryNames []string
+		setup        func(*testing.T, *config.Config)
+		wantErr      error
 	}{
 		{
-			name:        "specified library does not exist",
-			libraryName: "non-existent",
-			wantErr:     ErrLibraryNotFound,
+			name:         "specified library does not exist",
+			libraryNames: []string{"non-existent"},
+			wantErr:      ErrLibraryNotFound,
 		},
 		{
 			name: "library has no tag for last release",
@@ -564,7 +1161,7 @@ func TestFindLibrariesToBump_Error(t *testing.T) {
 				test.setup(t, cfg)
 			}
 
-			_, gotErr := findLibrariesToBump(t.Context(), cfg, test.all, test.libraryName)
+			_, gotErr := findLibrariesToBump(t.Context(), cfg, test.all, test.libraryNames, nil)
 			if gotErr == nil {
 				t.Fatal("expected error; got nil")
 			}
@@ -694,6 +1291,47 @@ func TestDeriveNextVersion(t *testing.T) {
 			versionOverride: "1.2.3",
 			wantVersion:     "1.2.3",
 		},
+		{
+			name: "prerelease from stable version",
+			cfg: func() *config.Config {
+				c := sample.Config()
+				c.Libraries[0].Version = "2.0.0"
+				return c
+			}(),
+			versionOpts: semver.DeriveNextOptions{Prerelease: "rc"},
+			wantVersion: "2.1.0-rc.1",
+		},
+		{
+			name: "prerelease of same identifier",
+			cfg: func() *config.Config {
+				c := sample.Config()
+				c.Libraries[0].Version = "2.1.0-rc.1"
+				return c
+			}(),
+			versionOpts: semver.DeriveNextOptions{Prerelease: "rc"},
+			wantVersion: "2.1.0-rc.2",
+		},
+		{
+			name: "prerelease, unreleased library",
+			cfg: func() *config.Config {
+				c := sample.Config()
+				c.Libraries[0].Version = ""
+				return c
+			}(),
+			versionOpts: semver.DeriveNextOptions{Prerelease: "beta"},
+			wantVersion: defaultVersion + "-beta.1",
+		},
+		{
+			name: "version override takes precedence over prerelease",
+			cfg: func() *config.Config {
+				c := sample.Config()
+				c.Libraries[0].Version = "2.0.0"
+				return c
+			}(),
+			versionOpts:     semver.DeriveNextOptions{Prerelease: "rc"},
+			versionOverride: "3.0.0",
+			wantVersion:     "3.0.0",
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			opts := testhelper.SetupOptions{
@@ -702,7 +1340,7 @@ func TestDeriveNextVersion(t *testing.T) {
 			}
 			testhelper.Setup(t, opts)
 
-			got, err := deriveNextVersion(test.cfg.Libraries[0], test.versionOpts, test.versionOverride)
+			got, err := deriveNextVersion(test.cfg.Libraries[0], test.versionOpts, test.versionOverride, semver.Minor)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -738,9 +1376,18 @@ func TestDeriveNextVersion_Error(t *testing.T) {
 			}(),
 			versionOverride: "1.2.1",
 		},
+		{
+			name: "prerelease identifier regression",
+			cfg: func() *config.Config {
+				c := sample.Config()
+				c.Libraries[0].Version = "2.1.0-rc.1"
+				return c
+			}(),
+			versionOpts: semver.DeriveNextOptions{Prerelease: "beta"},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := deriveNextVersion(test.cfg.Libraries[0], test.versionOpts, test.versionOverride)
+			got, err := deriveNextVersion(test.cfg.Libraries[0], test.versionOpts, test.versionOverride, semver.Minor)
 			if err == nil {
 				t.Errorf("DeriveNextVersion() expected error; returned no error and version %s", got)
 			}
@@ -912,7 +1559,7 @@ func TestFindLatestReleaseCommitHash(t *testing.T) {
 			if test.wantCommitCount != len(commits) {
 				t.Fatalf("expected setup to create %d commits; got %d", test.wantCommitCount, len(commits))
 			}
-			got, err := findLatestReleaseCommitHash(t.Context())
+			got, err := findLatestReleaseCommitHash(t.Context(), config.LibrarianYAML)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -983,7 +1630,7 @@ func TestFindLatestReleaseCommitHash_Error(t *testing.T) {
 			}
 			testhelper.Setup(t, opts)
 			test.setup(cfg)
-			got, err := findLatestReleaseCommitHash(t.Context())
+			got, err := findLatestReleaseCommitHash(t.Context(), config.LibrarianYAML)
 			if err == nil {
 				t.Errorf("expected error; succeeded with hash %s", got)
 			}
@@ -1030,7 +1677,7 @@ func TestLegacyRustBumpLibrary(t *testing.T) {
 
 			targetLibCfg := test.cfg.Libraries[0]
 			// Unused string param: lastTag.
-			err := legacyRustBumpLibrary(t.Context(), test.cfg, targetLibCfg, testUnusedStringParam, test.versionOverride)
+			err := legacyRustBumpLibrary(t.Context(), test.cfg, targetLibCfg, testUnusedStringParam, test.versionOverride, "")
 			if err != nil {
 				t.Fatalf("legacyRustBumpLibrary() error = %v", err)
 			}
@@ -1048,25 +1695,35 @@ func TestLegacyRustBump(t *testing.T) {
 	lib2Change := filepath.Join(sample.Lib2Output, "src", "lib.rs")
 
 	for _, test := range []struct {
-		name            string
-		libraryName     string
-		versionOverride string
-		all             bool
-		withChanges     []string
-		wantVersions    map[string]string
+		name         string
+		libraryNames []string
+		versions     map[string]string
+		all          bool
+		withChanges  []string
+		wantVersions map[string]string
 	}{
 		{
 			name:         "library name",
-			libraryName:  sample.Lib1Name,
+			libraryNames: []string{sample.Lib1Name},
 			withChanges:  []string{lib1Change},
 			wantVersions: map[string]string{sample.Lib1Name: sample.NextVersion},
 		},
 		{
-			name:            "library name and explicit version",
-			libraryName:     sample.Lib1Name,
-			versionOverride: "1.2.3",
-			withChanges:     []string{lib1Change},
-			wantVersions:    map[string]string{sample.Lib1Name: "1.2.3"},
+			name:         "library name and explicit version",
+			libraryNames: []string{sample.Lib1Name},
+			versions:     map[string]string{sample.Lib1Name: "1.2.3"},
+			withChanges:  []string{lib1Change},
+			wantVersions: map[string]string{sample.Lib1Name: "1.2.3"},
+		},
+		{
+			name:        "all flag and explicit version",
+			all:         true,
+			versions:    map[string]string{sample.Lib2Name: "1.2.3"},
+			withChanges: []string{lib1Change, lib2Change},
+			wantVersions: map[string]string{
+				sample.Lib1Name: sample.NextVersion,
+				sample.Lib2Name: "1.2.3",
+			},
 		},
 		{
 			name:        "all flag all have changes",
@@ -1094,7 +1751,7 @@ func TestLegacyRustBump(t *testing.T) {
 			}
 			testhelper.Setup(t, opts)
 
-			if err := legacyRustBump(t.Context(), cfg, test.all, test.libraryName, test.versionOverride); err != nil {
+			if err := legacyRustBump(t.Context(), config.LibrarianYAML, cfg, test.all, test.libraryNames, test.versions, ""); err != nil {
 				t.Fatal(err)
 			}
 
@@ -1156,7 +1813,7 @@ func TestLegacyRustBumpAll(t *testing.T) {
 			}
 			testhelper.Setup(t, opts)
 
-			err := legacyRustBumpAll(t.Context(), targetCfg, sinceTag)
+			err := legacyRustBumpAll(t.Context(), targetCfg, sinceTag, nil, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// generateCheckpointVersion is the schema version of [generateCheckpoint].
+// Checkpoints with a different version are ignored.
+const generateCheckpointVersion = 1
+
+var errResumeRequiresCheckpoint = errors.New("--resume requires --checkpoint-file")
+
+// generateCheckpoint records the libraries that a generate run completed, in
+// the file given by the --checkpoint-file flag, so that an interrupted run
+// can be resumed with --resume. It is keyed on the googleapis commit: a
+// checkpoint for another commit is ignored, as its libraries are out of date.
+type generateCheckpoint struct {
+	SchemaVersion    int      `json:"schemaVersion"`
+	GoogleapisCommit string   `json:"googleapisCommit"`
+	Completed        []string `json:"completed"`
+
+	// path is the file the checkpoint is written to.
+	path string
+}
+
+// newGenerateCheckpoint returns an empty checkpoint for googleapisCommit,
+// written to path.
+func newGenerateCheckpoint(path, googleapisCommit string) *generateCheckpoint {
+	return &generateCheckpoint{
+		SchemaVersion:    generateCheckpointVersion,
+		GoogleapisCommit: googleapisCommit,
+		Completed:        []string{},
+		path:             path,
+	}
+}
+
+// readGenerateCheckpoint reads the checkpoint at path. If the file does not
+// exist, or records another schema version or googleapis commit, an empty
+// checkpoint is returned. A checkpoint is never used when googleapisCommit
+// is empty, such as when generating from a local directory, since the inputs
+// cannot be compared.
+func readGenerateCheckpoint(path, googleapisCommit string) (*generateCheckpoint, error) {
+	checkpoint := newGenerateCheckpoint(path, googleapisCommit)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved generateCheckpoint
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, err
+	}
+	switch {
+	case googleapisCommit == "":
+		slog.Info("ignoring checkpoint: googleapis commit is unknown", "path", path)
+	case saved.SchemaVersion != generateCheckpointVersion:
+		slog.Info("ignoring checkpoint with unsupported schema version", "path", path, "version", saved.SchemaVersion)
+	case saved.GoogleapisCommit != googleapisCommit:
+		slog.Info("ignoring checkpoint for another googleapis commit", "path", path, "commit", saved.GoogleapisCommit)
+	default:
+		checkpoint.Completed = append(checkpoint.Completed, saved.Completed...)
+	}
+	return checkpoint, nil
+}
+
+// skipCompleted returns the libraries that the checkpoint does not record as
+// completed.
+func (c *generateCheckpoint) skipCompleted(libraries []*config.Library) []*config.Library {
+	var remaining []*config.Library
+	for _, library := range libraries {
+		if slices.Contains(c.Completed, library.Name) {
+			slog.Info("skipping library completed by a previous run", "library", library.Name)
+			continue
+		}
+		remaining = append(remaining, library)
+	}
+	return remaining
+}
+
+// record adds libraries to the completed libraries and writes the checkpoint.
+// It does nothing if c is nil.
+func (c *generateCheckpoint) record(libraries []*config.Library) error {
+	if c == nil {
+		return nil
+	}
+	for _, library := range libraries {
+		c.Completed = append(c.Completed, library.Name)
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that an interrupted write does not
+	// leave a truncated checkpoint.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
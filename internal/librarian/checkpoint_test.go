@@ -0,0 +1,180 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestReadGenerateCheckpoint(t *testing.T) {
+	const commit = "abc123"
+	for _, test := range []struct {
+		name    string
+		content string
+		commit  string
+		want    []string
+	}{
+		{
+			name:   "missing file",
+			commit: commit,
+			want:   []string{},
+		},
+		{
+			name:    "same commit",
+			content: `{"schemaVersion": 1, "googleapisCommit": "abc123", "completed": ["a", "b"]}`,
+			commit:  commit,
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "other commit",
+			content: `{"schemaVersion": 1, "googleapisCommit": "def456", "completed": ["a", "b"]}`,
+			commit:  commit,
+			want:    []string{},
+		},
+		{
+			name:    "unknown commit",
+			content: `{"schemaVersion": 1, "completed": ["a", "b"]}`,
+			want:    []string{},
+		},
+		{
+			name:    "other schema version",
+			content: `{"schemaVersion": 2, "googleapisCommit": "abc123", "completed": ["a", "b"]}`,
+			commit:  commit,
+			want:    []string{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+			if test.content != "" {
+				if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := readGenerateCheckpoint(path, test.commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got.Completed); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadGenerateCheckpoint_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readGenerateCheckpoint(path, "abc123"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestGenerateCheckpoint_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint := newGenerateCheckpoint(path, "abc123")
+	if err := checkpoint.record([]*config.Library{{Name: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.record([]*config.Library{{Name: "b"}, {Name: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readGenerateCheckpoint(path, "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	libraries := []*config.Library{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+	remaining := got.skipCompleted(libraries)
+	if diff := cmp.Diff([]*config.Library{{Name: "d"}}, remaining); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunGenerate_Checkpoint(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1":       "speech_v1.yaml",
+		"google/cloud/texttospeech/v1": "texttospeech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	writeFiles(t, map[string]string{
+		"speech/README.md":       "original",
+		"texttospeech/README.md": "original",
+		"texttospeech/VERSION":   "1.0.0",
+	})
+	cfg := &config.Config{
+		Language: config.LanguageFake,
+		Sources:  &config.Sources{Googleapis: &config.Source{Dir: googleapisDir}},
+	}
+	speech := &config.Library{
+		Name:   "speech",
+		Output: "speech",
+		APIs:   []*config.API{{Path: "google/cloud/speech/v1"}},
+	}
+	// texttospeech is generated after speech, and fails as the fake generator
+	// leaves VERSION, which is not in its allowlist.
+	texttospeech := &config.Library{
+		Name:            "texttospeech",
+		Output:          "texttospeech",
+		APIs:            []*config.API{{Path: "google/cloud/texttospeech/v1"}},
+		DependsOn:       []string{"speech"},
+		OutputAllowlist: []string{"README.md"},
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint := newGenerateCheckpoint(path, "abc123")
+	outcome, err := runGenerate(t.Context(), cfg, []*config.Library{speech, texttospeech}, 1, false, false, checkpoint, nil)
+	if !errors.Is(err, errFileNotAllowed) {
+		t.Fatalf("want error %v, got %v", errFileNotAllowed, err)
+	}
+	if diff := cmp.Diff([]*config.Library{speech}, outcome.completed); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if findLibraryError(err, texttospeech) == nil {
+		t.Errorf("want error attributed to %q, got %v", texttospeech.Name, err)
+	}
+	got, err := readGenerateCheckpoint(path, "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"speech"}, got.Completed); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	// The completed library is kept, and the failed one is rolled back.
+	for name, want := range map[string]string{
+		"speech/README.md":       "# speech\n\nGenerated library\n\n---\nFormatted\n",
+		"texttospeech/README.md": "original",
+	} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(content)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestGenerateCommand_ResumeRequiresCheckpoint(t *testing.T) {
+	err := Run(t.Context(), "librarian", "generate", "--all", "--resume")
+	if !errors.Is(err, errResumeRequiresCheckpoint) {
+		t.Errorf("want error %v, got %v", errResumeRequiresCheckpoint, err)
+	}
+}
@@ -20,17 +20,75 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/filesystem"
 )
 
+// librarianIgnoreFile is the name of an optional file in a library's output
+// directory listing gitignore-style patterns of files that clean preserves.
+const librarianIgnoreFile = ".librarianignore"
+
+// cleanPlan returns the files that cleaning library would remove, without
+// removing them. The language-specific clean is run on a temporary copy of
+// the output directory of library, so that the result matches a real clean.
+func cleanPlan(language string, library *config.Library) ([]string, error) {
+	if _, err := os.Stat(library.Output); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	dir, err := os.MkdirTemp("", "librarian-clean-plan-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	copied := filepath.Join(dir, "output")
+	if err := filesystem.CopyDir(library.Output, copied); err != nil {
+		return nil, err
+	}
+	clone := *library
+	clone.Output = copied
+	if err := cleanLibraries(language, []*config.Library{&clone}); err != nil {
+		return nil, err
+	}
+	var removed []string
+	err = filepath.WalkDir(library.Output, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(library.Output, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(copied, rel)); errors.Is(err, fs.ErrNotExist) {
+			removed = append(removed, path)
+		}
+		return nil
+	})
+	return removed, err
+}
+
 // checkAndClean removes all files in dir except those in keep. The keep list
 // should contain paths relative to dir. It returns an error if any file
 // in keep does not exist.
+//
+// Files matching the patterns in dir/.librarianignore are also preserved,
+// as is the .librarianignore file itself. The keep list takes precedence: a
+// negated pattern such as "!README.md" cannot remove a file listed in keep.
 func checkAndClean(dir string, keep []string) error {
 	keepSet := make(map[string]bool)
 	for _, k := range keep {
 		keepSet[filepath.Clean(k)] = true
 	}
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+	ignore, err := readLibrarianIgnore(dir)
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -45,6 +103,12 @@ func checkAndClean(dir string, keep []string) error {
 			keepSet[rel] = false
 			return nil
 		}
+		if rel == librarianIgnoreFile {
+			return nil
+		}
+		if ignore != nil && ignore.Match(strings.Split(filepath.ToSlash(rel), "/"), false) {
+			return nil
+		}
 		return os.Remove(path)
 	})
 	if err != nil {
@@ -67,3 +131,80 @@ func checkAndClean(dir string, keep []string) error {
 	}
 	return nil
 }
+
+// preserveIgnored runs clean and then restores the files in dir matched by
+// dir/.librarianignore, and the .librarianignore file itself. It lets
+// languages whose clean does not read .librarianignore honor it.
+func preserveIgnored(dir string, clean func() error) error {
+	ignore, err := readLibrarianIgnore(dir)
+	if err != nil {
+		return err
+	}
+	if ignore == nil {
+		return clean()
+	}
+	saved, err := os.MkdirTemp("", "librarian-ignore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(saved)
+	var preserved []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel != librarianIgnoreFile && !ignore.Match(strings.Split(filepath.ToSlash(rel), "/"), false) {
+			return nil
+		}
+		preserved = append(preserved, rel)
+		return copyFileAll(path, filepath.Join(saved, rel))
+	})
+	if err != nil {
+		return err
+	}
+	if err := clean(); err != nil {
+		return err
+	}
+	for _, rel := range preserved {
+		if err := copyFileAll(filepath.Join(saved, rel), filepath.Join(dir, rel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFileAll copies src to dest, creating the parent directories of dest.
+func copyFileAll(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	return filesystem.CopyFile(src, dest)
+}
+
+// readLibrarianIgnore parses dir/.librarianignore. It returns a nil matcher
+// if the file does not exist.
+func readLibrarianIgnore(dir string) (gitignore.Matcher, error) {
+	content, err := os.ReadFile(filepath.Join(dir, librarianIgnoreFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var patterns []gitignore.Pattern
+	for line := range strings.Lines(string(content)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return gitignore.NewMatcher(patterns), nil
+}
@@ -26,6 +26,7 @@ func TestCleanOutput(t *testing.T) {
 		name    string
 		files   []string
 		keep    []string
+		ignore  string
 		want    []string
 		wantErr bool
 	}{
@@ -81,6 +82,31 @@ func TestCleanOutput(t *testing.T) {
 			keep:  []string{"./Cargo.toml"},
 			want:  []string{"Cargo.toml"},
 		},
+		{
+			name:   "librarianignore patterns",
+			files:  []string{"Cargo.toml", "README.md", "src/lib.rs", "src/custom.rs"},
+			ignore: "# handwritten files\nREADME.md\nsrc/custom.rs\n",
+			want:   []string{"README.md", "src/custom.rs"},
+		},
+		{
+			name:   "librarianignore negated pattern",
+			files:  []string{"notes/a.md", "notes/b.md", "src/lib.rs"},
+			ignore: "*.md\n!b.md\n",
+			want:   []string{"notes/a.md"},
+		},
+		{
+			name:   "librarianignore directory pattern",
+			files:  []string{"custom/a.rs", "custom/nested/b.rs", "src/custom", "src/lib.rs"},
+			ignore: "custom/\n",
+			want:   []string{"custom/a.rs", "custom/nested/b.rs"},
+		},
+		{
+			name:   "keep takes precedence over negated pattern",
+			files:  []string{"Cargo.toml", "README.md"},
+			keep:   []string{"Cargo.toml"},
+			ignore: "!Cargo.toml\n",
+			want:   []string{"Cargo.toml"},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			dir := t.TempDir()
@@ -93,6 +119,11 @@ func TestCleanOutput(t *testing.T) {
 					t.Fatal(err)
 				}
 			}
+			if test.ignore != "" {
+				if err := os.WriteFile(filepath.Join(dir, librarianIgnoreFile), []byte(test.ignore), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
 			err := checkAndClean(dir, test.keep)
 			if test.wantErr {
 				if err == nil {
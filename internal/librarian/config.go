@@ -21,7 +21,6 @@ import (
 	"io"
 
 	"github.com/googleapis/librarian/internal/config"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
@@ -54,7 +53,7 @@ Supported cases:
   - Source repository field (e.g., commit, sha256, dir, subpath):
     librarian config get sources.[source-name].[field-name]`,
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return runConfigGet(cmd.Root().Writer, cmd.Args().Get(0), cmd.Args().Get(1))
+					return runConfigGet(cmd.Root().Writer, cmd.String("config-file"), cmd.Args().Get(0), cmd.Args().Get(1))
 				},
 			},
 			{
@@ -62,18 +61,18 @@ Supported cases:
 				Usage:     "set a configuration value",
 				UsageText: "librarian config set [path] [value]",
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return runConfigSet(cmd.Args().Get(0), cmd.Args().Get(1))
+					return runConfigSet(ctx, cmd.String("config-file"), cmd.Args().Get(0), cmd.Args().Get(1))
 				},
 			},
 		},
 	}
 }
 
-func runConfigGet(w io.Writer, path, value string) error {
+func runConfigGet(w io.Writer, configPath, path, value string) error {
 	if path == "" {
 		return errPathRequired
 	}
-	cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+	cfg, err := readConfig(configPath)
 	if err != nil {
 		return err
 	}
@@ -96,22 +95,22 @@ func runConfigGet(w io.Writer, path, value string) error {
 	return err
 }
 
-func runConfigSet(path, value string) error {
+func runConfigSet(ctx context.Context, configPath, path, value string) error {
 	if path == "" {
 		return errPathRequired
 	}
 	if value == "" {
 		return errValueRequired
 	}
-	cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+	cfg, err := readConfig(configPath)
 	if err != nil {
 		return err
 	}
-	updated, err := setConfigValue(cfg, path, value)
+	updated, err := setConfigValue(ctx, cfg, path, value)
 	if err != nil {
 		return err
 	}
-	return yaml.Write(config.LibrarianYAML, updated)
+	return writeConfig(configPath, updated)
 }
 
 func libraryName(cfg *config.Config, apiPath string) (string, error) {
@@ -67,7 +67,7 @@ libraries:
 				t.Fatal(err)
 			}
 			var buf bytes.Buffer
-			err = runConfigGet(&buf, test.path, test.value)
+			err = runConfigGet(&buf, config.LibrarianYAML, test.path, test.value)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -121,7 +121,7 @@ func TestRunConfigGet_Error(t *testing.T) {
 				t.Fatal(err)
 			}
 			var buf bytes.Buffer
-			err := runConfigGet(&buf, test.path, test.value)
+			err := runConfigGet(&buf, config.LibrarianYAML, test.path, test.value)
 			if err == nil {
 				t.Fatal("expected error; got nil")
 			}
@@ -155,7 +155,7 @@ func TestRunConfigSet(t *testing.T) {
 			if err := os.WriteFile("librarian.yaml", []byte(test.configYAML), 0o644); err != nil {
 				t.Fatal(err)
 			}
-			err := runConfigSet(test.path, test.value)
+			err := runConfigSet(t.Context(), config.LibrarianYAML, test.path, test.value)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -207,7 +207,7 @@ func TestRunConfigSet_Error(t *testing.T) {
 			if err := os.WriteFile("librarian.yaml", []byte(test.configYAML), 0o644); err != nil {
 				t.Fatal(err)
 			}
-			err := runConfigSet(test.path, test.value)
+			err := runConfigSet(t.Context(), config.LibrarianYAML, test.path, test.value)
 			if err == nil {
 				t.Fatal("expected error; got nil")
 			}
@@ -222,7 +222,7 @@ func TestRunConfigSet_Error(t *testing.T) {
 func TestRunConfigSet_FileNotFound(t *testing.T) {
 	tempDir := t.TempDir()
 	t.Chdir(tempDir)
-	err := runConfigSet("version", "1.2.4")
+	err := runConfigSet(t.Context(), config.LibrarianYAML, "version", "1.2.4")
 	if err == nil {
 		t.Fatal("expected error; got nil")
 	}
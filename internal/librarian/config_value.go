@@ -15,6 +15,7 @@
 package librarian
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -29,7 +30,7 @@ var (
 )
 
 // setConfigValue sets a value at a specific path within the configuration.
-func setConfigValue(cfg *config.Config, path string, value string) (*config.Config, error) {
+func setConfigValue(ctx context.Context, cfg *config.Config, path string, value string) (*config.Config, error) {
 	parts := strings.Split(path, ".")
 	if len(parts) == 1 {
 		switch parts[0] {
@@ -48,7 +49,7 @@ func setConfigValue(cfg *config.Config, path string, value string) (*config.Conf
 		}
 		switch fieldName {
 		case "commit":
-			commit, sha256, err := fetchSourceCommitAndChecksum("sources."+sourceName, value)
+			commit, sha256, err := fetchSourceCommitAndChecksum(ctx, "sources."+sourceName, value)
 			if err != nil {
 				return nil, err
 			}
@@ -154,16 +155,22 @@ func getSource(cfg *config.Config, sourceName string) *config.Source {
 	return *sourcePointer
 }
 
-// fetchSourceCommitAndChecksum gets the commit and checksum for a source.
-func fetchSourceCommitAndChecksum(sourceName string, branch string) (string, string, error) {
+// fetchSourceCommitAndChecksum gets the commit and checksum for a source. The
+// GitHub API request is authenticated if a GitHub token is found.
+func fetchSourceCommitAndChecksum(ctx context.Context, sourceName string, branch string) (string, string, error) {
 	repo, ok := sourceRepos[sourceName]
 	if !ok {
 		return "", "", fmt.Errorf("%w: %s", errUnknownSource, sourceName)
 	}
 	repo.Branch = branch
+	token, _, err := githubToken(ctx)
+	if err != nil {
+		return "", "", err
+	}
 	endpoints := &fetch.Endpoints{
 		API:      githubAPI,
 		Download: githubDownload,
+		Token:    token,
 	}
-	return fetch.LatestCommitAndChecksum(endpoints, &repo)
+	return fetch.LatestCommitAndChecksum(ctx, endpoints, &repo)
 }
@@ -196,7 +196,7 @@ func TestSetConfigValue(t *testing.T) {
 			cfg := &config.Config{
 				Version: "v1.0.0",
 			}
-			got, err := setConfigValue(cfg, test.path, test.value)
+			got, err := setConfigValue(t.Context(), cfg, test.path, test.value)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -232,7 +232,7 @@ func TestSetConfigValue_Error(t *testing.T) {
 			cfg := &config.Config{
 				Version: "v1.0.0",
 			}
-			_, err := setConfigValue(cfg, test.path, test.value)
+			_, err := setConfigValue(t.Context(), cfg, test.path, test.value)
 			if err == nil {
 				t.Errorf("setConfigValue(%q, %q) got nil err, want error", test.path, test.value)
 			}
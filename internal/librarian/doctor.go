@@ -0,0 +1,189 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// doctorContainerRuntime is the container runtime checked by doctor. It
+	// is only needed to run librarian in a container, for example with
+	// librarianops generate --docker.
+	doctorContainerRuntime = "docker"
+	// doctorHTTPTimeout bounds the GitHub API request made by doctor.
+	doctorHTTPTimeout = 10 * time.Second
+)
+
+var (
+	errDoctorChecksFailed = errors.New("required checks failed")
+	errGitHubTokenInvalid = errors.New("GitHub rejected the token")
+)
+
+// doctorCheck is a single check of the local environment.
+type doctorCheck struct {
+	// name is printed in the checklist.
+	name string
+	// required checks make doctor fail. Failures of other checks are
+	// reported as warnings.
+	required bool
+	// run performs the check, returning a short description of what was
+	// found on success.
+	run func(ctx context.Context) (string, error)
+}
+
+func doctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "doctor",
+		Usage:     "check the local environment for common problems",
+		UsageText: "librarian doctor",
+		Description: `doctor checks that the local environment can run librarian, and prints
+a checklist of the results. It checks that:
+
+  - git is installed
+  - the current directory contains a valid librarian.yaml
+  - the GitHub API is reachable, and accepts the GitHub token if one is
+    found in LIBRARIAN_GITHUB_TOKEN, LIBRARIAN_GITHUB_TOKEN_FILE or gh
+  - a container runtime (docker) is installed
+
+The GitHub API and container runtime checks are optional: their failures
+are reported as warnings. doctor exits with a non-zero status if any
+required check fails.`,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return runDoctor(ctx, cmd.Root().Writer, doctorChecks(cmd.String("config-file")))
+		},
+	}
+}
+
+// doctorChecks returns the checks run by librarian doctor, in the order they
+// are printed, checking the configuration file at configPath.
+func doctorChecks(configPath string) []*doctorCheck {
+	return []*doctorCheck{
+		{name: "git", required: true, run: checkGit},
+		{name: configPath, required: true, run: func(context.Context) (string, error) {
+			return checkLibrarianYAML(configPath)
+		}},
+		{name: "GitHub API", run: checkGitHubAPI},
+		{name: doctorContainerRuntime, run: checkContainerRuntime},
+	}
+}
+
+// runDoctor runs checks and writes a line for each result to w. It returns
+// errDoctorChecksFailed if any required check fails.
+func runDoctor(ctx context.Context, w io.Writer, checks []*doctorCheck) error {
+	var failed []string
+	for _, check := range checks {
+		detail, err := check.run(ctx)
+		status := "ok"
+		switch {
+		case err != nil && check.required:
+			status = "FAIL"
+			failed = append(failed, check.name)
+		case err != nil:
+			status = "warn"
+		}
+		if err != nil {
+			detail = err.Error()
+		}
+		if _, err := fmt.Fprintf(w, "[%s] %s: %s\n", status, check.name, detail); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", errDoctorChecksFailed, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func checkGit(ctx context.Context) (string, error) {
+	out, err := command.Output(ctx, command.Git, "--version")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func checkLibrarianYAML(configPath string) (string, error) {
+	cfg, err := readConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+	if err := validateConfig(cfg); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("language %s, %d libraries", cfg.Language, len(cfg.Libraries)), nil
+}
+
+// checkGitHubAPI queries the GitHub API rate limit, which does not count
+// against the limit. If a GitHub token is found, it is sent with the request
+// so that an invalid token is reported.
+func checkGitHubAPI(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, doctorHTTPTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubAPI+"/rate_limit", nil)
+	if err != nil {
+		return "", err
+	}
+	token, source, err := githubToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w from %s", errGitHubTokenInvalid, source)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response from %s: %s", githubAPI, resp.Status)
+	}
+	var body struct {
+		Rate struct {
+			Limit     int `json:"limit"`
+			Remaining int `json:"remaining"`
+		} `json:"rate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	auth := "unauthenticated"
+	if token != "" {
+		auth = "authenticated with " + source
+	}
+	return fmt.Sprintf("%s, %d of %d requests remaining", auth, body.Rate.Remaining, body.Rate.Limit), nil
+}
+
+func checkContainerRuntime(ctx context.Context) (string, error) {
+	out, err := command.Output(ctx, doctorContainerRuntime, "--version")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
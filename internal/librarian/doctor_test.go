@@ -0,0 +1,150 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestRunDoctor(t *testing.T) {
+	pass := func(context.Context) (string, error) { return "found", nil }
+	fail := func(context.Context) (string, error) { return "", errors.New("not found") }
+	for _, test := range []struct {
+		name    string
+		checks  []*doctorCheck
+		want    string
+		wantErr error
+	}{
+		{
+			name: "all pass",
+			checks: []*doctorCheck{
+				{name: "a", required: true, run: pass},
+				{name: "b", run: pass},
+			},
+			want: "[ok] a: found\n[ok] b: found\n",
+		},
+		{
+			name: "optional failure",
+			checks: []*doctorCheck{
+				{name: "a", required: true, run: pass},
+				{name: "b", run: fail},
+			},
+			want: "[ok] a: found\n[warn] b: not found\n",
+		},
+		{
+			name: "required failure",
+			checks: []*doctorCheck{
+				{name: "a", required: true, run: fail},
+				{name: "b", run: pass},
+			},
+			want:    "[FAIL] a: not found\n[ok] b: found\n",
+			wantErr: errDoctorChecksFailed,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := runDoctor(t.Context(), &buf, test.checks)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("runDoctor() error = %v, want %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, buf.String()); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckLibrarianYAML(t *testing.T) {
+	setupTestConfig(t, &config.Config{
+		Language: config.LanguageFake,
+		Sources:  &config.Sources{Googleapis: &config.Source{Commit: "abc123"}},
+	})
+	got, err := checkLibrarianYAML(config.LibrarianYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("language fake, 0 libraries", got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckLibrarianYAML_Error(t *testing.T) {
+	setupTestConfig(t, &config.Config{Language: config.LanguageFake})
+	if _, err := checkLibrarianYAML(config.LibrarianYAML); !errors.Is(err, errNoGoogleapiSourceInfo) {
+		t.Errorf("checkLibrarianYAML() error = %v, want %v", err, errNoGoogleapiSourceInfo)
+	}
+}
+
+func TestCheckGitHubAPI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "":
+			w.Write([]byte(`{"rate": {"limit": 60, "remaining": 59}}`))
+		case "Bearer good-token":
+			w.Write([]byte(`{"rate": {"limit": 5000, "remaining": 4999}}`))
+		default:
+			http.Error(w, "Bad credentials", http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+	originalAPI := githubAPI
+	t.Cleanup(func() { githubAPI = originalAPI })
+	githubAPI = ts.URL
+
+	for _, test := range []struct {
+		name    string
+		token   string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "unauthenticated",
+			want: "unauthenticated, 59 of 60 requests remaining",
+		},
+		{
+			name:  "valid token",
+			token: "good-token",
+			want:  "authenticated with LIBRARIAN_GITHUB_TOKEN, 4999 of 5000 requests remaining",
+		},
+		{
+			name:    "invalid token",
+			token:   "bad-token",
+			wantErr: errGitHubTokenInvalid,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(envGitHubToken, test.token)
+			t.Setenv(envGitHubTokenFile, "")
+			// Hide gh, so that the unauthenticated case does not pick up
+			// a local login.
+			t.Setenv("PATH", t.TempDir())
+			got, err := checkGitHubAPI(t.Context())
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("checkGitHubAPI() error = %v, want %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
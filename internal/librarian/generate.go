@@ -18,10 +18,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/googleapis/librarian/internal/command"
 	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/fetch"
+	"github.com/googleapis/librarian/internal/git"
 	"github.com/googleapis/librarian/internal/librarian/dart"
 	"github.com/googleapis/librarian/internal/librarian/golang"
 	"github.com/googleapis/librarian/internal/librarian/java"
@@ -32,7 +42,6 @@ import (
 	"github.com/googleapis/librarian/internal/librarian/rust"
 	"github.com/googleapis/librarian/internal/librarian/swift"
 	"github.com/googleapis/librarian/internal/sources"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 	"golang.org/x/sync/errgroup"
 )
@@ -43,6 +52,18 @@ var (
 	errSkipGenerate            = errors.New("library has skip_generate set")
 	errNoPreviewVariant        = errors.New("library does not have a preview variant")
 	errUnsupportedLanguage     = errors.New("language does not support generation")
+	errInvalidMaxConcurrency   = errors.New("--max-concurrency must not be negative")
+	errBothLibraryAndFilter    = errors.New("cannot specify both library name and --library-filter flag")
+	errBothAllAndFilter        = errors.New("cannot specify both --all and --library-filter flags")
+	errNoLibraryMatchesFilter  = errors.New("no libraries to generate match filter")
+	errSinceRequiresSourceDir  = errors.New("--since requires sources.googleapis.dir to be a git repository")
+	errSinceNotInHistory       = errors.New("--since must name a commit in the history of the googleapis checkout")
+	errAPISourceNotDir         = errors.New("--api-source must be an existing directory")
+	errUpdateRequiresSourceDir = errors.New("--update-source requires sources.googleapis.dir to be a git repository")
+	errNoChanges               = errors.New("generation produced no changes")
+	errDependencyCycle         = errors.New("library dependencies form a cycle")
+	errExcludeRequiresAll      = errors.New("--exclude-library requires --all or --library-filter")
+	errAllLibrariesExcluded    = errors.New("no libraries left to generate after --exclude-library")
 )
 
 func generateCommand() *cli.Command {
@@ -54,16 +75,119 @@ func generateCommand() *cli.Command {
 librarian.yaml.
 
 The library name argument selects a single library to regenerate. Use the
---all flag to regenerate every library in the workspace instead. Exactly
-one of <library> or --all must be provided.
+--all flag to regenerate every library in the workspace instead, or the
+--library-filter flag to regenerate every library whose name matches a
+regular expression. Exactly one of <library>, --all or --library-filter
+must be provided.
+
+The --exclude-library flag skips the named library when generating with
+--all or --library-filter, for example to work around a library that is
+temporarily broken. It can be repeated, and each name must match a library
+in librarian.yaml exactly. Unlike skip_generate, it only affects a single
+run.
 
 Generation is delegated to the language-specific tooling configured in
 librarian.yaml. Libraries marked with skip_generate are skipped.
 
+The --dry-run flag prints the libraries that would be generated, with their
+APIs and the files that cleaning would remove, and changes nothing. The
+googleapis checkout is not updated by --update-source in a dry run.
+
+The output directories of the libraries are copied to a temporary directory
+before they are cleaned. If cleaning or generation fails, they are restored
+from that copy, so a failed run does not leave half-cleaned libraries behind.
+The copy costs as much disk space and I/O as the outputs themselves, which is
+significant when generating every library of a large repository. The
+--no-rollback flag skips it when another mechanism, such as git, is used to
+discard a failed run. Without the copy, a failed run leaves the libraries as
+they are, and noop_ignore_patterns are not applied.
+
+After a library is generated, the shell commands in post_generate_hooks, of
+the default section and then of the library, are run with sh -c in its
+output directory, with LIBRARIAN_LIBRARY and LIBRARIAN_VERSION set to the
+name and version of the library. A failing hook fails generation like a
+failing generator, and the library is rolled back.
+
+Libraries with noop_ignore_patterns in librarian.yaml are left unchanged if
+the only lines changed by generation match those patterns, such as a
+generation date stamped into each file. No provenance file is written for
+them.
+
+The --since flag skips libraries whose APIs have not changed since the
+given commit. It requires sources.googleapis.dir to point at a git
+checkout of googleapis, and the commit must be in the history of that
+checkout.
+
+The --api-source flag generates from a local googleapis directory instead of
+the source configured in librarian.yaml, which is left unchanged. The
+directory is treated as a read-only source tree and does not need to be a
+git repository, which is useful when iterating on unmerged proto changes.
+No source commit is recorded in the --summary-file for such runs. The flag
+also accepts a path or URL ending in .tar.gz or .tgz, such as a pinned
+googleapis archive from GitHub, which is extracted to a temporary directory
+and used the same way. As with GitHub archives, the files in the tarball must
+be wrapped in a single top-level directory.
+
+The --update-source flag fetches the upstream branch of a local googleapis
+checkout, set with sources.googleapis.dir or --api-source, and fast-forwards
+it before generating, so that a stale checkout does not silently produce
+output from old protos. The checkout must be a git repository with a clean
+working tree, on a branch that tracks a remote branch. Generate fails
+without updating it if the branch has diverged from its upstream branch.
+
+The --fail-on-no-changes flag makes generate fail if the git working tree is
+clean after generation, which lets scheduled CI jobs detect that a
+regeneration changed nothing.
+
+The --checkpoint-file flag records the libraries that were generated
+successfully, as the run progresses. Rerunning with --resume skips those
+libraries, so an interrupted run over many libraries does not start from
+scratch. Completed libraries are kept if the run fails. The checkpoint is
+ignored if it was written for another googleapis commit, or when the
+commit is unknown, such as with --api-source.
+
+The --provenance-dir flag writes a <library>.json file for each generated
+library to the given directory, recording the googleapis commit, the
+librarian version and the time of the run. Pointing it at a directory in
+the repository, such as .librarian/provenance, keeps the provenance of each
+library next to its code. Libraries that are rolled back after a failure
+keep their previous provenance file.
+
+The --summary-file flag writes a JSON summary of the run, with the time spent
+on each library and its status: generated, unchanged when its changes were
+reverted as no-op, checkpointed when it was kept by --checkpoint-file after a
+failure, failed, with its own error and the exit status of the failed
+command, rolled-back when another library failed, incomplete when another
+library failed with --no-rollback, or not-attempted when the run failed
+before reaching it.
+
+The --metrics-pushgateway flag pushes metrics about the run to a Prometheus
+Pushgateway, as the librarian_generate job: the number of libraries
+generated, unchanged, failed and skipped, the time spent on each library,
+the duration of the run, and whether it succeeded.
+Pushing is best effort: a failure is logged and does not fail generate.
+
+The --no-clean flag skips cleaning the output directories of the libraries
+before generating them. Generated files are added or overwritten, and
+every other file is left in place, including files that cleaning would
+delete because they are not listed in keep. Stale generated files are also
+left behind, so this is only intended for migrations, and a warning is
+logged when it is used.
+
+The --patch-file flag writes the changes made by a successful run to the
+given file, as a unified diff of the git working tree against HEAD that
+includes new and deleted files but not files ignored by .gitignore. The
+patch can be kept as a CI artifact for review, and applied elsewhere with
+git apply.
+
 Examples:
 
-	librarian generate <library>   # regenerate one library
-	librarian generate --all       # regenerate every library
+	librarian generate <library>         # regenerate one library
+	librarian generate --all             # regenerate every library
+	librarian generate --all --dry-run   # list libraries without generating
+	librarian generate --library-filter='^google-cloud-bigtable-'
+	librarian generate --api-source=../googleapis <library>
+	librarian generate --all --checkpoint-file=generate.json --resume
 
 [after-flags]
 A typical librarian workflow for regenerating every library against the
@@ -76,47 +200,314 @@ latest API definitions is:
 				Name:  "all",
 				Usage: "generate all libraries",
 			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print the libraries that would be generated without generating them",
+			},
+			&cli.StringFlag{
+				Name:  "library-filter",
+				Usage: "generate all libraries whose name matches this regular expression",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-library",
+				Usage: "skip the library `name` when generating with --all or --library-filter; can be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "api-source",
+				Usage: "generate from this local googleapis `DIR`, which need not be a git repository, or from a .tar.gz file or URL",
+			},
+			&cli.BoolFlag{
+				Name:  "update-source",
+				Usage: "fast-forward the local googleapis checkout to its upstream branch before generating",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "only generate libraries whose APIs changed since this googleapis commit",
+			},
+			&cli.StringFlag{
+				Name:  "summary-file",
+				Usage: "write a JSON summary of the run to this path",
+			},
+			&cli.IntFlag{
+				Name:  "max-concurrency",
+				Usage: "maximum number of libraries to generate concurrently; 0 uses the number of CPUs",
+			},
+			&cli.BoolFlag{
+				Name:  "fail-on-no-changes",
+				Usage: "fail if the git working tree is clean after generation",
+			},
+			&cli.StringFlag{
+				Name:  "checkpoint-file",
+				Usage: "record the libraries completed by the run in this `FILE`",
+			},
+			&cli.BoolFlag{
+				Name:  "resume",
+				Usage: "skip libraries recorded as completed in --checkpoint-file",
+			},
+			&cli.StringFlag{
+				Name:  "provenance-dir",
+				Usage: "write a provenance file for each generated library to this `DIR`",
+			},
+			&cli.StringFlag{
+				Name:  "metrics-pushgateway",
+				Usage: "push metrics to the Prometheus Pushgateway at this `URL`",
+			},
+			&cli.BoolFlag{
+				Name:  "no-clean",
+				Usage: "do not delete existing files in library outputs before generating",
+			},
+			&cli.BoolFlag{
+				Name:  "no-rollback",
+				Usage: "do not copy library outputs to restore them if generation fails",
+			},
+			&cli.StringFlag{
+				Name:  "patch-file",
+				Usage: "write a unified diff of the changes made by generation to this `FILE`",
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			all := cmd.Bool("all")
 			libraryName := cmd.Args().First()
-			if !all && libraryName == "" {
+			libraryFilter := cmd.String("library-filter")
+			if !all && libraryName == "" && libraryFilter == "" {
 				return errMissingLibraryOrAllFlag
 			}
 			if all && libraryName != "" {
 				return errBothLibraryAndAllFlag
 			}
-			cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			if libraryName != "" && libraryFilter != "" {
+				return errBothLibraryAndFilter
+			}
+			if all && libraryFilter != "" {
+				return errBothAllAndFilter
+			}
+			exclude := cmd.StringSlice("exclude-library")
+			if len(exclude) > 0 && !all && libraryFilter == "" {
+				return errExcludeRequiresAll
+			}
+			if cmd.Bool("resume") && cmd.String("checkpoint-file") == "" {
+				return errResumeRequiresCheckpoint
+			}
+			var filter *regexp.Regexp
+			if libraryFilter != "" {
+				re, err := regexp.Compile(libraryFilter)
+				if err != nil {
+					return fmt.Errorf("invalid --library-filter %q: %w", libraryFilter, err)
+				}
+				filter = re
+				all = true
+			}
+			concurrency := cmd.Int("max-concurrency")
+			if concurrency < 0 {
+				return errInvalidMaxConcurrency
+			}
+			if concurrency == 0 {
+				concurrency = runtime.NumCPU()
+			}
+			cfg, err := readConfig(cmd.String("config-file"))
+			if err != nil {
+				return err
+			}
+			if apiSource := cmd.String("api-source"); apiSource != "" {
+				if isTarball(apiSource) {
+					dir, err := os.MkdirTemp("", "librarian-api-source-")
+					if err != nil {
+						return err
+					}
+					defer os.RemoveAll(dir)
+					if err := fetch.Tarball(ctx, apiSource, dir); err != nil {
+						return err
+					}
+					apiSource = dir
+				}
+				if err := useAPISource(cfg, apiSource); err != nil {
+					return err
+				}
+			}
+			dryRun := cmd.Bool("dry-run")
+			if cmd.Bool("update-source") {
+				if dryRun {
+					slog.Info("not updating the googleapis checkout in a dry run")
+				} else if err := updateAPISource(ctx, cfg.Sources); err != nil {
+					return err
+				}
+			}
+			libraries, err := selectLibraries(cfg, all, libraryName, filter, exclude)
+			if err != nil {
+				return err
+			}
+			if since := cmd.String("since"); since != "" {
+				if cfg.Sources == nil || cfg.Sources.Googleapis == nil || cfg.Sources.Googleapis.Dir == "" {
+					return errSinceRequiresSourceDir
+				}
+				if !git.IsWorkTree(ctx, command.Git, cfg.Sources.Googleapis.Dir) {
+					return fmt.Errorf("%w: %q", errSinceRequiresSourceDir, cfg.Sources.Googleapis.Dir)
+				}
+				libraries, err = filterChangedSince(ctx, cfg.Sources.Googleapis.Dir, since, libraries)
+				if err != nil {
+					return err
+				}
+				if len(libraries) == 0 {
+					slog.Info("no libraries have API changes", "since", since)
+					if cmd.Bool("fail-on-no-changes") {
+						return checkGeneratedChanges(ctx)
+					}
+					return nil
+				}
+			}
+			noClean := cmd.Bool("no-clean")
+			if dryRun {
+				return printGeneratePlan(cmd.Root().Writer, cfg.Language, libraries, noClean)
+			}
+			var checkpoint *generateCheckpoint
+			if path := cmd.String("checkpoint-file"); path != "" {
+				checkpoint = newGenerateCheckpoint(path, sourceCommit(cfg.Sources))
+				if cmd.Bool("resume") {
+					checkpoint, err = readGenerateCheckpoint(path, sourceCommit(cfg.Sources))
+					if err != nil {
+						return err
+					}
+					libraries = checkpoint.skipCompleted(libraries)
+					if len(libraries) == 0 {
+						slog.Info("all libraries were completed by a previous run", "checkpoint", path)
+						return nil
+					}
+				}
+			}
+			var provenance *generateProvenance
+			if dir := cmd.String("provenance-dir"); dir != "" {
+				provenance = newGenerateProvenance(dir, sourceCommit(cfg.Sources))
+			}
+			if noClean {
+				slog.Warn("--no-clean is set: library outputs are not cleaned, so stale generated files are kept")
+			}
+			start := time.Now()
+			outcome, err := runGenerate(ctx, cfg, libraries, concurrency, noClean, cmd.Bool("no-rollback"), checkpoint, provenance)
+			pushMetrics(ctx, cmd.String("metrics-pushgateway"), "librarian_generate", generateMetrics(libraries, outcome, time.Since(start), err))
+			if summaryFile := cmd.String("summary-file"); summaryFile != "" {
+				if summaryErr := writeGenerateSummary(summaryFile, libraries, outcome, sourceCommit(cfg.Sources), time.Since(start), err); summaryErr != nil {
+					return errors.Join(err, summaryErr)
+				}
+			}
 			if err != nil {
 				return err
 			}
-			return runGenerate(ctx, cfg, all, libraryName)
+			if patchFile := cmd.String("patch-file"); patchFile != "" {
+				if err := writeGeneratePatch(ctx, patchFile); err != nil {
+					return err
+				}
+			}
+			if cmd.Bool("fail-on-no-changes") {
+				return checkGeneratedChanges(ctx)
+			}
+			return nil
 		},
 	}
 }
 
-func runGenerate(ctx context.Context, cfg *config.Config, all bool, libraryName string) error {
-	sources, err := LoadSources(ctx, cfg.Sources)
+// writeGeneratePatch writes the uncommitted changes of the git working tree
+// of the current directory to path, as a unified diff.
+func writeGeneratePatch(ctx context.Context, path string) error {
+	patch, err := git.Diff(ctx, command.Git)
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(path, []byte(patch), 0o644)
+}
+
+// checkGeneratedChanges returns errNoChanges if the git working tree of the
+// current directory is clean.
+func checkGeneratedChanges(ctx context.Context) error {
+	err := git.AssertGitStatusClean(ctx, command.Git)
+	if err == nil {
+		return errNoChanges
+	}
+	if errors.Is(err, git.ErrGitStatusUnclean) {
+		return nil
+	}
+	return err
+}
 
+// useAPISource replaces the googleapis source in cfg with the local directory
+// dir, which is used as is without any git operations.
+func useAPISource(cfg *config.Config, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: %q", errAPISourceNotDir, dir)
+	}
+	if cfg.Sources == nil {
+		cfg.Sources = &config.Sources{}
+	}
+	cfg.Sources.Googleapis = &config.Source{Dir: dir}
+	return nil
+}
+
+// isTarball reports whether the --api-source value names a gzipped tarball
+// rather than a directory.
+func isTarball(source string) bool {
+	return strings.HasSuffix(source, ".tar.gz") || strings.HasSuffix(source, ".tgz")
+}
+
+// updateAPISource fast-forwards the local googleapis checkout in src to its
+// upstream branch.
+func updateAPISource(ctx context.Context, src *config.Sources) error {
+	if src == nil || src.Googleapis == nil || src.Googleapis.Dir == "" {
+		return errUpdateRequiresSourceDir
+	}
+	dir := src.Googleapis.Dir
+	if !git.IsWorkTree(ctx, command.Git, dir) {
+		return fmt.Errorf("%w: %q", errUpdateRequiresSourceDir, dir)
+	}
+	slog.Info("updating googleapis source", "dir", dir)
+	return git.FastForward(ctx, command.Git, dir)
+}
+
+// sourceCommit returns the googleapis commit used for generation, or the
+// empty string if generating from a local directory, where commit metadata is
+// unavailable.
+func sourceCommit(src *config.Sources) string {
+	if src == nil || src.Googleapis == nil || src.Googleapis.Dir != "" {
+		return ""
+	}
+	return src.Googleapis.Commit
+}
+
+// selectLibraries returns the libraries to generate, skipping those marked
+// with skip_generate and applying defaults. If filter is not nil, only
+// libraries whose name matches it are returned. Libraries named in exclude
+// are skipped, along with their preview variants; each name must be a
+// library in cfg.
+func selectLibraries(cfg *config.Config, all bool, libraryName string, filter *regexp.Regexp, exclude []string) ([]*config.Library, error) {
 	isPreview := isPreviewName(libraryName)
 	baseName := trimPreviewName(libraryName)
+	for _, name := range exclude {
+		if findLibraryByName(cfg, name) == nil {
+			return nil, fmt.Errorf("%w: --exclude-library %q", ErrLibraryNotFound, name)
+		}
+	}
 
-	// Prepare the libraries to generate by skipping as specified and applying
-	// defaults.
-	var libraries []*config.Library
+	var (
+		libraries []*config.Library
+		excluded  bool
+	)
 	for _, lib := range cfg.Libraries {
 		if !all && isPreview && lib.Name == baseName && lib.Preview == nil {
-			return fmt.Errorf("%w: %q", errNoPreviewVariant, baseName)
+			return nil, fmt.Errorf("%w: %q", errNoPreviewVariant, baseName)
+		}
+		if filter != nil && !filter.MatchString(lib.Name) {
+			continue
+		}
+		if slices.Contains(exclude, lib.Name) {
+			slog.Info("skipping excluded library", "library", lib.Name)
+			excluded = true
+			continue
 		}
 		if !shouldGenerate(lib, all, libraryName) {
 			continue
 		}
 		prepared, err := applyDefaults(cfg.Language, lib, cfg.Default)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if !all && isPreview {
 			prepared = ResolvePreview(prepared, cfg.Language)
@@ -128,25 +519,287 @@ func runGenerate(ctx context.Context, cfg *config.Config, all bool, libraryName
 		libraries = append(libraries, prepared)
 	}
 	if len(libraries) == 0 {
+		if excluded {
+			return nil, errAllLibrariesExcluded
+		}
+		if filter != nil {
+			return nil, fmt.Errorf("%w: %q", errNoLibraryMatchesFilter, filter)
+		}
 		if all {
-			return errors.New("no libraries to generate: all libraries have skip_generate set")
+			return nil, errors.New("no libraries to generate: all libraries have skip_generate set")
 		}
 		for _, lib := range cfg.Libraries {
 			if lib.Name == baseName {
-				return fmt.Errorf("%w: %q", errSkipGenerate, libraryName)
+				return nil, fmt.Errorf("%w: %q", errSkipGenerate, libraryName)
 			}
 		}
-		return fmt.Errorf("%w: %q", ErrLibraryNotFound, libraryName)
+		return nil, fmt.Errorf("%w: %q", ErrLibraryNotFound, libraryName)
 	}
+	return libraries, nil
+}
 
-	if err := cleanLibraries(cfg.Language, libraries); err != nil {
-		return err
+// filterChangedSince returns the libraries with changes to any of their API
+// paths since the given commit, in the googleapis repository at dir.
+func filterChangedSince(ctx context.Context, dir, since string, libraries []*config.Library) ([]*config.Library, error) {
+	// A commit outside the history of HEAD would compare against unrelated
+	// changes, and select libraries that have not changed.
+	inHistory, err := git.IsAncestor(ctx, command.Git, dir, since, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if !inHistory {
+		return nil, fmt.Errorf("%w: %s in %s", errSinceNotInHistory, since, dir)
+	}
+	var changed []*config.Library
+	for _, library := range libraries {
+		var paths []string
+		for _, api := range library.APIs {
+			paths = append(paths, api.Path)
+		}
+		if len(paths) == 0 {
+			slog.Info("skipping library without APIs", "library", library.Name)
+			continue
+		}
+		files, err := git.FilesChangedSinceInDir(ctx, command.Git, dir, since, paths)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			slog.Info("skipping library without API changes", "library", library.Name, "since", since)
+			continue
+		}
+		changed = append(changed, library)
+	}
+	return changed, nil
+}
+
+// printGeneratePlan writes the output directory and APIs of each library that
+// would be cleaned and generated, and the files that cleaning would remove
+// unless noClean is set, without modifying any files.
+func printGeneratePlan(w io.Writer, language string, libraries []*config.Library, noClean bool) error {
+	var b strings.Builder
+	for _, library := range libraries {
+		fmt.Fprintf(&b, "%s: %s\n", library.Name, library.Output)
+		for _, api := range library.APIs {
+			fmt.Fprintf(&b, "  %s\n", api.Path)
+		}
+		if noClean {
+			continue
+		}
+		removed, err := cleanPlan(language, library)
+		if err != nil {
+			return err
+		}
+		for _, path := range removed {
+			fmt.Fprintf(&b, "  would remove %s\n", path)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// runGenerate cleans and generates libraries, skipping the clean if noClean
+// is set. If checkpoint is not nil, each wave of libraries is recorded in it
+// once generated, and is not rolled back if a later wave fails. If provenance
+// is not nil, it is written for the libraries that are kept. The returned
+// outcome records the libraries kept by checkpoint and those reverted as
+// no-op, even if an error is returned. If noRollback is set, the outputs are
+// not snapshotted, so they are neither rolled back on failure nor reverted as
+// no-op.
+func runGenerate(ctx context.Context, cfg *config.Config, libraries []*config.Library, concurrency int, noClean, noRollback bool, checkpoint *generateCheckpoint, provenance *generateProvenance) (*generateOutcome, error) {
+	outcome := &generateOutcome{}
+	waves, err := generationWaves(libraries)
+	if err != nil {
+		return outcome, err
+	}
+	sources, err := LoadSources(ctx, cfg.Sources)
+	if err != nil {
+		return outcome, err
+	}
+	var snapshot *outputSnapshot
+	if !noRollback {
+		snapshot, err = snapshotOutputs(libraries)
+		if err != nil {
+			return outcome, err
+		}
+		defer func() {
+			if err := snapshot.remove(); err != nil {
+				slog.Warn("failed to remove snapshot of library outputs", "dir", snapshot.dir, "error", err)
+			}
+		}()
+	}
+	err = cleanAndGenerate(ctx, cfg, libraries, waves, sources, concurrency, noClean, checkpoint, outcome)
+	if err != nil {
+		if restoreErr := snapshot.restore(outcome.completed); restoreErr != nil {
+			return outcome, errors.Join(err, fmt.Errorf("rolling back library outputs: %w", restoreErr))
+		}
+		outcome.rolledBack = snapshot != nil
+		return outcome, errors.Join(err, provenance.write(outcome.completed))
+	}
+	outcome.reverted, err = revertNoopChanges(libraries, snapshot)
+	if err != nil {
+		return outcome, err
+	}
+	return outcome, provenance.write(slices.DeleteFunc(slices.Clone(libraries), func(lib *config.Library) bool {
+		return slices.Contains(outcome.reverted, lib)
+	}))
+}
+
+// cleanAndGenerate cleans libraries, unless noClean is set, and then
+// generates each wave of waves in turn. It records in outcome the libraries
+// of each wave that is started, the time spent on each library, and the
+// libraries recorded in checkpoint, if any, which are complete even if a later
+// wave fails.
+func cleanAndGenerate(ctx context.Context, cfg *config.Config, libraries []*config.Library, waves [][]*config.Library, src *sources.Sources, concurrency int, noClean bool, checkpoint *generateCheckpoint, outcome *generateOutcome) error {
+	if !noClean {
+		if err := cleanLibraries(cfg.Language, libraries); err != nil {
+			return err
+		}
+	}
+	for _, wave := range waves {
+		outcome.attempted = append(outcome.attempted, wave...)
+		if err := generateLibraries(ctx, cfg, wave, src, concurrency, &outcome.runs); err != nil {
+			return err
+		}
+		if err := runPostGenerateHooks(ctx, cfg, wave); err != nil {
+			return err
+		}
+		if err := checkOutputAllowlists(wave); err != nil {
+			return err
+		}
+		if checkpoint != nil {
+			if err := checkpoint.record(wave); err != nil {
+				return err
+			}
+			outcome.completed = append(outcome.completed, wave...)
+		}
+	}
+	return nil
+}
+
+// libraryError is an error in generating a single library, which the
+// summary of the run attributes to that library.
+type libraryError struct {
+	library *config.Library
+	err     error
+}
+
+func (e *libraryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *libraryError) Unwrap() error {
+	return e.err
+}
+
+// libraryRuns records the time spent on the steps of generating each
+// library. It is safe for concurrent use.
+type libraryRuns struct {
+	mu        sync.Mutex
+	durations map[*config.Library]time.Duration
+}
+
+// run runs f, a step of generating library, adding the time it takes to the
+// duration of library and wrapping its error, if any, in a [libraryError].
+// A nil *libraryRuns only wraps the error.
+func (r *libraryRuns) run(library *config.Library, f func() error) error {
+	start := time.Now()
+	err := f()
+	if r != nil {
+		r.mu.Lock()
+		if r.durations == nil {
+			r.durations = map[*config.Library]time.Duration{}
+		}
+		r.durations[library] += time.Since(start)
+		r.mu.Unlock()
+	}
+	if err != nil {
+		return &libraryError{library: library, err: err}
+	}
+	return nil
+}
+
+// duration returns the time spent on the steps of generating library.
+func (r *libraryRuns) duration(library *config.Library) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.durations[library]
+}
+
+// generationWaves splits libraries into waves, such that each library is in
+// a later wave than the libraries it depends on, and the libraries of a wave
+// can be generated concurrently. Dependencies on libraries that are not in
+// libraries are ignored. The libraries of each wave keep their order in
+// libraries, so that without dependencies there is a single wave.
+//
+// If the dependencies form a cycle, an error listing the cycle is returned.
+func generationWaves(libraries []*config.Library) ([][]*config.Library, error) {
+	byName := map[string]*config.Library{}
+	for _, lib := range libraries {
+		byName[lib.Name] = lib
+	}
+	done := map[string]bool{}
+	remaining := libraries
+	var waves [][]*config.Library
+	for len(remaining) > 0 {
+		var wave, next []*config.Library
+		for _, lib := range remaining {
+			ready := true
+			for _, dep := range lib.DependsOn {
+				if byName[dep] != nil && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, lib)
+			} else {
+				next = append(next, lib)
+			}
+		}
+		if len(wave) == 0 {
+			return nil, fmt.Errorf("%w: %s", errDependencyCycle, strings.Join(findCycle(next, byName), " -> "))
+		}
+		for _, lib := range wave {
+			done[lib.Name] = true
+		}
+		waves = append(waves, wave)
+		remaining = next
+	}
+	return waves, nil
+}
+
+// findCycle returns the names of the libraries in a dependency cycle among
+// libraries, starting and ending with the same name. Every library in
+// libraries must depend on another one in libraries, so a cycle always exists.
+func findCycle(libraries []*config.Library, byName map[string]*config.Library) []string {
+	pending := map[string]bool{}
+	for _, lib := range libraries {
+		pending[lib.Name] = true
+	}
+	// Follow pending dependencies from the first library until a library is
+	// visited twice.
+	var path []string
+	visited := map[string]int{}
+	lib := libraries[0]
+	for {
+		if i, ok := visited[lib.Name]; ok {
+			return append(path[i:], lib.Name)
+		}
+		visited[lib.Name] = len(path)
+		path = append(path, lib.Name)
+		for _, dep := range lib.DependsOn {
+			if pending[dep] {
+				lib = byName[dep]
+				break
+			}
+		}
 	}
-	return generateLibraries(ctx, cfg, libraries, sources)
 }
 
 // cleanLibraries iterates over all the given libraries sequentially,
-// delegating to language-specific code to clean each library.
+// delegating to language-specific code to clean each library. Files matched by
+// the .librarianignore file of a library are preserved for every language.
 func cleanLibraries(language string, libraries []*config.Library) error {
 	var err error
 	for _, library := range libraries {
@@ -156,17 +809,17 @@ func cleanLibraries(language string, libraries []*config.Library) error {
 		case config.LanguageFake:
 			err = fakeClean(library)
 		case config.LanguageGo:
-			err = golang.Clean(library)
+			err = preserveIgnored(library.Output, func() error { return golang.Clean(library) })
 		case config.LanguageJava:
-			err = java.Clean(library)
+			err = preserveIgnored(library.Output, func() error { return java.Clean(library) })
 		case config.LanguageNodejs:
-			err = nodejs.Clean(library)
+			err = preserveIgnored(library.Output, func() error { return nodejs.Clean(library) })
 		case config.LanguagePhp:
-			err = php.Clean(library)
+			err = preserveIgnored(library.Output, func() error { return php.Clean(library) })
 		case config.LanguagePython:
-			err = python.Clean(library)
+			err = preserveIgnored(library.Output, func() error { return python.Clean(library) })
 		case config.LanguageRuby:
-			err = ruby.Clean(library)
+			err = preserveIgnored(library.Output, func() error { return ruby.Clean(library) })
 		case config.LanguageRust:
 			keep, keepErr := rust.Keep(library)
 			if keepErr != nil {
@@ -187,122 +840,154 @@ func cleanLibraries(language string, libraries []*config.Library) error {
 
 // generateLibraries generates and formats all the given libraries,
 // delegating to language-specific code. Each language chooses its own
-// concurrency strategy for these two steps.
-func generateLibraries(ctx context.Context, cfg *config.Config, libraries []*config.Library, src *sources.Sources) error {
+// concurrency strategy for these two steps, running at most concurrency
+// libraries at a time where steps are parallelized. The steps of each
+// library are run through runs, which times them and attributes their errors
+// to the library.
+func generateLibraries(ctx context.Context, cfg *config.Config, libraries []*config.Library, src *sources.Sources, concurrency int, runs *libraryRuns) error {
 	switch cfg.Language {
 	case config.LanguageDart:
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := dart.Generate(gctx, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				if err := dart.Format(gctx, library); err != nil {
-					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := dart.Generate(gctx, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					if err := dart.Format(gctx, library); err != nil {
+						return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
 	case config.LanguageFake:
 		for _, library := range libraries {
-			if err := fakeGenerate(library); err != nil {
-				return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-			}
-			if err := fakeFormat(library); err != nil {
-				return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+			if err := runs.run(library, func() error {
+				if err := fakeGenerate(library); err != nil {
+					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+				}
+				if err := fakeFormat(library); err != nil {
+					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+				}
+				return nil
+			}); err != nil {
+				return err
 			}
 		}
 		return fakePostGenerate()
 	case config.LanguageGo:
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := golang.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := golang.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		if err := g.Wait(); err != nil {
 			return err
 		}
 		g, gctx = errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := golang.Format(gctx, library); err != nil {
-					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := golang.Format(gctx, library); err != nil {
+						return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
 	case config.LanguageJava:
 		for _, library := range libraries {
-			if err := java.Generate(ctx, cfg, library, src); err != nil {
-				return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-			}
-			if err := java.Format(ctx, library); err != nil {
-				return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+			if err := runs.run(library, func() error {
+				if err := java.Generate(ctx, cfg, library, src); err != nil {
+					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+				}
+				if err := java.Format(ctx, library); err != nil {
+					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+				}
+				return nil
+			}); err != nil {
+				return err
 			}
 		}
 		return java.PostGenerate(ctx, ".", cfg)
 	case config.LanguageNodejs:
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := nodejs.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := nodejs.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
 	case config.LanguagePhp:
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := php.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				if err := php.Format(gctx, library); err != nil {
-					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := php.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					if err := php.Format(gctx, library); err != nil {
+						return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
 	case config.LanguagePython:
+		if slices.ContainsFunc(libraries, func(library *config.Library) bool { return len(library.APIs) > 0 }) {
+			if err := python.CheckPostProcessorTools(ctx); err != nil {
+				return err
+			}
+		}
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				// TODO(https://github.com/googleapis/librarian/issues/3730):
-				// separate generation and formatting for Python.
-				if err := python.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					// TODO(https://github.com/googleapis/librarian/issues/3730):
+					// separate generation and formatting for Python.
+					if err := python.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
 	case config.LanguageRuby:
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := ruby.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				if err := ruby.Format(gctx, library); err != nil {
-					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := ruby.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					if err := ruby.Format(gctx, library); err != nil {
+						return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
@@ -310,36 +995,45 @@ func generateLibraries(ctx context.Context, cfg *config.Config, libraries []*con
 		// Generation can be parallelized but formatting cannot because
 		// cargo fmt shares the Cargo.toml workspace file across libraries.
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := rust.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := rust.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		if err := g.Wait(); err != nil {
 			return err
 		}
 		for _, library := range libraries {
-			if err := rust.Format(ctx, library); err != nil {
-				return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+			if err := runs.run(library, func() error {
+				if err := rust.Format(ctx, library); err != nil {
+					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+				}
+				return nil
+			}); err != nil {
+				return err
 			}
 		}
 		return rust.UpdateWorkspace(ctx)
 	case config.LanguageSwift:
 		g, gctx := errgroup.WithContext(ctx)
-		g.SetLimit(runtime.NumCPU())
+		g.SetLimit(concurrency)
 		for _, library := range libraries {
 			g.Go(func() error {
-				if err := swift.Generate(gctx, cfg, library, src); err != nil {
-					return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				if err := swift.Format(gctx, library); err != nil {
-					return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
-				}
-				return nil
+				return runs.run(library, func() error {
+					if err := swift.Generate(gctx, cfg, library, src); err != nil {
+						return fmt.Errorf("generate library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					if err := swift.Format(gctx, library); err != nil {
+						return fmt.Errorf("format library %q (%s): %w", library.Name, cfg.Language, err)
+					}
+					return nil
+				})
 			})
 		}
 		return g.Wait()
@@ -15,16 +15,21 @@
 package librarian
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/command"
 	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
 	"github.com/googleapis/librarian/internal/sample"
+	"github.com/googleapis/librarian/internal/testhelper"
 	"github.com/googleapis/librarian/internal/yaml"
 )
 
@@ -103,6 +108,37 @@ func TestGenerateCommand(t *testing.T) {
 			args:    []string{"librarian", "generate", lib3},
 			wantErr: errSkipGenerate,
 		},
+		{
+			name:             "max concurrency",
+			args:             []string{"librarian", "generate", "--all", "--max-concurrency=1"},
+			want:             []string{lib1, lib2, lib1PreviewName},
+			wantPostGenerate: true,
+		},
+		{
+			name: "library filter",
+			args: []string{"librarian", "generate", "--library-filter=^library-t"},
+			want: []string{lib2},
+		},
+		{
+			name:    "library filter matches nothing",
+			args:    []string{"librarian", "generate", "--library-filter=^nothing$"},
+			wantErr: errNoLibraryMatchesFilter,
+		},
+		{
+			name:    "both library and library filter",
+			args:    []string{"librarian", "generate", "--library-filter=^library", lib1},
+			wantErr: errBothLibraryAndFilter,
+		},
+		{
+			name:    "both all and library filter",
+			args:    []string{"librarian", "generate", "--all", "--library-filter=^library"},
+			wantErr: errBothAllAndFilter,
+		},
+		{
+			name:    "negative max concurrency",
+			args:    []string{"librarian", "generate", "--all", "--max-concurrency=-1"},
+			wantErr: errInvalidMaxConcurrency,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			tempDir := t.TempDir()
@@ -307,6 +343,725 @@ libraries:
 	}
 }
 
+func TestGenerateExcludeLibrary(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1":       "speech_v1.yaml",
+		"google/cloud/texttospeech/v1": "texttospeech_v1.yaml",
+		"google/cloud/translate/v3":    "translate_v3.yaml",
+	})
+	for _, test := range []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "all",
+			args: []string{"--all", "--exclude-library=library-one"},
+			want: []string{"library-three", "library-two"},
+		},
+		{
+			name: "repeated",
+			args: []string{"--all", "--exclude-library=library-one", "--exclude-library=library-three"},
+			want: []string{"library-two"},
+		},
+		{
+			name: "library filter",
+			args: []string{"--library-filter=^library-t", "--exclude-library=library-two"},
+			want: []string{"library-three"},
+		},
+		{
+			name:    "all excluded",
+			args:    []string{"--library-filter=^library-t", "--exclude-library=library-two", "--exclude-library=library-three"},
+			wantErr: errAllLibrariesExcluded,
+		},
+		{
+			name:    "unknown library",
+			args:    []string{"--all", "--exclude-library=library-four"},
+			wantErr: ErrLibraryNotFound,
+		},
+		{
+			name:    "library name",
+			args:    []string{"--exclude-library=library-one", "library-two"},
+			wantErr: errExcludeRequiresAll,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			configContent := fmt.Sprintf(`language: fake
+sources:
+  googleapis:
+    dir: %s
+libraries:
+  - name: library-one
+    output: library-one
+    apis:
+      - path: google/cloud/speech/v1
+  - name: library-two
+    output: library-two
+    apis:
+      - path: google/cloud/texttospeech/v1
+  - name: library-three
+    output: library-three
+    apis:
+      - path: google/cloud/translate/v3
+`, googleapisDir)
+			if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			args := append([]string{"librarian", "generate"}, test.args...)
+			err := Run(t.Context(), args...)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("want error %v, got %v", test.wantErr, err)
+			}
+			var got []string
+			for _, name := range []string{"library-one", "library-three", "library-two"} {
+				if _, err := os.Stat(filepath.Join(name, "README.md")); err == nil {
+					got = append(got, name)
+				}
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateDryRun(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	configContent := `language: fake
+sources:
+  googleapis:
+    dir: does-not-exist
+libraries:
+  - name: library-one
+    output: output1
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(filepath.Join(tempDir, config.LibrarianYAML), []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "generate", "--dry-run", "library-one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "output1")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected output directory to not be created, got %v", err)
+	}
+}
+
+func TestPrintGeneratePlan(t *testing.T) {
+	t.Chdir(t.TempDir())
+	output := "output1"
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"README.md", "KEEP.md"} {
+		if err := os.WriteFile(filepath.Join(output, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	libraries := []*config.Library{
+		{
+			Name:   "library-one",
+			Output: output,
+			APIs:   []*config.API{{Path: "google/cloud/speech/v1"}},
+		},
+		{
+			Name:   "library-two",
+			Output: "does-not-exist",
+		},
+	}
+	for _, test := range []struct {
+		name    string
+		noClean bool
+		want    string
+	}{
+		{
+			name: "clean",
+			want: `library-one: output1
+  google/cloud/speech/v1
+  would remove output1/README.md
+library-two: does-not-exist
+`,
+		},
+		{
+			name:    "no clean",
+			noClean: true,
+			want: `library-one: output1
+  google/cloud/speech/v1
+library-two: does-not-exist
+`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printGeneratePlan(&out, config.LanguageFake, libraries, test.noClean); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, out.String()); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+			if _, err := os.Stat(filepath.Join(output, "README.md")); err != nil {
+				t.Errorf("expected README.md to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateSince(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1":       "speech_v1.yaml",
+		"google/cloud/texttospeech/v1": "texttospeech_v1.yaml",
+	})
+	testhelper.ContinueInNewGitRepository(t, googleapisDir)
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial version")
+	testhelper.RunGit(t, "tag", "before")
+	if err := os.WriteFile(filepath.Join("google/cloud/speech/v1", "speech.proto"), []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "feat: change speech")
+
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	configContent := fmt.Sprintf(`language: fake
+sources:
+  googleapis:
+    dir: %s
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+  - name: texttospeech
+    output: texttospeech
+    apis:
+      - path: google/cloud/texttospeech/v1
+`, googleapisDir)
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "generate", "--all", "--since=before"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); err != nil {
+		t.Errorf("expected speech to be generated, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("texttospeech", "README.md")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected texttospeech to not be generated, got %v", err)
+	}
+}
+
+func TestGenerateSince_Error(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	configContent := `language: fake
+sources:
+  googleapis:
+    commit: abc123
+libraries:
+  - name: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(t.Context(), "librarian", "generate", "--all", "--since=before")
+	if !errors.Is(err, errSinceRequiresSourceDir) {
+		t.Errorf("want error %v, got %v", errSinceRequiresSourceDir, err)
+	}
+}
+
+func TestGenerateSince_NotInHistory(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	testhelper.ContinueInNewGitRepository(t, googleapisDir)
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial version")
+	testhelper.RunGit(t, "checkout", "-b", "other")
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "unrelated change")
+	testhelper.RunGit(t, "tag", "elsewhere")
+	testhelper.RunGit(t, "checkout", "-")
+
+	t.Chdir(t.TempDir())
+	configContent := fmt.Sprintf(`language: fake
+sources:
+  googleapis:
+    dir: %s
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`, googleapisDir)
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		since   string
+		wantErr error
+	}{
+		{name: "other branch", since: "elsewhere", wantErr: errSinceNotInHistory},
+		{name: "unknown commit", since: "does-not-exist", wantErr: git.ErrUnknownRevision},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := Run(t.Context(), "librarian", "generate", "--all", "--since="+test.since)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateAPISource(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	configContent := `language: fake
+sources:
+  googleapis:
+    commit: abc123
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "generate", "--api-source", googleapisDir, "--summary-file=summary.json", "--provenance-dir=provenance", "speech"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); err != nil {
+		t.Errorf("expected speech to be generated, got %v", err)
+	}
+	summary, err := readJSONFile[*generateSummary]("summary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.GoogleapisCommit != "" {
+		t.Errorf("GoogleapisCommit = %q, want empty", summary.GoogleapisCommit)
+	}
+	provenance, err := readJSONFile[*libraryProvenance](filepath.Join("provenance", "speech.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provenance.GoogleapisCommit != "" {
+		t.Errorf("provenance GoogleapisCommit = %q, want empty", provenance.GoogleapisCommit)
+	}
+	got, err := os.ReadFile(config.LibrarianYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(configContent, string(got)); diff != "" {
+		t.Errorf("librarian.yaml changed (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateAPISource_Tarball(t *testing.T) {
+	testhelper.RequireCommand(t, "tar")
+	tempDir := t.TempDir()
+	createGoogleapisServiceConfigs(t, tempDir, map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	tarball := filepath.Join(t.TempDir(), "googleapis.tar.gz")
+	if err := command.Run(t.Context(), "tar", "-czf", tarball, "-C", tempDir, "googleapis"); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "generate", "--api-source", tarball, "--summary-file=summary.json", "speech"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); err != nil {
+		t.Errorf("expected speech to be generated, got %v", err)
+	}
+	summary, err := readJSONFile[*generateSummary]("summary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.GoogleapisCommit != "" {
+		t.Errorf("GoogleapisCommit = %q, want empty", summary.GoogleapisCommit)
+	}
+}
+
+func TestGeneratePostGenerateHook_Error(t *testing.T) {
+	testhelper.RequireCommand(t, "sh")
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    post_generate_hooks:
+      - exit 3
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, map[string]string{filepath.Join("speech", "existing.txt"): "existing"})
+	if err := Run(t.Context(), "librarian", "generate", "--api-source", googleapisDir, "speech"); err == nil {
+		t.Fatal("expected error from failing hook, got nil")
+	}
+	got, err := os.ReadFile(filepath.Join("speech", "existing.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "existing" {
+		t.Errorf("existing.txt = %q, want %q", got, "existing")
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected generated files to be rolled back, got %v", err)
+	}
+}
+
+func TestGenerateFailOnNoChanges(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial commit")
+	args := []string{"librarian", "generate", "--api-source", googleapisDir, "--fail-on-no-changes", "speech"}
+
+	// The first generation creates the library.
+	if err := Run(t.Context(), args...); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "feat: generate speech")
+
+	// Generating again changes nothing.
+	if err := Run(t.Context(), args...); !errors.Is(err, errNoChanges) {
+		t.Errorf("want error %v, got %v", errNoChanges, err)
+	}
+}
+
+func TestGenerateNoClean(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The fake language cleans a library by deleting its README.md, which is
+	// missing here, so cleaning would fail.
+	handwritten := map[string]string{
+		filepath.Join("speech", "NOTES.md"):       "hand-authored notes\n",
+		filepath.Join("speech", "docs", "faq.md"): "hand-authored FAQ\n",
+	}
+	writeFiles(t, handwritten)
+
+	args := []string{"librarian", "generate", "--api-source", googleapisDir, "--no-clean", "speech"}
+	if err := Run(t.Context(), args...); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range handwritten {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", path, diff)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); err != nil {
+		t.Errorf("README.md was not generated: %v", err)
+	}
+}
+
+func TestGeneratePatchFile(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial commit")
+	patchFile := filepath.Join(t.TempDir(), "generate.patch")
+	args := []string{"librarian", "generate", "--api-source", googleapisDir, "--patch-file", patchFile, "speech"}
+	if err := Run(t.Context(), args...); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(patchFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := command.Output(t.Context(), command.Git, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for line := range strings.Lines(want) {
+		name := strings.TrimSpace(strings.TrimPrefix(line, "??"))
+		if !strings.Contains(string(got), "diff --git a/"+name+" b/"+name+"\nnew file mode") {
+			t.Errorf("patch does not add %s:\n%s", name, got)
+		}
+	}
+
+	// The patch applies to a clean checkout.
+	testhelper.RunGit(t, "clean", "-fdq")
+	testhelper.RunGit(t, "apply", patchFile)
+	if err := checkGeneratedChanges(t.Context()); err != nil {
+		t.Errorf("working tree is clean after applying the patch: %v", err)
+	}
+}
+
+func TestGenerateUpdateSource(t *testing.T) {
+	remoteDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	testhelper.ContinueInNewGitRepository(t, remoteDir)
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial version")
+	googleapisDir := filepath.Join(t.TempDir(), "googleapis")
+	testhelper.RunGit(t, "clone", remoteDir, googleapisDir)
+	protoFile := filepath.Join("google", "cloud", "speech", "v1", "speech.proto")
+	if err := os.WriteFile(protoFile, []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "feat: change speech")
+
+	t.Chdir(t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "generate", "--api-source", googleapisDir, "--update-source", "speech"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(googleapisDir, protoFile)); err != nil {
+		t.Errorf("expected googleapis checkout to be updated, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); err != nil {
+		t.Errorf("expected speech to be generated, got %v", err)
+	}
+}
+
+func TestGenerateDryRun_UpdateSource(t *testing.T) {
+	remoteDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	testhelper.ContinueInNewGitRepository(t, remoteDir)
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial version")
+	googleapisDir := filepath.Join(t.TempDir(), "googleapis")
+	testhelper.RunGit(t, "clone", remoteDir, googleapisDir)
+	protoFile := filepath.Join("google", "cloud", "speech", "v1", "speech.proto")
+	if err := os.WriteFile(protoFile, []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "feat: change speech")
+
+	t.Chdir(t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "generate", "--dry-run", "--api-source", googleapisDir, "--update-source", "speech"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(googleapisDir, protoFile)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected googleapis checkout to not be updated, got %v", err)
+	}
+}
+
+func TestGenerateAPISource_Error(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`
+	if err := os.WriteFile(config.LibrarianYAML, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{
+			name:    "missing directory",
+			args:    []string{"--api-source", filepath.Join(tempDir, "does-not-exist"), "speech"},
+			wantErr: errAPISourceNotDir,
+		},
+		{
+			name:    "file",
+			args:    []string{"--api-source", config.LibrarianYAML, "speech"},
+			wantErr: errAPISourceNotDir,
+		},
+		{
+			name:    "since without git",
+			args:    []string{"--api-source", googleapisDir, "--since=before", "speech"},
+			wantErr: errSinceRequiresSourceDir,
+		},
+		{
+			name:    "missing tarball",
+			args:    []string{"--api-source", filepath.Join(tempDir, "does-not-exist.tar.gz"), "speech"},
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name:    "update without git",
+			args:    []string{"--api-source", googleapisDir, "--update-source", "speech"},
+			wantErr: errUpdateRequiresSourceDir,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"librarian", "generate"}, test.args...)
+			err := Run(t.Context(), args...)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerationWaves(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		libraries []*config.Library
+		want      [][]string
+	}{
+		{
+			name: "no dependencies",
+			libraries: []*config.Library{
+				{Name: "a"},
+				{Name: "b"},
+			},
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name: "dependency first",
+			libraries: []*config.Library{
+				{Name: "wrapper", DependsOn: []string{"gapic"}},
+				{Name: "gapic"},
+				{Name: "other"},
+			},
+			want: [][]string{{"gapic", "other"}, {"wrapper"}},
+		},
+		{
+			name: "chain",
+			libraries: []*config.Library{
+				{Name: "c", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "a"},
+			},
+			want: [][]string{{"a"}, {"b"}, {"c"}},
+		},
+		{
+			name: "dependency not generated",
+			libraries: []*config.Library{
+				{Name: "wrapper", DependsOn: []string{"gapic"}},
+			},
+			want: [][]string{{"wrapper"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			waves, err := generationWaves(test.libraries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]string
+			for _, wave := range waves {
+				var names []string
+				for _, lib := range wave {
+					names = append(names, lib.Name)
+				}
+				got = append(got, names)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerationWaves_Cycle(t *testing.T) {
+	libraries := []*config.Library{
+		{Name: "independent"},
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"c"}},
+		{Name: "c", DependsOn: []string{"a"}},
+		{Name: "d", DependsOn: []string{"a"}},
+	}
+	_, err := generationWaves(libraries)
+	if !errors.Is(err, errDependencyCycle) {
+		t.Fatalf("want error %v, got %v", errDependencyCycle, err)
+	}
+	if want := "a -> b -> c -> a"; !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("error %q does not list the cycle %q", err, want)
+	}
+}
+
 func TestGenerate_Java(t *testing.T) {
 	tempDir := t.TempDir()
 	t.Chdir(tempDir)
@@ -375,6 +1130,26 @@ func createGoogleapisServiceConfigs(t *testing.T, tempDir string, configs map[st
 	return googleapisDir
 }
 
+func TestGenerate_PythonMissingTool(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+	cfg := &config.Config{Language: config.LanguagePython}
+	libraries := []*config.Library{
+		{Name: "google-cloud-one", Output: "packages/google-cloud-one", APIs: []*config.API{{Path: "google/cloud/one/v1"}}},
+		{Name: "google-cloud-two", Output: "packages/google-cloud-two", APIs: []*config.API{{Path: "google/cloud/two/v1"}}},
+	}
+	err := generateLibraries(t.Context(), cfg, libraries, nil, 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "python3") {
+		t.Fatalf("generateLibraries() error = %v, want it to name python3", err)
+	}
+	// The tools are checked once, before any library is generated.
+	for _, library := range libraries {
+		if _, err := os.Stat(library.Output); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("os.Stat(%q) error = %v, want %v", library.Output, err, fs.ErrNotExist)
+		}
+	}
+}
+
 func TestDefaultOutput(t *testing.T) {
 	for _, test := range []struct {
 		name       string
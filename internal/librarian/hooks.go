@@ -0,0 +1,48 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// runPostGenerateHooks runs the post_generate_hooks of cfg.Default, followed
+// by those of the library, for each library in libraries. Each hook is run
+// with sh -c in the output directory of the library.
+func runPostGenerateHooks(ctx context.Context, cfg *config.Config, libraries []*config.Library) error {
+	var defaultHooks []string
+	if cfg.Default != nil {
+		defaultHooks = cfg.Default.PostGenerateHooks
+	}
+	for _, library := range libraries {
+		env := map[string]string{
+			"LIBRARIAN_LIBRARY": library.Name,
+			"LIBRARIAN_VERSION": library.Version,
+		}
+		for _, hook := range slices.Concat(defaultHooks, library.PostGenerateHooks) {
+			slog.Info("running post-generate hook", "library", library.Name, "hook", hook)
+			if err := command.RunInDirWithEnv(ctx, library.Output, env, "sh", "-c", hook); err != nil {
+				return &libraryError{library: library, err: fmt.Errorf("post-generate hook for library %q: %w", library.Name, err)}
+			}
+		}
+	}
+	return nil
+}
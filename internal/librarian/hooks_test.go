@@ -0,0 +1,78 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+func TestRunPostGenerateHooks(t *testing.T) {
+	testhelper.RequireCommand(t, "sh")
+	t.Chdir(t.TempDir())
+	cfg := &config.Config{
+		Default: &config.Default{
+			PostGenerateHooks: []string{`echo "default $LIBRARIAN_LIBRARY" >> hooks.txt`},
+		},
+	}
+	libraries := []*config.Library{
+		{
+			Name:              "lib1",
+			Version:           "1.2.3",
+			Output:            "lib1",
+			PostGenerateHooks: []string{`echo "lib1 $LIBRARIAN_VERSION" >> hooks.txt`},
+		},
+		{Name: "lib2", Output: "lib2"},
+	}
+	for _, lib := range libraries {
+		if err := os.MkdirAll(lib.Output, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runPostGenerateHooks(t.Context(), cfg, libraries); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		filepath.Join("lib1", "hooks.txt"): "default lib1\nlib1 1.2.3\n",
+		filepath.Join("lib2", "hooks.txt"): "default lib2\n",
+	} {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestRunPostGenerateHooks_Error(t *testing.T) {
+	testhelper.RequireCommand(t, "sh")
+	t.Chdir(t.TempDir())
+	libraries := []*config.Library{
+		{Name: "lib", Output: ".", PostGenerateHooks: []string{"exit 1", "touch not-run.txt"}},
+	}
+	if err := runPostGenerateHooks(t.Context(), &config.Config{}, libraries); err == nil {
+		t.Fatal("runPostGenerateHooks() succeeded, want error")
+	}
+	if _, err := os.Stat("not-run.txt"); err == nil {
+		t.Error("hook after the failing hook should not run")
+	}
+}
@@ -62,7 +62,7 @@ func TestGenerate(t *testing.T) {
 
 	tmpDir := t.TempDir()
 	t.Chdir(tmpDir)
-	if err := generateLibraries(t.Context(), cfg, []*config.Library{library}, nil); err != nil {
+	if err := generateLibraries(t.Context(), cfg, []*config.Library{library}, nil, 1, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -92,7 +92,7 @@ func TestCleanLibraries(t *testing.T) {
 
 	tmpDir := t.TempDir()
 	t.Chdir(tmpDir)
-	if err := generateLibraries(t.Context(), cfg, []*config.Library{library}, nil); err != nil {
+	if err := generateLibraries(t.Context(), cfg, []*config.Library{library}, nil, 1, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -106,6 +106,38 @@ func TestCleanLibraries(t *testing.T) {
 	}
 }
 
+func TestCleanLibraries_LibrarianIgnore(t *testing.T) {
+	t.Chdir(t.TempDir())
+	library := &config.Library{
+		Name:   "test-library",
+		Output: "output",
+	}
+	for path, content := range map[string]string{
+		librarianIgnoreFile:                     "README.md\n",
+		"README.md":                             "handwritten",
+		filepath.Join("internal", "version.go"): "generated",
+	} {
+		path = filepath.Join(library.Output, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cleanLibraries(config.LanguageGo, []*config.Library{library}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{librarianIgnoreFile, "README.md"} {
+		if _, err := os.Stat(filepath.Join(library.Output, path)); err != nil {
+			t.Errorf("expected %s to be preserved, got %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(library.Output, "internal", "version.go")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected internal/version.go to be removed, got %v", err)
+	}
+}
+
 func TestFakeClean_Error(t *testing.T) {
 	const (
 		libraryName = "test-library"
@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/yaml"
+)
+
+var (
+	errUnsetEnvVar = errors.New("librarian.yaml references an unset environment variable")
+	envVarRegex    = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// interpolatedFields returns the fields of cfg that may reference environment
+// variables, keyed by their path in librarian.yaml. Interpolation is limited
+// to these fields, which differ between environments, so that a "${"
+// elsewhere in librarian.yaml is kept as is.
+func interpolatedFields(cfg *config.Config) map[string]*string {
+	fields := map[string]*string{"repo": &cfg.Repo}
+	for name, source := range sourcesOf(cfg.Sources) {
+		if *source != nil {
+			fields["sources."+name+".dir"] = &(*source).Dir
+		}
+	}
+	return fields
+}
+
+// sourcesOf returns a pointer to each source field of sources, keyed by its
+// name in librarian.yaml.
+func sourcesOf(sources *config.Sources) map[string]**config.Source {
+	if sources == nil {
+		return nil
+	}
+	return map[string]**config.Source{
+		"conformance": &sources.Conformance,
+		"discovery":   &sources.Discovery,
+		"googleapis":  &sources.Googleapis,
+		"protobuf":    &sources.ProtobufSrc,
+		"showcase":    &sources.Showcase,
+	}
+}
+
+// expandEnv replaces each ${NAME} in the interpolated fields of cfg with the
+// value of the environment variable NAME. It is an error for NAME to be
+// unset, as silently substituting an empty string would, for example, turn a
+// source directory into the current directory.
+func expandEnv(cfg *config.Config) error {
+	fields := interpolatedFields(cfg)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		field := fields[name]
+		expanded, err := expandEnvString(*field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*field = expanded
+	}
+	return nil
+}
+
+func expandEnvString(s string) (string, error) {
+	var unset []string
+	expanded := envVarRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := envVarRegex.FindStringSubmatch(match)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return value
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("%w: %s in %q", errUnsetEnvVar, unset[0], s)
+	}
+	return expanded, nil
+}
+
+// writeConfig writes cfg to path. Interpolated fields that still hold the
+// expansion of their value in the existing file at path are written with
+// that value, so that commands which rewrite librarian.yaml keep its
+// environment variable references rather than the values they had in this
+// run. cfg itself is not changed.
+func writeConfig(path string, cfg *config.Config) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return yaml.Write(path, cfg)
+	}
+	if err != nil {
+		return err
+	}
+	original, err := parseConfig(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	originalFields := interpolatedFields(original)
+	out := copyInterpolatedFields(cfg)
+	for name, field := range interpolatedFields(out) {
+		originalField, ok := originalFields[name]
+		if !ok || !envVarRegex.MatchString(*originalField) {
+			continue
+		}
+		if expanded, err := expandEnvString(*originalField); err == nil && expanded == *field {
+			*field = *originalField
+		}
+	}
+	return yaml.Write(path, out)
+}
+
+// copyInterpolatedFields returns a copy of cfg that shares everything with
+// cfg except for the structs holding its interpolated fields, so that they can
+// be changed without changing cfg.
+func copyInterpolatedFields(cfg *config.Config) *config.Config {
+	out := *cfg
+	if cfg.Sources != nil {
+		sources := *cfg.Sources
+		out.Sources = &sources
+		for _, source := range sourcesOf(out.Sources) {
+			if *source != nil {
+				s := **source
+				*source = &s
+			}
+		}
+	}
+	return &out
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestReadConfig_ExpandEnv(t *testing.T) {
+	t.Setenv("LIBRARIAN_TEST_OWNER", "googleapis")
+	t.Setenv("LIBRARIAN_TEST_GOOGLEAPIS", "/src/googleapis")
+	path := filepath.Join(t.TempDir(), config.LibrarianYAML)
+	content := `language: fake
+repo: ${LIBRARIAN_TEST_OWNER}/google-cloud-fake
+sources:
+  googleapis:
+    commit: abc123
+    dir: ${LIBRARIAN_TEST_GOOGLEAPIS}
+libraries:
+  - name: ${LIBRARIAN_TEST_OWNER}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.Config{
+		Language: "fake",
+		Repo:     "googleapis/google-cloud-fake",
+		Sources: &config.Sources{
+			Googleapis: &config.Source{Commit: "abc123", Dir: "/src/googleapis"},
+		},
+		// Only the interpolated fields are expanded.
+		Libraries: []*config.Library{{Name: "${LIBRARIAN_TEST_OWNER}"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadConfig_ExpandEnvError(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "repo",
+			content: "language: fake\nrepo: ${LIBRARIAN_TEST_UNSET}/google-cloud-fake\n",
+		},
+		{
+			name:    "source dir",
+			content: "language: fake\nsources:\n  googleapis:\n    commit: abc123\n    dir: ${LIBRARIAN_TEST_UNSET}\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), config.LibrarianYAML)
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := readConfig(path)
+			if !errors.Is(err, errUnsetEnvVar) {
+				t.Errorf("readConfig() error = %v, want %v", err, errUnsetEnvVar)
+			}
+		})
+	}
+}
+
+func TestExpandEnvString(t *testing.T) {
+	t.Setenv("LIBRARIAN_TEST_A", "a")
+	t.Setenv("LIBRARIAN_TEST_EMPTY", "")
+	for _, test := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "no references", in: "googleapis/google-cloud-go", want: "googleapis/google-cloud-go"},
+		{name: "several references", in: "${LIBRARIAN_TEST_A}/${LIBRARIAN_TEST_A}", want: "a/a"},
+		{name: "empty value", in: "x${LIBRARIAN_TEST_EMPTY}y", want: "xy"},
+		{name: "without braces", in: "$LIBRARIAN_TEST_A", want: "$LIBRARIAN_TEST_A"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := expandEnvString(test.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteConfig_KeepsEnvReferences(t *testing.T) {
+	t.Setenv("LIBRARIAN_TEST_OWNER", "googleapis")
+	t.Setenv("LIBRARIAN_TEST_GOOGLEAPIS", "/src/googleapis")
+	path := filepath.Join(t.TempDir(), config.LibrarianYAML)
+	content := `language: fake
+repo: ${LIBRARIAN_TEST_OWNER}/google-cloud-fake
+sources:
+  googleapis:
+    commit: abc123
+    dir: ${LIBRARIAN_TEST_GOOGLEAPIS}
+  showcase:
+    commit: def456
+    dir: ${LIBRARIAN_TEST_GOOGLEAPIS}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Sources.Googleapis.Commit = "fed789"
+	cfg.Sources.Showcase.Dir = "/src/showcase"
+	if err := writeConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	got := parseConfigFile(t, path)
+	want := &config.Config{
+		Language: "fake",
+		Repo:     "${LIBRARIAN_TEST_OWNER}/google-cloud-fake",
+		Sources: &config.Sources{
+			Googleapis: &config.Source{Commit: "fed789", Dir: "${LIBRARIAN_TEST_GOOGLEAPIS}"},
+			Showcase:   &config.Source{Commit: "def456", Dir: "/src/showcase"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	// The configuration in memory keeps its expanded values.
+	if diff := cmp.Diff("/src/googleapis", cfg.Sources.Googleapis.Dir); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// parseConfigFile parses the configuration at path without expanding
+// environment variables.
+func parseConfigFile(t *testing.T, path string) *config.Config {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
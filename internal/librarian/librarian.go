@@ -20,8 +20,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 
 	"github.com/googleapis/librarian/internal/command"
 	"github.com/googleapis/librarian/internal/config"
@@ -33,13 +35,17 @@ import (
 	"github.com/googleapis/librarian/internal/librarian/ruby"
 	"github.com/googleapis/librarian/internal/librarian/rust"
 	"github.com/googleapis/librarian/internal/tool/protoc"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
 // ErrLibraryNotFound is returned when the specified library is not found in config.
 var ErrLibraryNotFound = errors.New("library not found")
 
+var (
+	errInvalidLogFormat  = errors.New("--log-format must be one of text or json")
+	errInvalidConfigFile = errors.New("--config-file must be a relative path inside the repository")
+)
+
 // Run executes the librarian command with the given arguments.
 func Run(ctx context.Context, args ...string) error {
 	cmd := &cli.Command{
@@ -52,10 +58,49 @@ func Run(ctx context.Context, args ...string) error {
 				Aliases: []string{"v"},
 				Usage:   "enable verbose logging",
 			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Value: "text",
+				Usage: "log `format`: text or json",
+			},
+			&cli.StringFlag{
+				Name:  "github-api-endpoint",
+				Usage: "base `URL` of the GitHub REST API, for GitHub Enterprise; defaults to " + defaultGitHubAPI,
+			},
+			&cli.StringFlag{
+				Name:  "config-file",
+				Value: config.LibrarianYAML,
+				Usage: "read and write the librarian configuration at this `PATH`, relative to the repository root",
+			},
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 			command.Verbose = cmd.Bool("verbose")
-			setupLogger(command.Verbose)
+			handler, err := newLogHandler(os.Stderr, command.Verbose, cmd.String("log-format"))
+			if err != nil {
+				return ctx, err
+			}
+			logger := slog.New(handler)
+			if cmd.String("log-format") == "json" {
+				// Attach the command to each record so that JSON logs can be
+				// filtered by command.
+				logger = logger.With("command", cmd.Args().First())
+			}
+			slog.SetDefault(logger)
+			// The configuration file is also looked up in the git history,
+			// where paths are relative to the repository root.
+			if configFile := cmd.String("config-file"); !filepath.IsLocal(configFile) {
+				return ctx, fmt.Errorf("%w: %q", errInvalidConfigFile, configFile)
+			}
+			// Always set githubAPI, so that a flag from an earlier Run in the
+			// same process does not carry over.
+			githubAPI = defaultGitHubAPI
+			if cmd.IsSet("github-api-endpoint") {
+				endpoint, err := parseGitHubAPIEndpoint(cmd.String("github-api-endpoint"))
+				if err != nil {
+					return ctx, err
+				}
+				githubAPI = endpoint
+			}
 			return ctx, nil
 		},
 		Commands: []*cli.Command{
@@ -63,13 +108,18 @@ func Run(ctx context.Context, args ...string) error {
 			addCommand(),
 			generateCommand(),
 			bumpCommand(),
+			statusCommand(),
 			installCommand(),
 			tidyCommand(),
+			validateCommand(),
+			schemaCommand(),
 			updateCommand(),
 			publishCommand(),
 			tagCommand(),
+			abandonCommand(),
 			versionCommand(),
 			debugCommand(),
+			doctorCommand(),
 		},
 	}
 	return cmd.Run(ctx, args)
@@ -93,7 +143,7 @@ Examples:
 	librarian install go           # install Go-specific tools`,
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			lang := cmd.Args().First()
-			cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			cfg, err := readConfig(cmd.String("config-file"))
 			if err != nil && lang == "" {
 				return err
 			}
@@ -140,28 +190,51 @@ func versionCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "version",
 		Usage:     "print the binary version",
-		UsageText: "librarian version",
+		UsageText: "librarian version [--output-format=text|json|yaml]",
 		Description: `version prints the librarian binary version and exits. The version is
 embedded at build time and follows the conventions described at
-https://go.dev/ref/mod#versions.`,
+https://go.dev/ref/mod#versions.
+
+The --output-format flag selects json or yaml output for scripts, which
+also includes the git commit and date of the build and the Go version used.
+
+Examples:
+
+	librarian version
+	librarian version --output-format=json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "output-format",
+				Value: outputFormatText,
+				Usage: "output `format`: text, json or yaml",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			fmt.Printf("librarian version %s\n", Version())
-			return nil
+			return writeVersion(cmd.Root().Writer, readBuildInfo(), cmd.String("output-format"))
 		},
 	}
 }
 
-// setupLogger configures the default slog logger.
-// It uses a text handler writing to stderr at LevelWarn and above by default.
+// newLogHandler returns the handler for the default slog logger, writing to w
+// in the given format, which is either "text" or "json".
+// It logs at LevelWarn and above by default.
 // If verbose is true, the log level is set to LevelDebug.
 // Source information (file name and line number) is included in each log entry.
-func setupLogger(verbose bool) {
+func newLogHandler(w io.Writer, verbose bool, format string) (slog.Handler, error) {
 	level := slog.LevelWarn
 	if verbose {
 		level = slog.LevelDebug
 	}
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+	opts := &slog.HandlerOptions{
 		Level:     level,
 		AddSource: true,
-	})))
+	}
+	switch format {
+	case "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errInvalidLogFormat, format)
+	}
 }
@@ -0,0 +1,148 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestNewLogHandler(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		verbose bool
+		want    map[string]any
+	}{
+		{
+			name: "warn",
+			want: map[string]any{
+				"level":   "WARN",
+				"msg":     "warning",
+				"library": "google-cloud-storage",
+			},
+		},
+		{
+			name:    "verbose",
+			verbose: true,
+			want: map[string]any{
+				"level":   "DEBUG",
+				"msg":     "debug",
+				"library": "google-cloud-storage",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler, err := newLogHandler(&buf, test.verbose, "json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			logger := slog.New(handler)
+			logger.Debug("debug", "library", "google-cloud-storage")
+			if !test.verbose {
+				logger.Warn("warning", "library", "google-cloud-storage")
+			}
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON log %q: %v", buf.String(), err)
+			}
+			delete(got, "time")
+			delete(got, "source")
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewLogHandler_Error(t *testing.T) {
+	_, err := newLogHandler(&bytes.Buffer{}, false, "xml")
+	if !errors.Is(err, errInvalidLogFormat) {
+		t.Errorf("want error %v, got %v", errInvalidLogFormat, err)
+	}
+}
+
+func TestConfigFileFlag(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	configFile := filepath.Join("testdata", "fixture.yaml")
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configContent := fmt.Sprintf(`language: fake
+default:
+  output: generated
+sources:
+  googleapis:
+    dir: %s
+libraries:
+  - name: speech
+    output: speech
+    apis:
+      - path: google/cloud/speech/v1
+`, googleapisDir)
+	if err := os.WriteFile(configFile, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(t.Context(), "librarian", "--config-file", configFile, "generate", "speech"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("speech", "README.md")); err != nil {
+		t.Errorf("expected speech to be generated, got %v", err)
+	}
+	if err := Run(t.Context(), "librarian", "--config-file", configFile, "tidy"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) == configContent {
+		t.Errorf("expected %s to be tidied", configFile)
+	}
+	if _, err := os.Stat(config.LibrarianYAML); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %s to not be created, got %v", config.LibrarianYAML, err)
+	}
+}
+
+func TestConfigFileFlag_Error(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		configFile string
+	}{
+		{name: "absolute", configFile: filepath.Join(t.TempDir(), config.LibrarianYAML)},
+		{name: "outside repository", configFile: filepath.Join("..", config.LibrarianYAML)},
+		{name: "empty", configFile: ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := Run(t.Context(), "librarian", "--config-file", test.configFile, "tidy")
+			if !errors.Is(err, errInvalidConfigFile) {
+				t.Errorf("want error %v, got %v", errInvalidConfigFile, err)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"slices"
 	"strings"
 
 	"github.com/googleapis/librarian/internal/config"
@@ -376,6 +377,35 @@ func FindLibrary(c *config.Config, name string) (*config.Library, error) {
 	return nil, fmt.Errorf("%w: %q", ErrLibraryNotFound, name)
 }
 
+// LibraryOutputs returns the output directory of each library in c, keyed by
+// library name. Libraries without an output directory, such as mixed
+// libraries with no explicit output, are omitted.
+func LibraryOutputs(c *config.Config) map[string]string {
+	outputs := map[string]string{}
+	for _, library := range c.Libraries {
+		if output := libraryOutput(c.Language, library, c.Default); output != "" {
+			outputs[library.Name] = output
+		}
+	}
+	return outputs
+}
+
+// findLibraries returns the libraries with the given names, in the order
+// given. Repeated names are only returned once.
+func findLibraries(c *config.Config, names []string) ([]*config.Library, error) {
+	var libraries []*config.Library
+	for _, name := range names {
+		library, err := FindLibrary(c, name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(libraries, library) {
+			libraries = append(libraries, library)
+		}
+	}
+	return libraries, nil
+}
+
 // ResolvePreview returns a library where fields from lib.Preview override
 // those in the base lib, if set. If lib.Preview is not set or lib itself is nil
 // this returns nil.
@@ -403,6 +433,9 @@ func ResolvePreview(lib *config.Library, language string) *config.Library {
 	if p.Output != "" {
 		res.Output = p.Output
 	}
+	if p.OutputAllowlist != nil {
+		res.OutputAllowlist = p.OutputAllowlist
+	}
 	if p.Roots != nil {
 		res.Roots = p.Roots
 	}
@@ -727,6 +760,9 @@ func mergePython(dst, src *config.PythonPackage) *config.PythonPackage {
 	if src.OptArgsByAPI != nil {
 		res.OptArgsByAPI = src.OptArgsByAPI
 	}
+	if src.TransportByAPI != nil {
+		res.TransportByAPI = src.TransportByAPI
+	}
 	if src.ProtoOnlyAPIs != nil {
 		res.ProtoOnlyAPIs = src.ProtoOnlyAPIs
 	}
@@ -1083,6 +1083,30 @@ func TestResolvePreview_NoMutation(t *testing.T) {
 	}
 }
 
+func TestLibraryOutputs(t *testing.T) {
+	cfg := &config.Config{
+		Language: config.LanguageRust,
+		Default:  &config.Default{Output: "src/generated"},
+		Libraries: []*config.Library{
+			{
+				Name:   "google-cloud-storage",
+				Output: "src/storage",
+			},
+			{
+				Name: "google-cloud-secretmanager-v1",
+				APIs: []*config.API{{Path: "google/cloud/secretmanager/v1"}},
+			},
+		},
+	}
+	want := map[string]string{
+		"google-cloud-storage":          "src/storage",
+		"google-cloud-secretmanager-v1": "src/generated/cloud/secretmanager/v1",
+	}
+	if diff := cmp.Diff(want, LibraryOutputs(cfg)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestMergeDotnet(t *testing.T) {
 	for _, test := range []struct {
 		name string
@@ -1523,6 +1547,7 @@ func TestMergePython(t *testing.T) {
 					LibraryType:       "NEW",
 				},
 				OptArgsByAPI:                map[string][]string{"a": {"o"}},
+				TransportByAPI:              map[string]string{"a": "rest"},
 				ProtoOnlyAPIs:               []string{"proto"},
 				ClientDocumentationOverride: "client-doc",
 				IssueTrackerOverride:        "issue",
@@ -1536,6 +1561,7 @@ func TestMergePython(t *testing.T) {
 					LibraryType:       "NEW",
 				},
 				OptArgsByAPI:                map[string][]string{"a": {"o"}},
+				TransportByAPI:              map[string]string{"a": "rest"},
 				ProtoOnlyAPIs:               []string{"proto"},
 				ClientDocumentationOverride: "client-doc",
 				IssueTrackerOverride:        "issue",
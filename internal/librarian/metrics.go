@@ -0,0 +1,171 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// metricsPushTimeout bounds the time spent pushing metrics, so that an
+// unreachable Pushgateway does not hold up a run.
+const metricsPushTimeout = 10 * time.Second
+
+// labelValueReplacer escapes label values in the Prometheus text format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metric is a single sample of a Prometheus gauge.
+type metric struct {
+	name   string
+	help   string
+	labels map[string]string
+	value  float64
+}
+
+// generateMetrics returns the metrics of a generate run over libraries that
+// failed with runErr, if not nil. The result of each library is taken from
+// outcome: libraries kept by the checkpoint count as generated, those whose
+// changes were reverted as no-op as unchanged, and those that were not
+// generated because another library failed as skipped.
+func generateMetrics(libraries []*config.Library, outcome *generateOutcome, duration time.Duration, runErr error) []metric {
+	counts := map[string]int{}
+	var durations []metric
+	for _, library := range libraries {
+		status, _ := outcome.status(library, runErr)
+		counts[metricResult(status)]++
+		durations = append(durations, metric{
+			name:   "librarian_generate_library_duration_seconds",
+			help:   "Time spent generating each library in the last generate run.",
+			labels: map[string]string{"library": library.Name},
+			value:  outcome.runs.duration(library).Seconds(),
+		})
+	}
+	const help = "Number of libraries by result of the last generate run."
+	var metrics []metric
+	for _, result := range []string{"generated", "unchanged", "failed", "skipped"} {
+		metrics = append(metrics, metric{name: "librarian_generate_libraries", help: help, labels: map[string]string{"result": result}, value: float64(counts[result])})
+	}
+	metrics = append(metrics,
+		metric{name: "librarian_generate_duration_seconds", help: "Duration of the last generate run.", value: duration.Seconds()},
+		metric{name: "librarian_generate_success", help: "Whether the last generate run succeeded.", value: boolValue(runErr == nil)},
+	)
+	return append(metrics, durations...)
+}
+
+// metricResult returns the result label of the librarian_generate_libraries
+// metric for a library with the given summary status.
+func metricResult(status string) string {
+	switch status {
+	case libraryStatusGenerated, libraryStatusCheckpointed:
+		return "generated"
+	case libraryStatusUnchanged:
+		return "unchanged"
+	case libraryStatusFailed:
+		return "failed"
+	default:
+		return "skipped"
+	}
+}
+
+// bumpMetrics returns a metric for each library whose version changed from
+// the one in before.
+func bumpMetrics(cfg *config.Config, before map[string]string) []metric {
+	var metrics []metric
+	for _, lib := range cfg.Libraries {
+		if lib.Version == before[lib.Name] {
+			continue
+		}
+		metrics = append(metrics, metric{
+			name:   "librarian_bump_version_info",
+			help:   "Versions assigned by the last bump run, with a value of 1.",
+			labels: map[string]string{"library": lib.Name, "version": lib.Version},
+			value:  1,
+		})
+	}
+	return metrics
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// pushMetrics replaces the metrics of job in the Prometheus Pushgateway at
+// gateway. Pushing is best effort: failures are logged and never fail the
+// run.
+func pushMetrics(ctx context.Context, gateway, job string, metrics []metric) {
+	if gateway == "" || len(metrics) == 0 {
+		return
+	}
+	if err := putMetrics(ctx, gateway, job, metrics); err != nil {
+		slog.Warn("failed to push metrics", "gateway", gateway, "job", job, "error", err)
+	}
+}
+
+func putMetrics(ctx context.Context, gateway, job string, metrics []metric) error {
+	ctx, cancel := context.WithTimeout(ctx, metricsPushTimeout)
+	defer cancel()
+	target := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(formatMetrics(metrics)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response from %s: %s", target, resp.Status)
+	}
+	return nil
+}
+
+// formatMetrics encodes metrics in the Prometheus text exposition format.
+// Samples of the same metric must be adjacent in metrics.
+func formatMetrics(metrics []metric) []byte {
+	var b bytes.Buffer
+	previous := ""
+	for _, m := range metrics {
+		if m.name != previous {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+			previous = m.name
+		}
+		b.WriteString(m.name)
+		if len(m.labels) > 0 {
+			var labels []string
+			for _, k := range slices.Sorted(maps.Keys(m.labels)) {
+				labels = append(labels, k+`="`+labelValueReplacer.Replace(m.labels[k])+`"`)
+			}
+			b.WriteString("{" + strings.Join(labels, ",") + "}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(m.value, 'g', -1, 64) + "\n")
+	}
+	return b.Bytes()
+}
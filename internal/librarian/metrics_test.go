@@ -0,0 +1,171 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestFormatMetrics(t *testing.T) {
+	metrics := []metric{
+		{name: "a_total", help: "Help for a.", labels: map[string]string{"result": "ok", "library": `say "hi"`}, value: 2},
+		{name: "a_total", help: "Help for a.", labels: map[string]string{"result": "failed", "library": "x"}, value: 0},
+		{name: "b_seconds", help: "Help for b.", value: 1.5},
+	}
+	want := `# HELP a_total Help for a.
+# TYPE a_total gauge
+a_total{library="say \"hi\"",result="ok"} 2
+a_total{library="x",result="failed"} 0
+# HELP b_seconds Help for b.
+# TYPE b_seconds gauge
+b_seconds 1.5
+`
+	if diff := cmp.Diff(want, string(formatMetrics(metrics))); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateMetrics(t *testing.T) {
+	a, b, c := &config.Library{Name: "a"}, &config.Library{Name: "b"}, &config.Library{Name: "c"}
+	libraries := []*config.Library{a, b, c}
+	for _, test := range []struct {
+		name    string
+		outcome *generateOutcome
+		runErr  error
+		want    map[string]float64
+	}{
+		{
+			name: "success",
+			outcome: &generateOutcome{runs: libraryRuns{durations: map[*config.Library]time.Duration{
+				a: time.Second,
+				b: 2 * time.Second,
+				c: 3 * time.Second,
+			}}},
+			want: map[string]float64{
+				"librarian_generate_libraries/generated":        3,
+				"librarian_generate_library_duration_seconds/a": 1,
+				"librarian_generate_library_duration_seconds/b": 2,
+				"librarian_generate_library_duration_seconds/c": 3,
+				"librarian_generate_success/":                   1,
+			},
+		},
+		{
+			name:    "unchanged",
+			outcome: &generateOutcome{reverted: []*config.Library{b}},
+			want: map[string]float64{
+				"librarian_generate_libraries/generated": 2,
+				"librarian_generate_libraries/unchanged": 1,
+				"librarian_generate_success/":            1,
+			},
+		},
+		{
+			name:    "failure",
+			outcome: &generateOutcome{attempted: libraries, rolledBack: true},
+			runErr:  &libraryError{library: a, err: errors.New("generation failed")},
+			want: map[string]float64{
+				"librarian_generate_libraries/failed":  1,
+				"librarian_generate_libraries/skipped": 2,
+			},
+		},
+		{
+			name:    "failure with checkpoint",
+			outcome: &generateOutcome{attempted: libraries[:2], completed: libraries[:1], rolledBack: true},
+			runErr:  &libraryError{library: b, err: errors.New("generation failed")},
+			want: map[string]float64{
+				"librarian_generate_libraries/generated": 1,
+				"librarian_generate_libraries/failed":    1,
+				"librarian_generate_libraries/skipped":   1,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := map[string]float64{}
+			for _, m := range generateMetrics(libraries, test.outcome, 90*time.Second, test.runErr) {
+				if m.value != 0 {
+					got[m.name+"/"+m.labels["result"]+m.labels["library"]] = m.value
+				}
+			}
+			want := map[string]float64{"librarian_generate_duration_seconds/": 90}
+			maps.Copy(want, test.want)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBumpMetrics(t *testing.T) {
+	cfg := &config.Config{
+		Libraries: []*config.Library{
+			{Name: "bumped", Version: "1.1.0"},
+			{Name: "unchanged", Version: "2.0.0"},
+			{Name: "new", Version: "0.1.0"},
+		},
+	}
+	before := map[string]string{"bumped": "1.0.0", "unchanged": "2.0.0"}
+	got := map[string]string{}
+	for _, m := range bumpMetrics(cfg, before) {
+		got[m.labels["library"]] = m.labels["version"]
+	}
+	want := map[string]string{"bumped": "1.1.0", "new": "0.1.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		gotMethod, gotPath, gotBody = r.Method, r.URL.Path, string(body)
+	}))
+	defer ts.Close()
+	metrics := []metric{{name: "m", help: "Help.", value: 1}}
+	pushMetrics(t.Context(), ts.URL+"/", "librarian_generate", metrics)
+	if gotMethod != http.MethodPut {
+		t.Errorf("method = %q, want %q", gotMethod, http.MethodPut)
+	}
+	if want := "/metrics/job/librarian_generate"; gotPath != want {
+		t.Errorf("path = %q, want %q", gotPath, want)
+	}
+	if diff := cmp.Diff(string(formatMetrics(metrics)), gotBody); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPutMetrics_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	metrics := []metric{{name: "m", help: "Help.", value: 1}}
+	if err := putMetrics(t.Context(), ts.URL, "librarian_generate", metrics); err == nil {
+		t.Error("putMetrics() succeeded, want error")
+	}
+	// pushMetrics only logs the failure.
+	pushMetrics(t.Context(), ts.URL, "librarian_generate", metrics)
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// noopIgnorePatterns compiles the noop_ignore_patterns of lib.
+func noopIgnorePatterns(lib *config.Library) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, p := range lib.NoopIgnorePatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: library %q: %w", errInvalidNoopIgnorePattern, lib.Name, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// revertNoopChanges restores from snapshot the output of each library whose
+// generated changes only touch lines matching its noop_ignore_patterns, so
+// that a regeneration that merely restamps a date or version leaves the
+// library unchanged. It returns the libraries that were restored.
+func revertNoopChanges(libraries []*config.Library, snapshot *outputSnapshot) ([]*config.Library, error) {
+	var reverted []*config.Library
+	for _, lib := range libraries {
+		patterns, err := noopIgnorePatterns(lib)
+		if err != nil {
+			return reverted, err
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+		saved, ok := snapshot.savedOutput(lib.Output)
+		if !ok {
+			continue
+		}
+		noop, err := isNoopChange(lib.Output, saved, patterns)
+		if err != nil {
+			return reverted, fmt.Errorf("library %q: %w", lib.Name, err)
+		}
+		if !noop {
+			continue
+		}
+		slog.Info("discarding generated changes that only touch noop_ignore_patterns", "library", lib.Name)
+		if err := restoreOutput(lib.Output, saved); err != nil {
+			return reverted, fmt.Errorf("library %q: %w", lib.Name, err)
+		}
+		reverted = append(reverted, lib)
+	}
+	return reverted, nil
+}
+
+// isNoopChange reports whether the files in output differ from those in saved
+// only in lines where both the old and the new line match one of patterns.
+// Adding, removing or renaming a file, or changing the number of lines in a
+// file, is always a change.
+func isNoopChange(output, saved string, patterns []*regexp.Regexp) (bool, error) {
+	before, err := listFiles(saved)
+	if err != nil {
+		return false, err
+	}
+	after, err := listFiles(output)
+	if err != nil {
+		return false, err
+	}
+	if !slices.Equal(before, after) {
+		return false, nil
+	}
+	for _, name := range after {
+		old, err := os.ReadFile(filepath.Join(saved, name))
+		if err != nil {
+			return false, err
+		}
+		generated, err := os.ReadFile(filepath.Join(output, name))
+		if err != nil {
+			return false, err
+		}
+		if !onlyIgnoredLinesDiffer(old, generated, patterns) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func onlyIgnoredLinesDiffer(old, generated []byte, patterns []*regexp.Regexp) bool {
+	if bytes.Equal(old, generated) {
+		return true
+	}
+	oldLines := bytes.Split(old, []byte("\n"))
+	generatedLines := bytes.Split(generated, []byte("\n"))
+	if len(oldLines) != len(generatedLines) {
+		return false
+	}
+	ignored := func(line []byte) bool {
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.Match(line) })
+	}
+	for i := range oldLines {
+		if bytes.Equal(oldLines[i], generatedLines[i]) {
+			continue
+		}
+		if !ignored(oldLines[i]) || !ignored(generatedLines[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// listFiles returns the sorted paths, relative to dir, of the files in dir.
+// It returns nil if dir does not exist.
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return files, err
+}
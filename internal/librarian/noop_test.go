@@ -0,0 +1,163 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestIsNoopChange(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile(`^// Generated on \d{4}-\d{2}-\d{2}$`)}
+	before := map[string]string{
+		"a.go":     "// Generated on 2026-01-01\npackage a\n",
+		"b/b.go":   "package b\n",
+		"doc.txt":  "unchanged\n",
+		"date.txt": "// Generated on 2026-01-01\n",
+	}
+	for _, test := range []struct {
+		name  string
+		after map[string]string
+		want  bool
+	}{
+		{
+			name:  "unchanged",
+			after: before,
+			want:  true,
+		},
+		{
+			name: "only ignored lines",
+			after: map[string]string{
+				"a.go":     "// Generated on 2026-10-16\npackage a\n",
+				"b/b.go":   "package b\n",
+				"doc.txt":  "unchanged\n",
+				"date.txt": "// Generated on 2026-10-16\n",
+			},
+			want: true,
+		},
+		{
+			name: "other line changed",
+			after: map[string]string{
+				"a.go":     "// Generated on 2026-10-16\npackage aa\n",
+				"b/b.go":   "package b\n",
+				"doc.txt":  "unchanged\n",
+				"date.txt": "// Generated on 2026-01-01\n",
+			},
+		},
+		{
+			name: "ignored line replaced by other line",
+			after: map[string]string{
+				"a.go":     "// Generated by hand\npackage a\n",
+				"b/b.go":   "package b\n",
+				"doc.txt":  "unchanged\n",
+				"date.txt": "// Generated on 2026-01-01\n",
+			},
+		},
+		{
+			name: "line added",
+			after: map[string]string{
+				"a.go":     "// Generated on 2026-01-01\npackage a\n",
+				"b/b.go":   "package b\n",
+				"doc.txt":  "unchanged\nadded\n",
+				"date.txt": "// Generated on 2026-01-01\n",
+			},
+		},
+		{
+			name: "file removed",
+			after: map[string]string{
+				"a.go":    "// Generated on 2026-01-01\npackage a\n",
+				"b/b.go":  "package b\n",
+				"doc.txt": "unchanged\n",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			files := map[string]string{}
+			for name, content := range before {
+				files[filepath.Join("saved", name)] = content
+			}
+			for name, content := range test.after {
+				files[filepath.Join("output", name)] = content
+			}
+			writeFiles(t, files)
+			got, err := isNoopChange("output", "saved", patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("isNoopChange() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRevertNoopChanges(t *testing.T) {
+	t.Chdir(t.TempDir())
+	const stamped = "version: 1.0.0\ncode\n"
+	writeFiles(t, map[string]string{
+		"noop/a.txt":    stamped,
+		"changed/a.txt": stamped,
+		"plain/a.txt":   stamped,
+	})
+	noop := &config.Library{Name: "noop", Output: "noop", NoopIgnorePatterns: []string{`^version: `}}
+	changed := &config.Library{Name: "changed", Output: "changed", NoopIgnorePatterns: []string{`^version: `}}
+	plain := &config.Library{Name: "plain", Output: "plain"}
+	libraries := []*config.Library{noop, changed, plain}
+	snapshot, err := snapshotOutputs(libraries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.remove()
+	writeFiles(t, map[string]string{
+		"noop/a.txt":    "version: 1.1.0\ncode\n",
+		"changed/a.txt": "version: 1.1.0\nnew code\n",
+		"plain/a.txt":   "version: 1.1.0\ncode\n",
+	})
+
+	reverted, err := revertNoopChanges(libraries, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*config.Library{noop}, reverted); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	for name, want := range map[string]string{
+		"noop/a.txt":    stamped,
+		"changed/a.txt": "version: 1.1.0\nnew code\n",
+		"plain/a.txt":   "version: 1.1.0\ncode\n",
+	} {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestRevertNoopChanges_Error(t *testing.T) {
+	libraries := []*config.Library{{Name: "lib", Output: "lib", NoopIgnorePatterns: []string{"("}}}
+	if _, err := revertNoopChanges(libraries, &outputSnapshot{}); !errors.Is(err, errInvalidNoopIgnorePattern) {
+		t.Errorf("want error %v, got %v", errInvalidNoopIgnorePattern, err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// libraryProvenanceVersion is the schema version of [libraryProvenance]. It
+// must be incremented whenever a field is removed or its meaning changes.
+const libraryProvenanceVersion = 1
+
+// libraryProvenance records the inputs that produced a generated library. It
+// is written to <library>.json in the directory given by the
+// --provenance-dir flag. GoogleapisCommit is empty when generating from a
+// local directory, where commit metadata is unavailable.
+type libraryProvenance struct {
+	SchemaVersion    int    `json:"schemaVersion"`
+	Library          string `json:"library"`
+	GoogleapisCommit string `json:"googleapisCommit,omitempty"`
+	LibrarianVersion string `json:"librarianVersion"`
+	GeneratedAt      string `json:"generatedAt"`
+}
+
+// generateProvenance writes a [libraryProvenance] file for each library
+// generated by a run.
+type generateProvenance struct {
+	// dir is the directory the provenance files are written to.
+	dir              string
+	googleapisCommit string
+	librarianVersion string
+	// generatedAt is the start of the run, recorded for every library so
+	// that the libraries of a run share a timestamp.
+	generatedAt time.Time
+}
+
+// newGenerateProvenance returns a generateProvenance that writes to dir, for
+// a run starting now from googleapisCommit.
+func newGenerateProvenance(dir, googleapisCommit string) *generateProvenance {
+	return &generateProvenance{
+		dir:              dir,
+		googleapisCommit: googleapisCommit,
+		librarianVersion: Version(),
+		generatedAt:      time.Now(),
+	}
+}
+
+// write writes the provenance file of each of libraries, replacing any file
+// left by a previous run. It does nothing if p is nil.
+func (p *generateProvenance) write(libraries []*config.Library) error {
+	if p == nil || len(libraries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return err
+	}
+	for _, library := range libraries {
+		b, err := json.MarshalIndent(&libraryProvenance{
+			SchemaVersion:    libraryProvenanceVersion,
+			Library:          library.Name,
+			GoogleapisCommit: p.googleapisCommit,
+			LibrarianVersion: p.librarianVersion,
+			GeneratedAt:      p.generatedAt.UTC().Format(time.RFC3339),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(p.dir, library.Name+".json"), append(b, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestGenerateProvenance_Write(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".librarian", "provenance")
+	provenance := &generateProvenance{
+		dir:              dir,
+		googleapisCommit: "abc123",
+		librarianVersion: "v1.2.3",
+		generatedAt:      time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("PST", -8*60*60)),
+	}
+	libraries := []*config.Library{{Name: "library-one"}, {Name: "library-two"}}
+	if err := provenance.write(libraries); err != nil {
+		t.Fatal(err)
+	}
+	for _, library := range libraries {
+		got, err := readJSONFile[*libraryProvenance](filepath.Join(dir, library.Name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &libraryProvenance{
+			SchemaVersion:    libraryProvenanceVersion,
+			Library:          library.Name,
+			GoogleapisCommit: "abc123",
+			LibrarianVersion: "v1.2.3",
+			GeneratedAt:      "2026-03-04T13:06:07Z",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestGenerateProvenance_WriteNil(t *testing.T) {
+	var provenance *generateProvenance
+	if err := provenance.write([]*config.Library{{Name: "library-one"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateProvenance_Write_Error(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	provenance := newGenerateProvenance(file, "abc123")
+	if err := provenance.write([]*config.Library{{Name: "library-one"}}); err == nil {
+		t.Error("expected an error, got none")
+	}
+}
+
+func TestRunGenerate_Provenance(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1":       "speech_v1.yaml",
+		"google/cloud/texttospeech/v1": "texttospeech_v1.yaml",
+	})
+	cfg := &config.Config{
+		Language: config.LanguageFake,
+		Sources:  &config.Sources{Googleapis: &config.Source{Dir: googleapisDir}},
+	}
+	speech := &config.Library{
+		Name:   "speech",
+		Output: "speech",
+		APIs:   []*config.API{{Path: "google/cloud/speech/v1"}},
+	}
+	// texttospeech is generated after speech, and fails as the fake generator
+	// leaves VERSION, which is not in its allowlist.
+	texttospeech := &config.Library{
+		Name:            "texttospeech",
+		Output:          "texttospeech",
+		APIs:            []*config.API{{Path: "google/cloud/texttospeech/v1"}},
+		DependsOn:       []string{"speech"},
+		OutputAllowlist: []string{"README.md"},
+	}
+	libraries := []*config.Library{speech, texttospeech}
+	for _, test := range []struct {
+		name       string
+		checkpoint bool
+		want       []string
+	}{
+		{
+			name: "all rolled back",
+		},
+		{
+			name:       "completed libraries kept",
+			checkpoint: true,
+			want:       []string{"speech.json"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			writeFiles(t, map[string]string{
+				"speech/README.md":       "original",
+				"texttospeech/README.md": "original",
+				"texttospeech/VERSION":   "1.0.0",
+			})
+			dir := filepath.Join(t.TempDir(), "provenance")
+			var checkpoint *generateCheckpoint
+			if test.checkpoint {
+				checkpoint = newGenerateCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "")
+			}
+			_, err := runGenerate(t.Context(), cfg, libraries, 1, false, false, checkpoint, newGenerateProvenance(dir, ""))
+			if !errors.Is(err, errFileNotAllowed) {
+				t.Fatalf("want error %v, got %v", errFileNotAllowed, err)
+			}
+			var got []string
+			entries, err := os.ReadDir(dir)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
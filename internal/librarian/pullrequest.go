@@ -0,0 +1,236 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/fetch"
+)
+
+// releasePendingLabel is the label carried by release pull requests that
+// have not yet been tagged.
+const releasePendingLabel = "release:pending"
+
+var (
+	errInvalidPullRequestURL  = errors.New("invalid pull request URL")
+	errPullRequestNotFound    = errors.New("pull request not found")
+	errPullRequestNotMerged   = errors.New("pull request is not merged")
+	errPullRequestNotReleased = errors.New("pull request is not a pending release")
+	errMissingGitHubToken     = errors.New("a GitHub token is required")
+
+	pullRequestPathRegexp = regexp.MustCompile(`^/([^/]+)/([^/]+)/pull/(\d+)/?$`)
+)
+
+// pullRequestRef identifies a pull request on GitHub.
+type pullRequestRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// pullRequest holds the fields of a GitHub pull request used by librarian.
+type pullRequest struct {
+	Number         int
+	State          string
+	Merged         bool
+	MergeCommitSHA string
+	Labels         []string
+	// HeadRef is the name of the branch the pull request merges from.
+	HeadRef string
+	// HeadRepo is the full name, such as octocat/google-cloud-go, of the
+	// repository of HeadRef. It is empty if that repository was deleted.
+	HeadRepo string
+}
+
+// parsePullRequestURL parses a URL of the form
+// https://{host}/{owner}/{repo}/pull/{number}, where host is the GitHub host
+// of githubAPI: github.com, or the host of a GitHub Enterprise Server.
+func parsePullRequestURL(rawURL string) (*pullRequestRef, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", errInvalidPullRequestURL, rawURL, err)
+	}
+	if host := githubHost(); u.Scheme != "https" || u.Hostname() != host {
+		return nil, fmt.Errorf("%w: %q is not an https URL on %s", errInvalidPullRequestURL, rawURL, host)
+	}
+	matches := pullRequestPathRegexp.FindStringSubmatch(u.Path)
+	if matches == nil {
+		return nil, fmt.Errorf("%w: %q", errInvalidPullRequestURL, rawURL)
+	}
+	number, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", errInvalidPullRequestURL, rawURL, err)
+	}
+	return &pullRequestRef{Owner: matches[1], Repo: matches[2], Number: number}, nil
+}
+
+// getPullRequest fetches a pull request and its labels from the GitHub API.
+// The request is authenticated if a GitHub token is found.
+func getPullRequest(ctx context.Context, ref *pullRequestRef) (*pullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", githubAPI, ref.Owner, ref.Repo, ref.Number)
+	resp, err := githubRequest(ctx, http.MethodGet, url, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s/%s#%d", errPullRequestNotFound, ref.Owner, ref.Repo, ref.Number)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from %s: %s", url, resp.Status)
+	}
+	var body struct {
+		Number         int    `json:"number"`
+		State          string `json:"state"`
+		Merged         bool   `json:"merged"`
+		MergeCommitSHA string `json:"merge_commit_sha"`
+		Labels         []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Head struct {
+			Ref  string `json:"ref"`
+			Repo *struct {
+				FullName string `json:"full_name"`
+			} `json:"repo"`
+		} `json:"head"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	pr := &pullRequest{
+		Number:         body.Number,
+		State:          body.State,
+		Merged:         body.Merged,
+		MergeCommitSHA: body.MergeCommitSHA,
+		HeadRef:        body.Head.Ref,
+	}
+	if body.Head.Repo != nil {
+		pr.HeadRepo = body.Head.Repo.FullName
+	}
+	for _, label := range body.Labels {
+		pr.Labels = append(pr.Labels, label.Name)
+	}
+	return pr, nil
+}
+
+// closePullRequest closes a pull request without merging it.
+func closePullRequest(ctx context.Context, ref *pullRequestRef) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", githubAPI, ref.Owner, ref.Repo, ref.Number)
+	resp, err := githubRequest(ctx, http.MethodPatch, url, map[string]string{"state": "closed"}, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response from %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// deleteBranch deletes branch from repo, given by its full name. It reports
+// whether the branch existed.
+func deleteBranch(ctx context.Context, repo, branch string) (bool, error) {
+	// Slashes in the branch name are part of the reference path.
+	segments := strings.Split(branch, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/git/refs/heads/%s", githubAPI, repo, strings.Join(segments, "/"))
+	resp, err := githubRequest(ctx, http.MethodDelete, endpoint, nil, true)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		// GitHub returns 422 for a reference that does not exist.
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected response from %s: %s", endpoint, resp.Status)
+}
+
+// removeLabel removes label from a pull request. A label that is not on the
+// pull request is ignored.
+func removeLabel(ctx context.Context, ref *pullRequestRef, label string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d/labels/%s", githubAPI, ref.Owner, ref.Repo, ref.Number, url.PathEscape(label))
+	resp, err := githubRequest(ctx, http.MethodDelete, endpoint, nil, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unexpected response from %s: %s", endpoint, resp.Status)
+	}
+	return nil
+}
+
+// githubRequest sends a request to the GitHub API, with body encoded as JSON
+// if not nil, retrying it while GitHub rejects it with a rate limit. The
+// request is authenticated if a GitHub token is found; if requireToken is set,
+// it fails with [errMissingGitHubToken] otherwise.
+func githubRequest(ctx context.Context, method, url string, body any, requireToken bool) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, _, err := githubToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if requireToken {
+		return nil, fmt.Errorf("%w for %s %s", errMissingGitHubToken, method, url)
+	}
+	return fetch.DoWithRateLimitRetry(http.DefaultClient, req)
+}
+
+// validateReleasePullRequest checks that pr is a merged release pull request
+// which has not yet been tagged.
+func validateReleasePullRequest(pr *pullRequest) error {
+	if !pr.Merged {
+		return fmt.Errorf("%w: #%d", errPullRequestNotMerged, pr.Number)
+	}
+	if !slices.Contains(pr.Labels, releasePendingLabel) {
+		return fmt.Errorf("%w: #%d does not have the %q label", errPullRequestNotReleased, pr.Number, releasePendingLabel)
+	}
+	return nil
+}
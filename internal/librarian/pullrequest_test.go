@@ -0,0 +1,192 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePullRequestURL(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		githubAPI string
+		url       string
+		want      *pullRequestRef
+	}{
+		{
+			name:      "basic",
+			githubAPI: defaultGitHubAPI,
+			url:       "https://github.com/googleapis/google-cloud-go/pull/123",
+			want:      &pullRequestRef{Owner: "googleapis", Repo: "google-cloud-go", Number: 123},
+		},
+		{
+			name:      "trailing slash",
+			githubAPI: defaultGitHubAPI,
+			url:       "https://github.com/googleapis/google-cloud-go/pull/123/",
+			want:      &pullRequestRef{Owner: "googleapis", Repo: "google-cloud-go", Number: 123},
+		},
+		{
+			name:      "github enterprise",
+			githubAPI: "https://github.example.com/api/v3",
+			url:       "https://github.example.com/googleapis/google-cloud-go/pull/123",
+			want:      &pullRequestRef{Owner: "googleapis", Repo: "google-cloud-go", Number: 123},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			original := githubAPI
+			t.Cleanup(func() { githubAPI = original })
+			githubAPI = test.githubAPI
+			got, err := parsePullRequestURL(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParsePullRequestURL_Error(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		githubAPI string
+		url       string
+	}{
+		{name: "empty", githubAPI: defaultGitHubAPI, url: ""},
+		{name: "issue", githubAPI: defaultGitHubAPI, url: "https://github.com/googleapis/google-cloud-go/issues/123"},
+		{name: "not a number", githubAPI: defaultGitHubAPI, url: "https://github.com/googleapis/google-cloud-go/pull/abc"},
+		{name: "files tab", githubAPI: defaultGitHubAPI, url: "https://github.com/googleapis/google-cloud-go/pull/123/files"},
+		{name: "http", githubAPI: defaultGitHubAPI, url: "http://github.com/googleapis/google-cloud-go/pull/123"},
+		{name: "other host", githubAPI: defaultGitHubAPI, url: "https://github.example.com/googleapis/google-cloud-go/pull/123"},
+		{name: "github.com with enterprise", githubAPI: "https://github.example.com/api/v3", url: "https://github.com/googleapis/google-cloud-go/pull/123"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			original := githubAPI
+			t.Cleanup(func() { githubAPI = original })
+			githubAPI = test.githubAPI
+			if _, err := parsePullRequestURL(test.url); !errors.Is(err, errInvalidPullRequestURL) {
+				t.Errorf("parsePullRequestURL(%q) error = %v, want %v", test.url, err, errInvalidPullRequestURL)
+			}
+		})
+	}
+}
+
+func setupPullRequestServer(t *testing.T, body string) {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/googleapis/google-cloud-go/pulls/123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	original := githubAPI
+	t.Cleanup(func() { githubAPI = original })
+	githubAPI = ts.URL
+	t.Setenv(envGitHubToken, "test-token")
+}
+
+func TestReleasePullRequestCommit(t *testing.T) {
+	setupPullRequestServer(t, `{
+		"number": 123,
+		"merged": true,
+		"merge_commit_sha": "abc123",
+		"labels": [{"name": "autorelease"}, {"name": "release:pending"}]
+	}`)
+	got, err := releasePullRequestCommit(t.Context(), "https://127.0.0.1/googleapis/google-cloud-go/pull/123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "abc123"; got != want {
+		t.Errorf("releasePullRequestCommit() = %q, want %q", got, want)
+	}
+}
+
+func TestReleasePullRequestCommit_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		url     string
+		body    string
+		wantErr error
+	}{
+		{
+			name:    "invalid url",
+			url:     "not-a-url",
+			wantErr: errInvalidPullRequestURL,
+		},
+		{
+			name:    "not found",
+			url:     "https://127.0.0.1/googleapis/google-cloud-go/pull/456",
+			wantErr: errPullRequestNotFound,
+		},
+		{
+			name:    "not merged",
+			url:     "https://127.0.0.1/googleapis/google-cloud-go/pull/123",
+			body:    `{"number": 123, "merged": false, "labels": [{"name": "release:pending"}]}`,
+			wantErr: errPullRequestNotMerged,
+		},
+		{
+			name:    "missing label",
+			url:     "https://127.0.0.1/googleapis/google-cloud-go/pull/123",
+			body:    `{"number": 123, "merged": true, "merge_commit_sha": "abc123", "labels": [{"name": "release:tagged"}]}`,
+			wantErr: errPullRequestNotReleased,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			setupPullRequestServer(t, test.body)
+			_, err := releasePullRequestCommit(t.Context(), test.url)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("releasePullRequestCommit() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitHubRequest_RateLimit(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		bodies = append(bodies, string(data))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv(envGitHubToken, "test-token")
+	resp, err := githubRequest(t.Context(), http.MethodPatch, ts.URL, map[string]string{"state": "closed"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	want := []string{`{"state":"closed"}`, `{"state":"closed"}`}
+	if diff := cmp.Diff(want, bodies); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -60,6 +60,15 @@ func fillPythonPreview(stable, preview *config.Library) *config.Library {
 		}
 		p.OptArgsByAPI = filtered
 	}
+	if len(p.TransportByAPI) == 0 && len(s.TransportByAPI) > 0 {
+		filtered := make(map[string]string)
+		for _, api := range preview.APIs {
+			if transport, ok := s.TransportByAPI[api.Path]; ok {
+				filtered[api.Path] = transport
+			}
+		}
+		p.TransportByAPI = filtered
+	}
 
 	return preview
 }
@@ -111,6 +111,47 @@ func TestFill(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "preview library filters TransportByAPI",
+			library: &config.Library{
+				Name:   "google-cloud-secret-manager",
+				Output: "packages/google-cloud-secret-manager",
+				Python: &config.PythonPackage{
+					TransportByAPI: map[string]string{
+						"google/cloud/secretmanager/v1": "rest",
+						"google/cloud/secretmanager/v2": "grpc",
+					},
+				},
+				Preview: &config.Library{
+					Name: "google-cloud-secret-manager-preview",
+					APIs: []*config.API{
+						{Path: "google/cloud/secretmanager/v1"},
+					},
+				},
+			},
+			want: &config.Library{
+				Name:   "google-cloud-secret-manager",
+				Output: "packages/google-cloud-secret-manager",
+				Python: &config.PythonPackage{
+					TransportByAPI: map[string]string{
+						"google/cloud/secretmanager/v1": "rest",
+						"google/cloud/secretmanager/v2": "grpc",
+					},
+				},
+				Preview: &config.Library{
+					Name:   "google-cloud-secret-manager-preview",
+					Output: "preview-packages/google-cloud-secret-manager",
+					APIs: []*config.API{
+						{Path: "google/cloud/secretmanager/v1"},
+					},
+					Python: &config.PythonPackage{
+						TransportByAPI: map[string]string{
+							"google/cloud/secretmanager/v1": "rest",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "preview library merges Python config",
 			library: &config.Library{
@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/googleapis/librarian/internal/repometadata"
 	"github.com/googleapis/librarian/internal/serviceconfig"
 	"github.com/googleapis/librarian/internal/sources"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -54,8 +57,10 @@ const (
 )
 
 var (
-	errNoDefaultVersion        = errors.New("default version must be specified for every library with generated APIs")
-	errExplicitTransportOption = errors.New("transport option is derived from sdk.yaml and must not be specified explicitly")
+	errNoDefaultVersion         = errors.New("default version must be specified for every library with generated APIs")
+	errExplicitTransportOption  = errors.New("transport option is derived from sdk.yaml and must not be specified explicitly; use transport_by_api to override it")
+	errInvalidTransport         = errors.New("invalid transport in transport_by_api")
+	errMissingPostProcessorTool = errors.New("post-processor requires a missing tool")
 )
 
 // Generate generates a Python client library.
@@ -293,19 +298,33 @@ func generateAPI(ctx context.Context, api *config.API, library *config.Library,
 	return nil
 }
 
+// stageProtoFiles copies the proto files at relativeProtoPaths from
+// googleapisDir to the same relative paths in targetDir. Files are copied in
+// parallel, and a path listed more than once is only copied once.
 func stageProtoFiles(googleapisDir, targetDir string, relativeProtoPaths []string) error {
+	var g errgroup.Group
+	g.SetLimit(runtime.NumCPU())
+	staged := map[string]bool{}
 	for _, proto := range relativeProtoPaths {
-		sourceProtoFile := filepath.Join(googleapisDir, proto)
-		targetProtoFile := filepath.Join(targetDir, proto)
-		dir := filepath.Dir(targetProtoFile)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return fmt.Errorf("creating directory %s failed: %w", dir, err)
-		}
-		if err := filesystem.CopyFile(sourceProtoFile, targetProtoFile); err != nil {
-			return fmt.Errorf("copying proto file %s failed: %w", sourceProtoFile, err)
+		proto = filepath.Clean(proto)
+		if staged[proto] {
+			continue
 		}
+		staged[proto] = true
+		g.Go(func() error {
+			sourceProtoFile := filepath.Join(googleapisDir, proto)
+			targetProtoFile := filepath.Join(targetDir, proto)
+			dir := filepath.Dir(targetProtoFile)
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("creating directory %s failed: %w", dir, err)
+			}
+			if err := filesystem.CopyFile(sourceProtoFile, targetProtoFile); err != nil {
+				return fmt.Errorf("copying proto file %s failed: %w", sourceProtoFile, err)
+			}
+			return nil
+		})
 	}
-	return nil
+	return g.Wait()
 }
 
 func createProtocOptions(api *config.API, library *config.Library, googleapisDir, stagingDir string) ([]string, error) {
@@ -337,9 +356,9 @@ func createProtocOptions(api *config.API, library *config.Library, googleapisDir
 	if _, explicitTransport := findOption(opts, transportOption); explicitTransport {
 		return nil, fmt.Errorf("error creating GAPIC options for %s: %w", api.Path, errExplicitTransportOption)
 	}
-	transport := serviceconfig.GRPCRest
-	if apiMetadata != nil {
-		transport = apiMetadata.Transport(config.LanguagePython)
+	transport, err := apiTransport(api, library, apiMetadata)
+	if err != nil {
+		return nil, err
 	}
 	opts = append(opts, fmt.Sprintf("%s=%s", transportOption, transport))
 
@@ -380,6 +399,26 @@ func createProtocOptions(api *config.API, library *config.Library, googleapisDir
 	}, nil
 }
 
+// apiTransport returns the transport to generate for api: the one in the
+// library's transport_by_api if set, otherwise the one for Python in
+// apiMetadata, which defaults to grpc+rest.
+func apiTransport(api *config.API, library *config.Library, apiMetadata *serviceconfig.API) (serviceconfig.Transport, error) {
+	if library.Python != nil {
+		if override, ok := library.Python.TransportByAPI[api.Path]; ok {
+			switch transport := serviceconfig.Transport(override); transport {
+			case serviceconfig.GRPC, serviceconfig.Rest, serviceconfig.GRPCRest:
+				return transport, nil
+			default:
+				return "", fmt.Errorf("%w for %s: %q", errInvalidTransport, api.Path, override)
+			}
+		}
+	}
+	if apiMetadata == nil {
+		return serviceconfig.GRPCRest, nil
+	}
+	return apiMetadata.Transport(config.LanguagePython), nil
+}
+
 func isProtoOnly(api *config.API, library *config.Library) bool {
 	return library.Python != nil && slices.Contains(library.Python.ProtoOnlyAPIs, api.Path)
 }
@@ -398,6 +437,8 @@ func getStagingChildDirectory(apiPath string, isProtoOnly bool) string {
 }
 
 // runPostProcessor runs the synthtool post processor on the output directory.
+// The tools it needs are checked by [CheckPostProcessorTools], once per run
+// rather than once per library.
 func runPostProcessor(ctx context.Context, repoRoot, outDir, generationRoot string) error {
 	// The post-processor expects the string replacement scripts to be in the
 	// output directory, so we need to copy them there.
@@ -429,6 +470,23 @@ python_mono_repo.owlbot_main(%q)
 	return nil
 }
 
+// CheckPostProcessorTools reports which of the tools needed to post-process
+// generated libraries is missing, and how to install it, so that a missing
+// tool is not reported as an obscure failure half way through
+// post-processing. It should be called before generating libraries with APIs.
+func CheckPostProcessorTools(ctx context.Context) error {
+	if _, err := exec.LookPath("python3"); err != nil {
+		return fmt.Errorf("%w: python3 is not installed or not in PATH; install Python 3 from https://www.python.org/downloads/", errMissingPostProcessorTool)
+	}
+	if _, err := exec.LookPath("nox"); err != nil {
+		return fmt.Errorf("%w: nox is not installed or not in PATH; install it with \"librarian install python\"", errMissingPostProcessorTool)
+	}
+	if err := command.Run(ctx, "python3", "-c", "import synthtool"); err != nil {
+		return fmt.Errorf("%w: Python module synthtool cannot be imported by python3; install it with \"librarian install python\": %w", errMissingPostProcessorTool, err)
+	}
+	return nil
+}
+
 // copyReadmeToDocsDir copies README.rst to docs/README.rst.
 // This handles symlinks properly by reading content and writing a real file.
 // This is a no-op if either the source doesn't exist, or the library is
@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"testing"
@@ -218,6 +219,61 @@ func TestCreateProtocOptions(t *testing.T) {
 	}
 }
 
+func TestCreateProtocOptions_Transport(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		name           string
+		apiPath        string
+		transportByAPI map[string]string
+		want           string
+	}{
+		{
+			name:    "default",
+			apiPath: "google/cloud/secretmanager/v1",
+			want:    "grpc+rest",
+		},
+		{
+			name:    "from API list",
+			apiPath: "google/cloud/apigeeconnect/v1",
+			want:    "grpc",
+		},
+		{
+			name:           "override default",
+			apiPath:        "google/cloud/secretmanager/v1",
+			transportByAPI: map[string]string{"google/cloud/secretmanager/v1": "rest"},
+			want:           "rest",
+		},
+		{
+			name:           "override API list",
+			apiPath:        "google/cloud/apigeeconnect/v1",
+			transportByAPI: map[string]string{"google/cloud/apigeeconnect/v1": "grpc+rest"},
+			want:           "grpc+rest",
+		},
+		{
+			name:           "override for another API",
+			apiPath:        "google/cloud/apigeeconnect/v1",
+			transportByAPI: map[string]string{"google/cloud/secretmanager/v1": "rest"},
+			want:           "grpc",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			library := &config.Library{
+				Name:   "google-cloud-test",
+				Python: &config.PythonPackage{TransportByAPI: test.transportByAPI},
+			}
+			got, err := createProtocOptions(&config.API{Path: test.apiPath}, library, googleapisDir, "staging")
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := strings.Split(strings.TrimPrefix(got[1], "--python_gapic_opt="), ",")
+			transport, _ := findOption(opts, transportOption)
+			if diff := cmp.Diff(test.want, transport); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestCreateProtocOptions_Error(t *testing.T) {
 	t.Parallel()
 	for _, test := range []struct {
@@ -239,6 +295,35 @@ func TestCreateProtocOptions_Error(t *testing.T) {
 			},
 			wantErr: errExplicitTransportOption,
 		},
+		{
+			name: "transport in OptArgsByAPI with TransportByAPI",
+			api:  &config.API{Path: "google/cloud/secretmanager/v1"},
+			library: &config.Library{
+				Name: "google-cloud-secret-manager",
+				Python: &config.PythonPackage{
+					OptArgsByAPI: map[string][]string{
+						"google/cloud/secretmanager/v1": {"transport=rest"},
+					},
+					TransportByAPI: map[string]string{
+						"google/cloud/secretmanager/v1": "rest",
+					},
+				},
+			},
+			wantErr: errExplicitTransportOption,
+		},
+		{
+			name: "invalid transport in TransportByAPI",
+			api:  &config.API{Path: "google/cloud/secretmanager/v1"},
+			library: &config.Library{
+				Name: "google-cloud-secret-manager",
+				Python: &config.PythonPackage{
+					TransportByAPI: map[string]string{
+						"google/cloud/secretmanager/v1": "http",
+					},
+				},
+			},
+			wantErr: errInvalidTransport,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			_, gotErr := createProtocOptions(test.api, test.library, googleapisDir, "staging")
@@ -256,7 +341,9 @@ func TestStageProtoFiles(t *testing.T) {
 		"google/cloud/gkehub/v1/feature.proto",
 		"google/cloud/gkehub/v1/membership.proto",
 	}
-	if err := stageProtoFiles(googleapisDir, targetDir, relativeProtoPaths); err != nil {
+	// Files listed more than once are only staged once.
+	duplicated := append(slices.Clone(relativeProtoPaths), "google/cloud/gkehub/v1/feature.proto", "./google/cloud/gkehub/v1/membership.proto")
+	if err := stageProtoFiles(googleapisDir, targetDir, duplicated); err != nil {
 		t.Fatal(err)
 	}
 	copiedFiles := []string{}
@@ -287,11 +374,13 @@ func TestStageProtoFiles_Error(t *testing.T) {
 		relativeProtoPaths []string
 		setup              func(t *testing.T, targetDir string)
 		wantErr            error
+		wantPath           string
 	}{
 		{
 			name:               "path doesn't exist",
-			relativeProtoPaths: []string{"google/cloud/bogus.proto"},
+			relativeProtoPaths: []string{"google/cloud/gkehub/v1/feature.proto", "google/cloud/bogus.proto"},
 			wantErr:            fs.ErrNotExist,
+			wantPath:           "google/cloud/bogus.proto",
 		},
 		{
 			name:               "can't create directory",
@@ -302,7 +391,8 @@ func TestStageProtoFiles_Error(t *testing.T) {
 					t.Fatal(err)
 				}
 			},
-			wantErr: syscall.ENOTDIR,
+			wantErr:  syscall.ENOTDIR,
+			wantPath: "google/cloud/gkehub/v1",
 		},
 		{
 			name:               "can't write file",
@@ -313,7 +403,8 @@ func TestStageProtoFiles_Error(t *testing.T) {
 					t.Fatal(err)
 				}
 			},
-			wantErr: syscall.EISDIR,
+			wantErr:  syscall.EISDIR,
+			wantPath: "google/cloud/gkehub/v1/feature.proto",
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -323,7 +414,10 @@ func TestStageProtoFiles_Error(t *testing.T) {
 			}
 			gotErr := stageProtoFiles(googleapisDir, targetDir, test.relativeProtoPaths)
 			if !errors.Is(gotErr, test.wantErr) {
-				t.Errorf("stageProtoFiles error = %v, wantErr %v", gotErr, test.wantErr)
+				t.Fatalf("stageProtoFiles error = %v, wantErr %v", gotErr, test.wantErr)
+			}
+			if !strings.Contains(gotErr.Error(), test.wantPath) {
+				t.Errorf("stageProtoFiles error = %v, want it to name %s", gotErr, test.wantPath)
 			}
 		})
 	}
@@ -684,6 +778,82 @@ func TestRunPostProcessor_Error(t *testing.T) {
 	}
 }
 
+func TestCheckPostProcessorTools(t *testing.T) {
+	bin := t.TempDir()
+	writeFakeCommand(t, bin, "python3", 0)
+	writeFakeCommand(t, bin, "nox", 0)
+	t.Setenv("PATH", bin)
+	if err := CheckPostProcessorTools(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckPostProcessorTools_Error(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		commands map[string]int
+		wantTool string
+	}{
+		{
+			name:     "no python3",
+			commands: map[string]int{"nox": 0},
+			wantTool: "python3",
+		},
+		{
+			name:     "no nox",
+			commands: map[string]int{"python3": 0},
+			wantTool: "nox",
+		},
+		{
+			name:     "no synthtool",
+			commands: map[string]int{"python3": 1, "nox": 0},
+			wantTool: "synthtool",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bin := t.TempDir()
+			for name, exitCode := range test.commands {
+				writeFakeCommand(t, bin, name, exitCode)
+			}
+			t.Setenv("PATH", bin)
+			err := CheckPostProcessorTools(t.Context())
+			if !errors.Is(err, errMissingPostProcessorTool) {
+				t.Fatalf("CheckPostProcessorTools() error = %v, want %v", err, errMissingPostProcessorTool)
+			}
+			if !strings.Contains(err.Error(), test.wantTool) {
+				t.Errorf("CheckPostProcessorTools() error = %v, want it to name %q", err, test.wantTool)
+			}
+		})
+	}
+}
+
+func TestCheckPostProcessorTools_ImportError(t *testing.T) {
+	bin := t.TempDir()
+	writeFakeCommand(t, bin, "nox", 0)
+	script := "#!/bin/sh\necho \"ImportError: cannot import name 'yaml'\" >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "python3"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	err := CheckPostProcessorTools(t.Context())
+	if !errors.Is(err, errMissingPostProcessorTool) {
+		t.Fatalf("CheckPostProcessorTools() error = %v, want %v", err, errMissingPostProcessorTool)
+	}
+	if !strings.Contains(err.Error(), "ImportError: cannot import name 'yaml'") {
+		t.Errorf("CheckPostProcessorTools() error = %v, want it to include the ImportError", err)
+	}
+}
+
+// writeFakeCommand writes an executable called name to dir, which exits with
+// exitCode.
+func writeFakeCommand(t *testing.T, dir, name string, exitCode int) {
+	t.Helper()
+	script := fmt.Sprintf("#!/bin/sh\nexit %d\n", exitCode)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGenerateAPI(t *testing.T) {
 	t.Parallel()
 	if testing.Short() {
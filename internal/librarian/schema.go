@@ -0,0 +1,152 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/urfave/cli/v3"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the generated schema.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema used to describe librarian.yaml.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+func schemaCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "schema",
+		Usage:     "print the JSON Schema for librarian.yaml",
+		UsageText: "librarian schema",
+		Description: `schema prints a JSON Schema document describing librarian.yaml, which
+editors can use to validate and autocomplete the file.
+
+The schema is derived from the yaml struct tags of the librarian
+configuration types. Fields without omitempty are always written by
+librarian, and are therefore listed as required.
+
+Examples:
+
+	librarian schema > librarian.schema.json`,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return writeConfigSchema(cmd.Root().Writer)
+		},
+	}
+}
+
+// writeConfigSchema writes the JSON Schema for [config.Config] to w.
+func writeConfigSchema(w io.Writer) error {
+	b, err := json.MarshalIndent(configSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// configSchema returns the JSON Schema for [config.Config]. Each struct type
+// is described once in $defs and referenced wherever it is used.
+func configSchema() *jsonSchema {
+	defs := map[string]*jsonSchema{}
+	root := typeSchema(reflect.TypeFor[config.Config](), defs)
+	root.Schema = jsonSchemaDialect
+	root.Title = config.LibrarianYAML
+	root.Defs = defs
+	return root
+}
+
+// typeSchema returns the schema for t, adding any struct types it uses to
+// defs.
+func typeSchema(t reflect.Type, defs map[string]*jsonSchema) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			// Reserve the name before describing the fields, as types such as
+			// Library refer to themselves.
+			defs[t.Name()] = nil
+			s := &jsonSchema{
+				Type:                 "object",
+				Properties:           map[string]*jsonSchema{},
+				AdditionalProperties: false,
+			}
+			addFields(s, t, defs)
+			defs[t.Name()] = s
+		}
+		return &jsonSchema{Ref: "#/$defs/" + t.Name()}
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// addFields adds the properties of struct type t to s, following the rules
+// used by the yaml package: fields are named by their yaml tag, or by their
+// lowercased Go name if untagged, and inline fields are flattened into s.
+func addFields(s *jsonSchema, t reflect.Type, defs map[string]*jsonSchema) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		options := strings.Split(opts, ",")
+		if slices.Contains(options, "inline") {
+			addFields(s, field.Type, defs)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		s.Properties[name] = typeSchema(field.Type, defs)
+		if !slices.Contains(options, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestSchemaCommand(t *testing.T) {
+	var got bytes.Buffer
+	if err := writeConfigSchema(&got); err != nil {
+		t.Fatal(err)
+	}
+	goldenPath := filepath.Join("testdata", "schema.json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenPath, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), got.String()); diff != "" {
+		t.Errorf("schema changed, run go test -update to accept (-want +got):\n%s", diff)
+	}
+}
+
+type schemaTestInline struct {
+	Shared string `yaml:"shared,omitempty"`
+}
+
+type schemaTestStruct struct {
+	schemaTestInline `yaml:",inline"`
+
+	Name     string              `yaml:"name"`
+	Count    int                 `yaml:"count,omitempty"`
+	Enabled  *bool               `yaml:"enabled,omitempty"`
+	Tags     []string            `yaml:"tags,omitempty"`
+	Labels   map[string]string   `yaml:"labels,omitempty"`
+	Children []*schemaTestStruct `yaml:"children,omitempty"`
+	Ignored  string              `yaml:"-"`
+	Untagged float64
+}
+
+func TestTypeSchema(t *testing.T) {
+	defs := map[string]*jsonSchema{}
+	got := typeSchema(reflect.TypeFor[schemaTestStruct](), defs)
+	if diff := cmp.Diff(&jsonSchema{Ref: "#/$defs/schemaTestStruct"}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	want := map[string]*jsonSchema{
+		"schemaTestStruct": {
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"shared":  {Type: "string"},
+				"name":    {Type: "string"},
+				"count":   {Type: "integer"},
+				"enabled": {Type: "boolean"},
+				"tags":    {Type: "array", Items: &jsonSchema{Type: "string"}},
+				"labels":  {Type: "object", AdditionalProperties: &jsonSchema{Type: "string"}},
+				"children": {
+					Type:  "array",
+					Items: &jsonSchema{Ref: "#/$defs/schemaTestStruct"},
+				},
+				"untagged": {Type: "number"},
+			},
+			Required:             []string{"name", "untagged"},
+			AdditionalProperties: false,
+		},
+	}
+	if diff := cmp.Diff(want, defs); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/yaml"
+)
+
+// currentSchemaVersion is the version of the librarian.yaml schema
+// understood by this version of librarian.
+const currentSchemaVersion = 1
+
+var (
+	errInvalidSchemaVersion = errors.New("schema_version must be a positive integer")
+	errMissingSchemaUpgrade = errors.New("no upgrade registered for schema_version")
+	errSchemaVersionTooNew  = errors.New("schema_version is newer than supported; upgrade librarian")
+)
+
+// schemaUpgrade upgrades a decoded librarian.yaml document in place from one
+// schema version to the next. It works on the document rather than on
+// [config.Config], so that it can handle fields that were renamed or changed
+// type.
+type schemaUpgrade func(doc map[string]any) error
+
+// schemaUpgrades holds the upgrade from each schema version to the next,
+// keyed by the version it upgrades from. Changing the shape of
+// [config.Config] requires incrementing currentSchemaVersion and registering
+// an upgrade here.
+var schemaUpgrades = map[int]schemaUpgrade{}
+
+// readConfig reads the librarian configuration at path, upgrading it to the
+// current schema version and expanding the environment variables referenced
+// by its interpolated fields. Configurations that are written back must be
+// written with writeConfig, to keep those references.
+func readConfig(path string) (*config.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := expandEnv(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parseConfig parses a librarian configuration, upgrading it to the current
+// schema version.
+func parseConfig(data []byte) (*config.Config, error) {
+	doc, err := yaml.Unmarshal[map[string]any](data)
+	if err != nil {
+		return nil, err
+	}
+	if *doc == nil {
+		return yaml.Unmarshal[config.Config](data)
+	}
+	upgraded, err := upgradeSchema(*doc, currentSchemaVersion, schemaUpgrades)
+	if err != nil {
+		return nil, err
+	}
+	if upgraded {
+		if data, err = yaml.Marshal(*doc); err != nil {
+			return nil, err
+		}
+	}
+	return yaml.Unmarshal[config.Config](data)
+}
+
+// upgradeSchema applies upgrades to doc until it is at the current schema
+// version. It reports whether doc was changed.
+func upgradeSchema(doc map[string]any, current int, upgrades map[int]schemaUpgrade) (bool, error) {
+	version := 1
+	if value, ok := doc["schema_version"]; ok {
+		n, ok := value.(int)
+		if !ok || n < 1 {
+			return false, fmt.Errorf("%w: %v", errInvalidSchemaVersion, value)
+		}
+		version = n
+	}
+	if version > current {
+		return false, fmt.Errorf("%w: got %d, this version of librarian supports up to %d", errSchemaVersionTooNew, version, current)
+	}
+	if version == current {
+		return false, nil
+	}
+	for ; version < current; version++ {
+		upgrade, ok := upgrades[version]
+		if !ok {
+			return false, fmt.Errorf("%w: %d", errMissingSchemaUpgrade, version)
+		}
+		if err := upgrade(doc); err != nil {
+			return false, fmt.Errorf("failed to upgrade from schema_version %d: %w", version, err)
+		}
+	}
+	doc["schema_version"] = current
+	return true, nil
+}
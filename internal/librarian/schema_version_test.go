@@ -0,0 +1,178 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// testSchemaUpgrades rename a top-level "lang" field to "language" in version
+// 1, and set a default version in version 2.
+var testSchemaUpgrades = map[int]schemaUpgrade{
+	1: func(doc map[string]any) error {
+		doc["language"] = doc["lang"]
+		delete(doc, "lang")
+		return nil
+	},
+	2: func(doc map[string]any) error {
+		if _, ok := doc["version"]; !ok {
+			doc["version"] = "v0.1.0"
+		}
+		return nil
+	},
+}
+
+func TestUpgradeSchema(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		doc          map[string]any
+		want         map[string]any
+		wantUpgraded bool
+	}{
+		{
+			name:         "missing version",
+			doc:          map[string]any{"lang": "go"},
+			want:         map[string]any{"language": "go", "version": "v0.1.0", "schema_version": 3},
+			wantUpgraded: true,
+		},
+		{
+			name:         "intermediate version",
+			doc:          map[string]any{"schema_version": 2, "language": "go", "version": "v1.0.0"},
+			want:         map[string]any{"schema_version": 3, "language": "go", "version": "v1.0.0"},
+			wantUpgraded: true,
+		},
+		{
+			name: "current version",
+			doc:  map[string]any{"schema_version": 3, "language": "go"},
+			want: map[string]any{"schema_version": 3, "language": "go"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			upgraded, err := upgradeSchema(test.doc, 3, testSchemaUpgrades)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if upgraded != test.wantUpgraded {
+				t.Errorf("upgradeSchema() = %t, want %t", upgraded, test.wantUpgraded)
+			}
+			if diff := cmp.Diff(test.want, test.doc); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpgradeSchema_Error(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		doc      map[string]any
+		upgrades map[int]schemaUpgrade
+		wantErr  error
+	}{
+		{
+			name:     "newer version",
+			doc:      map[string]any{"schema_version": 4},
+			upgrades: testSchemaUpgrades,
+			wantErr:  errSchemaVersionTooNew,
+		},
+		{
+			name:     "not a number",
+			doc:      map[string]any{"schema_version": "two"},
+			upgrades: testSchemaUpgrades,
+			wantErr:  errInvalidSchemaVersion,
+		},
+		{
+			name:     "zero",
+			doc:      map[string]any{"schema_version": 0},
+			upgrades: testSchemaUpgrades,
+			wantErr:  errInvalidSchemaVersion,
+		},
+		{
+			name:     "missing upgrade",
+			doc:      map[string]any{"language": "go"},
+			upgrades: map[int]schemaUpgrade{1: testSchemaUpgrades[1]},
+			wantErr:  errMissingSchemaUpgrade,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := upgradeSchema(test.doc, 3, test.upgrades); !errors.Is(err, test.wantErr) {
+				t.Errorf("upgradeSchema() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data string
+		want *config.Config
+	}{
+		{
+			name: "missing version",
+			data: "language: go\n",
+			want: &config.Config{Language: "go"},
+		},
+		{
+			name: "current version",
+			data: "language: go\nschema_version: 1\n",
+			want: &config.Config{Language: "go", SchemaVersion: 1},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: &config.Config{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseConfig([]byte(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name:    "newer version",
+			data:    "language: go\nschema_version: 2\n",
+			wantErr: errSchemaVersionTooNew,
+		},
+		{
+			name:    "invalid version",
+			data:    "language: go\nschema_version: latest\n",
+			wantErr: errInvalidSchemaVersion,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseConfig([]byte(test.data)); !errors.Is(err, test.wantErr) {
+				t.Errorf("parseConfig() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/filesystem"
+)
+
+// outputSnapshot is a copy of the output directories of libraries, taken
+// before they are cleaned and generated so that a failed run can be rolled
+// back instead of leaving half-cleaned libraries behind.
+type outputSnapshot struct {
+	// dir is the temporary directory holding the copies.
+	dir string
+	// outputs lists the snapshotted output directories. The copy of
+	// outputs[i] is in dir/i, which does not exist if outputs[i] did not.
+	outputs []string
+}
+
+// snapshotOutputs copies the output directories of libraries to a temporary
+// directory. Output directories nested in another one are covered by the
+// outer copy. An output directory of "." is not snapshotted, as it is the
+// whole repository.
+func snapshotOutputs(libraries []*config.Library) (*outputSnapshot, error) {
+	var outputs []string
+	for _, library := range libraries {
+		output := filepath.Clean(library.Output)
+		if output == "." {
+			slog.Warn("not snapshotting library output at the repository root", "library", library.Name)
+			continue
+		}
+		outputs = append(outputs, output)
+	}
+	slices.Sort(outputs)
+	outputs = slices.Compact(outputs)
+	outputs = slices.DeleteFunc(outputs, func(output string) bool {
+		return slices.ContainsFunc(outputs, func(outer string) bool {
+			return isInDir(outer, output)
+		})
+	})
+	dir, err := os.MkdirTemp("", "librarian-snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	s := &outputSnapshot{dir: dir, outputs: outputs}
+	for i, output := range outputs {
+		err := filesystem.CopyDir(output, s.copyDir(i))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.Join(fmt.Errorf("snapshot %s: %w", output, err), s.remove())
+		}
+	}
+	return s, nil
+}
+
+// restore returns each output directory to its snapshotted state. Files that
+// were created since the snapshot are removed, and the snapshotted files are
+// copied back; unchanged files are not rewritten.
+//
+// Output directories that contain the output of a library in completed are
+// left as they are, so that completed libraries are kept. A nil snapshot
+// restores nothing.
+func (s *outputSnapshot) restore(completed []*config.Library) error {
+	if s == nil {
+		return nil
+	}
+	var errs []error
+	for i, output := range s.outputs {
+		if slices.ContainsFunc(completed, func(library *config.Library) bool {
+			done := filepath.Clean(library.Output)
+			return done == output || isInDir(output, done)
+		}) {
+			continue
+		}
+		if err := restoreOutput(output, s.copyDir(i)); err != nil {
+			errs = append(errs, fmt.Errorf("restore %s: %w", output, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remove deletes the snapshot.
+func (s *outputSnapshot) remove() error {
+	return os.RemoveAll(s.dir)
+}
+
+// savedOutput returns the path of the snapshot copy of output, which may be
+// nested in the copy of an outer output directory. It reports false if output
+// was not snapshotted or did not exist when the snapshot was taken, or if s
+// is nil.
+func (s *outputSnapshot) savedOutput(output string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	output = filepath.Clean(output)
+	for i, outer := range s.outputs {
+		if outer != output && !isInDir(outer, output) {
+			continue
+		}
+		rel, err := filepath.Rel(outer, output)
+		if err != nil {
+			return "", false
+		}
+		saved := filepath.Join(s.copyDir(i), rel)
+		if _, err := os.Stat(saved); err != nil {
+			return "", false
+		}
+		return saved, true
+	}
+	return "", false
+}
+
+func (s *outputSnapshot) copyDir(i int) string {
+	return filepath.Join(s.dir, fmt.Sprint(i))
+}
+
+// restoreOutput makes output match the snapshot copy in saved, which does not
+// exist if output did not exist when the snapshot was taken.
+func restoreOutput(output, saved string) error {
+	if _, err := os.Stat(saved); errors.Is(err, fs.ErrNotExist) {
+		return os.RemoveAll(output)
+	}
+	err := filepath.WalkDir(output, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(output, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(saved, rel)); errors.Is(err, fs.ErrNotExist) {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return filesystem.CopyDir(saved, output)
+}
+
+// isInDir reports whether path is strictly inside dir.
+func isInDir(dir, path string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestOutputSnapshot(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, map[string]string{
+		"lib1/a.txt":        "a",
+		"lib1/nested/b.txt": "b",
+		"lib2/c.txt":        "c",
+	})
+	libraries := []*config.Library{
+		{Name: "lib1", Output: "lib1"},
+		{Name: "nested", Output: "lib1/nested"},
+		{Name: "lib2", Output: "lib2"},
+		{Name: "new", Output: "new"},
+	}
+	snapshot, err := snapshotOutputs(libraries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.remove()
+	if diff := cmp.Diff([]string{"lib1", "lib2", "new"}, snapshot.outputs); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Simulate a partial clean and generation.
+	for _, name := range []string{"lib1/a.txt", "lib1/nested/b.txt"} {
+		if err := os.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFiles(t, map[string]string{
+		"lib2/c.txt":     "changed",
+		"lib2/stray.txt": "stray",
+		"new/d.txt":      "d",
+	})
+
+	if err := snapshot.restore(nil); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"lib1/a.txt":        "a",
+		"lib1/nested/b.txt": "b",
+		"lib2/c.txt":        "c",
+	} {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"lib2/stray.txt", "new"} {
+		if _, err := os.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s should have been removed, got error %v", name, err)
+		}
+	}
+
+	if err := snapshot.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(snapshot.dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("snapshot directory should have been removed, got error %v", err)
+	}
+}
+
+func TestSnapshotOutputs_SkipsRoot(t *testing.T) {
+	t.Chdir(t.TempDir())
+	snapshot, err := snapshotOutputs([]*config.Library{{Name: "root", Output: "."}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.remove()
+	if len(snapshot.outputs) != 0 {
+		t.Errorf("outputs = %q, want none", snapshot.outputs)
+	}
+}
+
+func TestGenerate_RollbackOnFailure(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	// The fake generator rewrites README.md and leaves VERSION, which the
+	// allowlist does not cover, so generation fails after cleaning.
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    output_allowlist:
+      - README.md
+    apis:
+      - path: google/cloud/speech/v1
+`
+	writeFiles(t, map[string]string{
+		config.LibrarianYAML: configContent,
+		"speech/README.md":   "original",
+		"speech/VERSION":     "1.0.0",
+	})
+	err := Run(t.Context(), "librarian", "generate", "--api-source", googleapisDir, "speech")
+	if !errors.Is(err, errFileNotAllowed) {
+		t.Fatalf("want error %v, got %v", errFileNotAllowed, err)
+	}
+	got, err := os.ReadFile("speech/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("original", string(got)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerate_NoRollback(t *testing.T) {
+	googleapisDir := createGoogleapisServiceConfigs(t, t.TempDir(), map[string]string{
+		"google/cloud/speech/v1": "speech_v1.yaml",
+	})
+	t.Chdir(t.TempDir())
+	configContent := `language: fake
+libraries:
+  - name: speech
+    output: speech
+    output_allowlist:
+      - README.md
+    apis:
+      - path: google/cloud/speech/v1
+`
+	writeFiles(t, map[string]string{
+		config.LibrarianYAML: configContent,
+		"speech/README.md":   "original",
+		"speech/VERSION":     "1.0.0",
+	})
+	err := Run(t.Context(), "librarian", "generate", "--no-rollback", "--api-source", googleapisDir, "speech")
+	if !errors.Is(err, errFileNotAllowed) {
+		t.Fatalf("want error %v, got %v", errFileNotAllowed, err)
+	}
+	got, err := os.ReadFile("speech/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# speech\n\nGenerated library\n\n---\nFormatted\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -36,6 +36,8 @@ const (
 // ErrMissingGoogleapisSource is returned when the googleapis source is missing.
 var ErrMissingGoogleapisSource = errors.New("must specify googleapis source")
 
+var errMissingSourceSHA256 = errors.New("source commit must be pinned with sha256")
+
 // LoadSources fetches all source repositories needed for generation in parallel.
 // It returns a *sources.Sources struct with all directories populated.
 func LoadSources(ctx context.Context, src *config.Sources) (*sources.Sources, error) {
@@ -107,6 +109,11 @@ func fetchSource(ctx context.Context, source *config.Source, repo string) (strin
 		}
 		return absDir, nil
 	}
+	// Require the checksum even if the source is already cached, so that
+	// every source is verified the same way when it is downloaded.
+	if source.SHA256 == "" {
+		return "", fmt.Errorf("%w: %s@%s", errMissingSourceSHA256, repo, source.Commit)
+	}
 	dir, err := fetch.Repo(ctx, repo, source.Commit, source.SHA256)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch %s: %w", repo, err)
@@ -58,6 +58,14 @@ func TestLoadSources(t *testing.T) {
 			src:     &config.Sources{},
 			wantErr: ErrMissingGoogleapisSource,
 		},
+		{
+			name: "commit without sha256",
+			src: &config.Sources{
+				Googleapis: &config.Source{Dir: "/tmp/googleapis"},
+				Showcase:   &config.Source{Commit: "abc123"},
+			},
+			wantErr: errMissingSourceSHA256,
+		},
 		{
 			name: "googleapis dir set",
 			src: &config.Sources{
@@ -0,0 +1,160 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
+	"github.com/googleapis/librarian/internal/semver"
+	"github.com/urfave/cli/v3"
+)
+
+func statusCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "status",
+		Hidden:    true,
+		Usage:     "report which libraries have unreleased changes",
+		UsageText: "librarian status [<library>...]",
+		Description: `status reports, for each library, whether it has changes since its last
+release and the version librarian bump would assign to it. It does not
+modify any files.
+
+If library names are given, only those libraries are reported. Otherwise
+every library with a version that is not configured with skip_release is
+reported.
+
+Examples:
+
+	librarian status                  # report all libraries
+	librarian status <library>        # report one library`,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := readConfig(cmd.String("config-file"))
+			if err != nil {
+				return err
+			}
+			statuses, err := findReleaseStatus(ctx, cfg, cmd.Args().Slice())
+			if err != nil {
+				return err
+			}
+			return printReleaseStatus(cmd.Root().Writer, statuses)
+		},
+	}
+}
+
+// releaseStatus describes the unreleased changes of a library.
+type releaseStatus struct {
+	// name is the library name.
+	name string
+	// version is the current version of the library.
+	version string
+	// nextVersion is the version bump would assign to the library. It is
+	// empty if the library has no unreleased changes.
+	nextVersion string
+	// changeLevel is the change level of the unreleased commits.
+	changeLevel semver.ChangeLevel
+	// commits is the number of unreleased commits affecting the library.
+	commits int
+}
+
+// findReleaseStatus returns the release status of the libraries with the
+// given names, or of all releasable libraries if libraryNames is empty. It
+// uses the same tags and versioning rules as the bump command.
+func findReleaseStatus(ctx context.Context, cfg *config.Config, libraryNames []string) ([]*releaseStatus, error) {
+	if err := validateTagFormat(cfg); err != nil {
+		return nil, err
+	}
+	var libraries []*config.Library
+	if len(libraryNames) > 0 {
+		var err error
+		if libraries, err = findLibraries(cfg, libraryNames); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, lib := range cfg.Libraries {
+			if !lib.SkipRelease && lib.Version != "" {
+				libraries = append(libraries, lib)
+			}
+		}
+	}
+	// Rust uses a single tag for the latest release of all libraries. See
+	// legacyRustBump.
+	var legacyTag string
+	if cfg.Language == config.LanguageRust {
+		var err error
+		if legacyTag, err = git.GetLastTag(ctx, command.Git, config.RemoteUpstream, config.BranchMain); err != nil {
+			return nil, err
+		}
+	}
+	opts := languageVersioningOptions[cfg.Language]
+	var statuses []*releaseStatus
+	for _, lib := range libraries {
+		status := &releaseStatus{name: lib.Name, version: lib.Version}
+		statuses = append(statuses, status)
+		if lib.Version == "" {
+			status.nextVersion = defaultVersion
+			continue
+		}
+		since := legacyTag
+		if since == "" {
+			since = git.FormatTag(cfg.Default.TagFormat, lib.Name, lib.Version)
+		}
+		changed, err := libraryChangedSince(ctx, cfg, lib, since)
+		if err != nil {
+			return nil, fmt.Errorf("error finding changes for library %s since %s: %w", lib.Name, since, err)
+		}
+		if !changed {
+			continue
+		}
+		output := libraryOutput(cfg.Language, lib, cfg.Default)
+		messages, err := libraryCommitMessages(ctx, lib, since, output)
+		if err != nil {
+			return nil, err
+		}
+		if status.changeLevel, status.commits, err = releaseChangeLevel(cfg, messages); err != nil {
+			return nil, err
+		}
+		if status.changeLevel == semver.None {
+			continue
+		}
+		if status.nextVersion, err = deriveNextVersion(lib, opts, "", status.changeLevel); err != nil {
+			return nil, err
+		}
+	}
+	return statuses, nil
+}
+
+// printReleaseStatus writes one line per library describing its release
+// status.
+func printReleaseStatus(w io.Writer, statuses []*releaseStatus) error {
+	var b strings.Builder
+	for _, s := range statuses {
+		switch {
+		case s.version == "":
+			fmt.Fprintf(&b, "%s: unreleased -> %s (first release)\n", s.name, s.nextVersion)
+		case s.nextVersion == "":
+			fmt.Fprintf(&b, "%s: %s (no unreleased changes)\n", s.name, s.version)
+		default:
+			fmt.Fprintf(&b, "%s: %s -> %s (%s, %d commits)\n", s.name, s.version, s.nextVersion, s.changeLevel, s.commits)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,186 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
+	"github.com/googleapis/librarian/internal/sample"
+	"github.com/googleapis/librarian/internal/semver"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+func TestFindReleaseStatus(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	lib1Change := filepath.Join(sample.Lib1Output, "src", "lib.rs")
+	for _, test := range []struct {
+		name         string
+		libraryNames []string
+		setup        func(*testing.T, *config.Config)
+		want         []*releaseStatus
+	}{
+		{
+			name: "all libraries",
+			want: []*releaseStatus{
+				{name: sample.Lib1Name, version: sample.InitialVersion, nextVersion: sample.NextVersion, changeLevel: semver.Minor, commits: 1},
+				{name: sample.Lib2Name, version: sample.InitialVersion},
+			},
+		},
+		{
+			name:         "library specified directly",
+			libraryNames: []string{sample.Lib2Name},
+			want: []*releaseStatus{
+				{name: sample.Lib2Name, version: sample.InitialVersion},
+			},
+		},
+		{
+			name: "breaking change",
+			setup: func(t *testing.T, cfg *config.Config) {
+				writeFileAndCommit(t, lib1Change, []byte("breaking"), "feat!: remove a feature")
+			},
+			want: []*releaseStatus{
+				{name: sample.Lib1Name, version: sample.InitialVersion, nextVersion: "2.0.0", changeLevel: semver.Major, commits: 2},
+				{name: sample.Lib2Name, version: sample.InitialVersion},
+			},
+		},
+		{
+			name: "ignored commit",
+			setup: func(t *testing.T, cfg *config.Config) {
+				cfg.Release = &config.Release{IgnoredCommits: []string{"^chore: update deps"}}
+				writeFileAndCommit(t, lib1Change, []byte("deps"), "chore: update deps")
+			},
+			want: []*releaseStatus{
+				{name: sample.Lib1Name, version: sample.InitialVersion, nextVersion: sample.NextVersion, changeLevel: semver.Minor, commits: 1},
+				{name: sample.Lib2Name, version: sample.InitialVersion},
+			},
+		},
+		{
+			name: "all commits ignored",
+			setup: func(t *testing.T, cfg *config.Config) {
+				cfg.Release = &config.Release{IgnoredCommits: []string{"^feat: changed"}}
+			},
+			want: []*releaseStatus{
+				{name: sample.Lib1Name, version: sample.InitialVersion},
+				{name: sample.Lib2Name, version: sample.InitialVersion},
+			},
+		},
+		{
+			name: "skip release",
+			setup: func(t *testing.T, cfg *config.Config) {
+				cfg.Libraries[0].SkipRelease = true
+			},
+			want: []*releaseStatus{
+				{name: sample.Lib2Name, version: sample.InitialVersion},
+			},
+		},
+		{
+			name:         "unreleased library specified directly",
+			libraryNames: []string{sample.Lib1Name},
+			setup: func(t *testing.T, cfg *config.Config) {
+				cfg.Libraries[0].Version = ""
+			},
+			want: []*releaseStatus{
+				{name: sample.Lib1Name, nextVersion: defaultVersion},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := sample.Config()
+			testhelper.Setup(t, testhelper.SetupOptions{
+				Clone:       true,
+				Config:      cfg,
+				Tags:        []string{sample.InitialLib1Tag, sample.InitialLib2Tag},
+				WithChanges: []string{lib1Change},
+			})
+			if test.setup != nil {
+				test.setup(t, cfg)
+			}
+			got, err := findReleaseStatus(t.Context(), cfg, test.libraryNames)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(releaseStatus{})); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindReleaseStatus_Error(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	for _, test := range []struct {
+		name         string
+		libraryNames []string
+		tagFormat    string
+		wantErr      error
+	}{
+		{
+			name:         "library not found",
+			libraryNames: []string{"not-found"},
+			wantErr:      ErrLibraryNotFound,
+		},
+		{
+			name: "missing release tag",
+		},
+		{
+			name:      "invalid tag format",
+			tagFormat: "{name}-latest",
+			wantErr:   git.ErrInvalidTagFormat,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := sample.Config()
+			if test.tagFormat != "" {
+				cfg.Default.TagFormat = test.tagFormat
+			}
+			testhelper.Setup(t, testhelper.SetupOptions{
+				Clone:  true,
+				Config: cfg,
+			})
+			_, err := findReleaseStatus(t.Context(), cfg, test.libraryNames)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("findReleaseStatus() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintReleaseStatus(t *testing.T) {
+	statuses := []*releaseStatus{
+		{name: "changed", version: "1.2.3", nextVersion: "2.0.0", changeLevel: semver.Major, commits: 3},
+		{name: "unchanged", version: "1.0.0"},
+		{name: "new", nextVersion: "0.1.0"},
+	}
+	var buf bytes.Buffer
+	if err := printReleaseStatus(&buf, statuses); err != nil {
+		t.Fatal(err)
+	}
+	want := `changed: 1.2.3 -> 2.0.0 (major, 3 commits)
+unchanged: 1.0.0 (no unreleased changes)
+new: unreleased -> 0.1.0 (first release)
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
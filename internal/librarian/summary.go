@@ -0,0 +1,179 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/googleapis/librarian/internal/config"
+)
+
+// generateSummaryVersion is the schema version of [generateSummary]. It must
+// be incremented whenever a field is removed or its meaning changes.
+const generateSummaryVersion = 1
+
+// generateSummary is the machine-readable result of a generate run, written
+// to the path given by the --summary-file flag. GoogleapisCommit is empty when
+// generating from a local directory, where commit metadata is unavailable.
+type generateSummary struct {
+	SchemaVersion    int                       `json:"schemaVersion"`
+	Libraries        []*generateSummaryLibrary `json:"libraries"`
+	GoogleapisCommit string                    `json:"googleapisCommit,omitempty"`
+	DurationSeconds  float64                   `json:"durationSeconds"`
+	Error            string                    `json:"error,omitempty"`
+}
+
+// Statuses of a library in a [generateSummary].
+const (
+	// libraryStatusGenerated is the status of a library that was generated.
+	libraryStatusGenerated = "generated"
+	// libraryStatusUnchanged is the status of a library whose generated
+	// changes were reverted because they only touch noop_ignore_patterns.
+	libraryStatusUnchanged = "unchanged"
+	// libraryStatusCheckpointed is the status of a library that was generated
+	// and recorded in the checkpoint before the run failed, so it is kept.
+	libraryStatusCheckpointed = "checkpointed"
+	// libraryStatusFailed is the status of a library whose generation
+	// failed. Its output was rolled back unless --no-rollback is set.
+	libraryStatusFailed = "failed"
+	// libraryStatusRolledBack is the status of a library that did not fail
+	// itself, but whose output was rolled back because another library
+	// failed.
+	libraryStatusRolledBack = "rolled-back"
+	// libraryStatusIncomplete is the status of a library that did not fail
+	// itself, but whose output was left as is although the run failed.
+	libraryStatusIncomplete = "incomplete"
+	// libraryStatusNotAttempted is the status of a library whose generation
+	// was never started because the run failed first.
+	libraryStatusNotAttempted = "not-attempted"
+)
+
+// generateSummaryLibrary describes a single library in a [generateSummary].
+// Error and ExitCode are set for failed libraries, ExitCode only when the
+// failure is a command that exited with a non-zero status. DurationSeconds is
+// the time spent generating and formatting the library.
+type generateSummaryLibrary struct {
+	Name            string  `json:"name"`
+	Output          string  `json:"output"`
+	Status          string  `json:"status"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Error           string  `json:"error,omitempty"`
+	ExitCode        int     `json:"exitCode,omitempty"`
+}
+
+// generateOutcome records what happened to each library of a generate run.
+type generateOutcome struct {
+	// attempted lists the libraries whose generation was started.
+	attempted []*config.Library
+	// completed lists the libraries recorded in the checkpoint, which are
+	// kept even if the run failed.
+	completed []*config.Library
+	// reverted lists the libraries whose changes were reverted as no-op.
+	reverted []*config.Library
+	// rolledBack reports whether the outputs of the libraries that are not
+	// completed were rolled back after the run failed.
+	rolledBack bool
+	// runs records the time spent generating each library.
+	runs libraryRuns
+}
+
+// status returns the status of library in a run that failed with runErr, if
+// not nil, and the error of the library itself, if any.
+func (o *generateOutcome) status(library *config.Library, runErr error) (string, error) {
+	switch {
+	case runErr == nil && slices.Contains(o.reverted, library):
+		return libraryStatusUnchanged, nil
+	case runErr == nil:
+		return libraryStatusGenerated, nil
+	case slices.Contains(o.completed, library):
+		return libraryStatusCheckpointed, nil
+	}
+	if err := findLibraryError(runErr, library); err != nil {
+		return libraryStatusFailed, err
+	}
+	switch {
+	case !slices.Contains(o.attempted, library):
+		return libraryStatusNotAttempted, nil
+	case o.rolledBack:
+		return libraryStatusRolledBack, nil
+	default:
+		return libraryStatusIncomplete, nil
+	}
+}
+
+// findLibraryError returns the first [libraryError] for library in the tree
+// of err, or nil if there is none.
+func findLibraryError(err error, library *config.Library) error {
+	switch err := err.(type) {
+	case *libraryError:
+		if err.library == library {
+			return err.err
+		}
+		return findLibraryError(err.err, library)
+	case interface{ Unwrap() error }:
+		return findLibraryError(err.Unwrap(), library)
+	case interface{ Unwrap() []error }:
+		for _, err := range err.Unwrap() {
+			if libErr := findLibraryError(err, library); libErr != nil {
+				return libErr
+			}
+		}
+	}
+	return nil
+}
+
+// writeGenerateSummary writes a [generateSummary] for the given libraries to
+// path, recording the googleapis commit the libraries were generated from
+// and the status of each library in outcome. The error from the generate
+// run, if any, is recorded in the summary, and the error of each failed
+// library in its entry.
+func writeGenerateSummary(path string, libraries []*config.Library, outcome *generateOutcome, googleapisCommit string, duration time.Duration, runErr error) error {
+	summary := &generateSummary{
+		SchemaVersion:    generateSummaryVersion,
+		Libraries:        []*generateSummaryLibrary{},
+		GoogleapisCommit: googleapisCommit,
+		DurationSeconds:  duration.Seconds(),
+	}
+	for _, library := range libraries {
+		status, libErr := outcome.status(library, runErr)
+		entry := &generateSummaryLibrary{
+			Name:            library.Name,
+			Output:          library.Output,
+			Status:          status,
+			DurationSeconds: outcome.runs.duration(library).Seconds(),
+		}
+		if libErr != nil {
+			entry.Error = libErr.Error()
+			var exitErr *exec.ExitError
+			if errors.As(libErr, &exitErr) {
+				entry.ExitCode = exitErr.ExitCode()
+			}
+		}
+		summary.Libraries = append(summary.Libraries, entry)
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+	b, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+)
+
+func TestWriteGenerateSummary(t *testing.T) {
+	one := &config.Library{Name: "library-one", Output: "output1"}
+	two := &config.Library{Name: "library-two", Output: "output2"}
+	libraries := []*config.Library{one, two}
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	for _, test := range []struct {
+		name    string
+		outcome *generateOutcome
+		runErr  error
+		want    *generateSummary
+	}{
+		{
+			name:    "success",
+			outcome: &generateOutcome{},
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusGenerated},
+					{Name: "library-two", Output: "output2", Status: libraryStatusGenerated},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+			},
+		},
+		{
+			name:    "reverted",
+			outcome: &generateOutcome{reverted: []*config.Library{two}},
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusGenerated},
+					{Name: "library-two", Output: "output2", Status: libraryStatusUnchanged},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+			},
+		},
+		{
+			name: "durations",
+			outcome: &generateOutcome{runs: libraryRuns{durations: map[*config.Library]time.Duration{
+				one: 2 * time.Second,
+				two: 500 * time.Millisecond,
+			}}},
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusGenerated, DurationSeconds: 2},
+					{Name: "library-two", Output: "output2", Status: libraryStatusGenerated, DurationSeconds: 0.5},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+			},
+		},
+		{
+			name:    "failure before generation",
+			outcome: &generateOutcome{},
+			runErr:  errors.New("load sources failed"),
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusNotAttempted},
+					{Name: "library-two", Output: "output2", Status: libraryStatusNotAttempted},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+				Error:            "load sources failed",
+			},
+		},
+		{
+			name:    "failed library",
+			outcome: &generateOutcome{attempted: libraries, rolledBack: true},
+			runErr:  &libraryError{library: two, err: errors.New("generate failed")},
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusRolledBack},
+					{Name: "library-two", Output: "output2", Status: libraryStatusFailed, Error: "generate failed"},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+				Error:            "generate failed",
+			},
+		},
+		{
+			name:    "failed library without rollback",
+			outcome: &generateOutcome{attempted: libraries},
+			runErr:  &libraryError{library: two, err: errors.New("generate failed")},
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusIncomplete},
+					{Name: "library-two", Output: "output2", Status: libraryStatusFailed, Error: "generate failed"},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+				Error:            "generate failed",
+			},
+		},
+		{
+			name:    "failed library with exit status",
+			outcome: &generateOutcome{attempted: []*config.Library{one}, rolledBack: true},
+			runErr:  errors.Join(&libraryError{library: one, err: fmt.Errorf("generate: %w", exitErr)}, errors.New("cleanup failed")),
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusFailed, Error: "generate: exit status 3", ExitCode: 3},
+					{Name: "library-two", Output: "output2", Status: libraryStatusNotAttempted},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+				Error:            "generate: exit status 3\ncleanup failed",
+			},
+		},
+		{
+			name:    "failure with checkpoint",
+			outcome: &generateOutcome{attempted: libraries, completed: []*config.Library{one}, rolledBack: true},
+			runErr:  &libraryError{library: two, err: errors.New("generate failed")},
+			want: &generateSummary{
+				SchemaVersion: generateSummaryVersion,
+				Libraries: []*generateSummaryLibrary{
+					{Name: "library-one", Output: "output1", Status: libraryStatusCheckpointed},
+					{Name: "library-two", Output: "output2", Status: libraryStatusFailed, Error: "generate failed"},
+				},
+				GoogleapisCommit: "abc123",
+				DurationSeconds:  1.5,
+				Error:            "generate failed",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "summary.json")
+			if err := writeGenerateSummary(path, libraries, test.outcome, "abc123", 1500*time.Millisecond, test.runErr); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readJSONFile[*generateSummary](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteGenerateSummary_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "summary.json")
+	if err := writeGenerateSummary(path, nil, &generateOutcome{}, "", time.Second, nil); err == nil {
+		t.Error("expected an error, got none")
+	}
+}
@@ -18,18 +18,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"regexp"
+	"strings"
 
 	"github.com/googleapis/librarian/internal/command"
-	"github.com/googleapis/librarian/internal/config"
 	"github.com/googleapis/librarian/internal/git"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
 var (
 	errNoLibrariesAtReleaseCommit = errors.New("commit does not release any libraries")
 	errCannotDeriveReleaseTag     = errors.New("unable to derive release tag")
+	errTagAtDifferentCommit       = errors.New("tag already exists at a different commit")
 	pullRequestCommitSubjectRegex = regexp.MustCompile(`\(#(\d+)\)$`)
 )
 
@@ -47,6 +49,21 @@ Run tag after librarian publish has succeeded. By default, the most
 recent release commit reachable from HEAD is used; --release-commit
 overrides this with a specific commit.
 
+The --pr flag names the release pull request by URL, which must be on the
+host of --github-api-endpoint: github.com by default. tag then checks that
+the pull request is merged and carries the release:pending label before
+creating any tags, and uses its merge commit as the release commit unless
+--release-commit is also given.
+
+Tags that already exist at the release commit are skipped, so tag can be
+re-run safely after a partial failure. A tag that exists at a different
+commit is an error.
+
+The --dry-run flag prints the tags that tag would create, and the tags that
+already exist at the release commit, without creating any. The release
+commit and pull request are checked exactly as in a real run, so the
+preview fails where the real run would.
+
 The --create-release-tag flag additionally creates a tag of the form
 release-<PR number>; this is used by the legacy release jobs and will be
 removed once those jobs are retired.
@@ -55,12 +72,22 @@ Examples:
 
 	librarian tag
 	librarian tag --release-commit=<sha>
+	librarian tag --pr=https://github.com/googleapis/google-cloud-go/pull/123
+	librarian tag --pr=https://github.com/googleapis/google-cloud-go/pull/123 --dry-run
 	librarian tag --create-release-tag`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "release-commit",
 				Usage: "the release commit to tag; default finds latest release commit",
 			},
+			&cli.StringFlag{
+				Name:  "pr",
+				Usage: "`URL` of the release pull request to validate before tagging",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print the tags that would be created without creating them",
+			},
 			// TODO(https://github.com/googleapis/librarian/issues/4472): remove
 			// this when we've migrated off the legacy release jobs.
 			&cli.BoolFlag{
@@ -69,43 +96,62 @@ Examples:
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			return tag(ctx, cmd.String("release-commit"), cmd.Bool("create-release-tag"))
+			releaseCommit := cmd.String("release-commit")
+			if url := cmd.String("pr"); url != "" {
+				mergeCommit, err := releasePullRequestCommit(ctx, url)
+				if err != nil {
+					return err
+				}
+				if releaseCommit == "" {
+					releaseCommit = mergeCommit
+				}
+			}
+			return tag(ctx, cmd.Root().Writer, cmd.String("config-file"), releaseCommit, cmd.Bool("create-release-tag"), cmd.Bool("dry-run"))
 		},
 	}
 }
 
 // tag implements the tag command. It finds the release commit to publish
-// (unless already specified). The configuration at the release commit is used
-// for all further operations.
-func tag(ctx context.Context, releaseCommit string, createReleaseTag bool) error {
+// (unless already specified). The configuration at configPath, relative to
+// the repository root, at the release commit is used for all further
+// operations. If dryRun is set, the tags are written to w instead of being
+// created.
+func tag(ctx context.Context, w io.Writer, configPath, releaseCommit string, createReleaseTag, dryRun bool) error {
 	if err := git.AssertGitStatusClean(ctx, command.Git); err != nil {
 		return err
 	}
 	if releaseCommit == "" {
-		latestReleaseCommit, err := findLatestReleaseCommitHash(ctx)
+		latestReleaseCommit, err := findLatestReleaseCommitHash(ctx, configPath)
 		if err != nil {
 			return err
 		}
 		releaseCommit = latestReleaseCommit
 	}
-	releaseCommitCfgContent, err := git.ShowFileAtRevision(ctx, command.Git, releaseCommit, config.LibrarianYAML)
+	releaseCommitCfgContent, err := git.ShowFileAtRevision(ctx, command.Git, releaseCommit, configPath)
 	if err != nil {
 		return err
 	}
-	releaseCommitCfg, err := yaml.Unmarshal[config.Config]([]byte(releaseCommitCfgContent))
+	releaseCommitCfg, err := parseConfig([]byte(releaseCommitCfgContent))
 	if err != nil {
 		return err
 	}
+	var tagFormat string
+	if releaseCommitCfg.Default != nil {
+		tagFormat = releaseCommitCfg.Default.TagFormat
+	}
+	if err := git.ValidateTagFormat(tagFormat); err != nil {
+		return fmt.Errorf("default.tag_format at %s: %w", releaseCommit, err)
+	}
 	// Load the immediately-preceding config so we can find all libraries that
 	// were released by that commit. (This duplicates work done in
 	// findLatestReleaseCommitHash, but keeps the interface simple - and means
 	// that if we specify the release commit directly, we can skip
 	// findLatestReleaseCommitHash entirely.)
-	beforeReleaseCommitCfgContent, err := git.ShowFileAtRevision(ctx, command.Git, releaseCommit+"~", config.LibrarianYAML)
+	beforeReleaseCommitCfgContent, err := git.ShowFileAtRevision(ctx, command.Git, releaseCommit+"~", configPath)
 	if err != nil {
 		return err
 	}
-	beforeReleaseCommitCfg, err := yaml.Unmarshal[config.Config]([]byte(beforeReleaseCommitCfgContent))
+	beforeReleaseCommitCfg, err := parseConfig([]byte(beforeReleaseCommitCfgContent))
 	if err != nil {
 		return err
 	}
@@ -117,8 +163,9 @@ func tag(ctx context.Context, releaseCommit string, createReleaseTag bool) error
 		return fmt.Errorf("error tagging %s: %w", releaseCommit, errNoLibrariesAtReleaseCommit)
 	}
 
-	// If we need to create a release tag, do that first - in case we can't
-	// determine the tag name.
+	// Work out every tag name before creating any, so that a tag name that
+	// can't be derived doesn't leave a partially tagged release behind.
+	var tagNames []string
 	if createReleaseTag {
 		commitSubject, err := git.GetCommitSubject(ctx, command.Git, releaseCommit)
 		if err != nil {
@@ -128,24 +175,115 @@ func tag(ctx context.Context, releaseCommit string, createReleaseTag bool) error
 		if len(matches) != 2 {
 			return fmt.Errorf("commit subject has unexpected format '%s': %w", commitSubject, errCannotDeriveReleaseTag)
 		}
-		tagName := "release-" + matches[1]
-		err = git.Tag(ctx, command.Git, tagName, releaseCommit)
-		if err != nil {
-			return fmt.Errorf("error creating tag %s: %w", tagName, err)
-		}
+		tagNames = append(tagNames, "release-"+matches[1])
 	}
 
-	tagFormat := releaseCommitCfg.Default.TagFormat
 	for _, libraryToTag := range librariesToTag {
 		lib, err := FindLibrary(releaseCommitCfg, libraryToTag)
 		if err != nil {
 			return err
 		}
-		tagName := formatTagName(tagFormat, lib)
-		err = git.Tag(ctx, command.Git, tagName, releaseCommit)
+		tagNames = append(tagNames, git.FormatTag(tagFormat, lib.Name, lib.Version))
+	}
+	tags, err := tagCommits(ctx)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return printTagPlan(ctx, w, tags, tagNames, releaseCommit)
+	}
+	for _, tagName := range tagNames {
+		if err := createTag(ctx, tags, tagName, releaseCommit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printTagPlan writes to w which of tagNames would be created at
+// releaseCommit, and which already exist there according to tags, as returned
+// by [tagCommits]. As in a real run, a tag that exists at a different commit
+// is an error.
+func printTagPlan(ctx context.Context, w io.Writer, tags map[string]string, tagNames []string, releaseCommit string) error {
+	var b strings.Builder
+	for _, tagName := range tagNames {
+		exists, err := tagExists(ctx, tags, tagName, releaseCommit)
 		if err != nil {
-			return fmt.Errorf("error creating tag %s: %w", tagName, err)
+			return err
+		}
+		if exists {
+			fmt.Fprintf(&b, "%s: already exists at %s\n", tagName, releaseCommit)
+		} else {
+			fmt.Fprintf(&b, "%s: would be created at %s\n", tagName, releaseCommit)
 		}
 	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// releasePullRequestCommit fetches the pull request at url, checks that it is
+// a merged release pull request, and returns its merge commit.
+func releasePullRequestCommit(ctx context.Context, url string) (string, error) {
+	ref, err := parsePullRequestURL(url)
+	if err != nil {
+		return "", err
+	}
+	pr, err := getPullRequest(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if err := validateReleasePullRequest(pr); err != nil {
+		return "", err
+	}
+	return pr.MergeCommitSHA, nil
+}
+
+// createTag creates the given tag pointing at releaseCommit. If the tag
+// already exists at releaseCommit according to tags, for example because a
+// previous run of the tag command failed part way through, it is left as is.
+// If the tag exists at a different commit, an error is returned.
+func createTag(ctx context.Context, tags map[string]string, tagName, releaseCommit string) error {
+	exists, err := tagExists(ctx, tags, tagName, releaseCommit)
+	if err != nil {
+		return err
+	}
+	if exists {
+		slog.Info("already tagged", "tag", tagName, "commit", releaseCommit)
+		return nil
+	}
+	if err := git.Tag(ctx, command.Git, tagName, releaseCommit); err != nil {
+		return fmt.Errorf("error creating tag %s: %w", tagName, err)
+	}
 	return nil
 }
+
+// tagCommits returns the commit that each tag of the repository points to,
+// keyed by tag name.
+func tagCommits(ctx context.Context) (map[string]string, error) {
+	tags, err := git.Tags(ctx, command.Git)
+	if err != nil {
+		return nil, err
+	}
+	commits := map[string]string{}
+	for _, tag := range tags {
+		commits[tag.Name] = tag.Commit
+	}
+	return commits, nil
+}
+
+// tagExists reports whether the given tag exists at releaseCommit according
+// to tags. If the tag exists at a different commit, an error is returned.
+func tagExists(ctx context.Context, tags map[string]string, tagName, releaseCommit string) (bool, error) {
+	existing, ok := tags[tagName]
+	if !ok {
+		return false, nil
+	}
+	want, err := git.GetCommitHash(ctx, command.Git, releaseCommit+"^{commit}")
+	if err != nil {
+		return false, err
+	}
+	if existing != want {
+		return false, fmt.Errorf("%w: %s is at %s, want %s", errTagAtDifferentCommit, tagName, existing, want)
+	}
+	return true, nil
+}
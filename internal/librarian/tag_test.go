@@ -0,0 +1,152 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
+	"github.com/googleapis/librarian/internal/sample"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+func TestCreateTag(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	for _, test := range []struct {
+		name         string
+		existingTags []string
+	}{
+		{
+			name: "new tag",
+		},
+		{
+			name:         "tag already exists at release commit",
+			existingTags: []string{"lib/v1.0.0"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testhelper.Setup(t, testhelper.SetupOptions{
+				Tags:        test.existingTags,
+				WithChanges: []string{testhelper.ReadmeFile},
+			})
+			// Existing tags are applied before the change, so tag the
+			// commit they point at.
+			releaseCommit, err := git.GetCommitHash(t.Context(), command.Git, "HEAD~")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags, err := tagCommits(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := createTag(t.Context(), tags, "lib/v1.0.0", releaseCommit); err != nil {
+				t.Fatal(err)
+			}
+			got, err := git.GetCommitHash(t.Context(), command.Git, "lib/v1.0.0^{commit}")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != releaseCommit {
+				t.Errorf("tag points at %s, want %s", got, releaseCommit)
+			}
+		})
+	}
+}
+
+func TestCreateTag_Error(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	testhelper.Setup(t, testhelper.SetupOptions{
+		Tags:        []string{"lib/v1.0.0"},
+		WithChanges: []string{testhelper.ReadmeFile},
+	})
+	tags, err := tagCommits(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = createTag(t.Context(), tags, "lib/v1.0.0", "HEAD")
+	if !errors.Is(err, errTagAtDifferentCommit) {
+		t.Errorf("createTag() error = %v, want %v", err, errTagAtDifferentCommit)
+	}
+}
+
+func TestTag_DryRun(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	cfg := &config.Config{
+		Default: &config.Default{TagFormat: "{name}/v{version}"},
+		Libraries: []*config.Library{
+			{Name: sample.Lib1Name, Version: "1.0.0"},
+			{Name: sample.Lib2Name, Version: "1.2.0"},
+		},
+	}
+	testhelper.Setup(t, testhelper.SetupOptions{Config: cfg})
+	cfg.Libraries[0].Version = "1.1.0"
+	cfg.Libraries[1].Version = "1.3.0"
+	writeConfigAndCommitWithMessage(t, cfg, "chore: release (#123)")
+	releaseCommit, err := git.GetCommitHash(t.Context(), command.Git, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "tag", sample.Lib2Name+"/v1.3.0")
+	tagsBefore := listTags(t)
+
+	var out bytes.Buffer
+	if err := tag(t.Context(), &out, config.LibrarianYAML, "", true, true); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`release-123: would be created at %[1]s
+%[2]s/v1.1.0: would be created at %[1]s
+%[3]s/v1.3.0: already exists at %[1]s
+`, releaseCommit, sample.Lib1Name, sample.Lib2Name)
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(tagsBefore, listTags(t)); diff != "" {
+		t.Errorf("dry run changed tags (-want +got):\n%s", diff)
+	}
+}
+
+func TestTag_DryRunError(t *testing.T) {
+	testhelper.RequireCommand(t, "git")
+	cfg := &config.Config{
+		Default:   &config.Default{TagFormat: "{name}/v{version}"},
+		Libraries: []*config.Library{{Name: sample.Lib1Name, Version: "1.0.0"}},
+	}
+	testhelper.Setup(t, testhelper.SetupOptions{Config: cfg})
+	testhelper.RunGit(t, "tag", sample.Lib1Name+"/v1.1.0")
+	cfg.Libraries[0].Version = "1.1.0"
+	writeConfigAndCommit(t, cfg)
+
+	var out bytes.Buffer
+	err := tag(t.Context(), &out, config.LibrarianYAML, "", false, true)
+	if !errors.Is(err, errTagAtDifferentCommit) {
+		t.Errorf("tag() error = %v, want %v", err, errTagAtDifferentCommit)
+	}
+}
+
+func listTags(t *testing.T) []string {
+	t.Helper()
+	out, err := command.Output(t.Context(), command.Git, "tag", "--list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(out)
+}
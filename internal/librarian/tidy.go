@@ -30,7 +30,6 @@ import (
 	"github.com/googleapis/librarian/internal/librarian/python"
 	"github.com/googleapis/librarian/internal/librarian/rust"
 	"github.com/googleapis/librarian/internal/serviceconfig"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
@@ -64,11 +63,15 @@ with a canonical formatting.
 Run tidy after editing librarian.yaml by hand, or as a quick check that
 the configuration is well-formed.`,
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			cfg, err := readConfig(cmd.String("config-file"))
 			if err != nil {
 				return err
 			}
-			return RunTidyOnConfig(ctx, ".", cfg)
+			cfg, err = TidyConfig(cfg)
+			if err != nil {
+				return err
+			}
+			return writeConfig(cmd.String("config-file"), cfg)
 		},
 	}
 }
@@ -76,21 +79,35 @@ the configuration is well-formed.`,
 // RunTidyOnConfig formats and validates the provided librarian configuration
 // and writes it to disk, relative to the specified repository root directory.
 func RunTidyOnConfig(ctx context.Context, repoDir string, cfg *config.Config) error {
-	if err := validateTools(cfg); err != nil {
+	return tidyAndWriteConfig(filepath.Join(repoDir, config.LibrarianYAML), cfg)
+}
+
+// tidyAndWriteConfig formats and validates cfg and writes it to path.
+func tidyAndWriteConfig(path string, cfg *config.Config) error {
+	cfg, err := TidyConfig(cfg)
+	if err != nil {
 		return err
 	}
+	return writeConfig(path, cfg)
+}
+
+// TidyConfig formats and validates the provided librarian configuration, and
+// returns the configuration that [RunTidyOnConfig] would write to disk.
+func TidyConfig(cfg *config.Config) (*config.Config, error) {
+	if err := validateTools(cfg); err != nil {
+		return nil, err
+	}
 	if err := validateLibraries(cfg); err != nil {
-		return err
+		return nil, err
 	}
 	if cfg.Sources == nil || cfg.Sources.Googleapis == nil {
-		return errNoGoogleapiSourceInfo
+		return nil, errNoGoogleapiSourceInfo
 	}
 	var err error
 	if cfg.Libraries, err = tidyLibraries(cfg); err != nil {
-		return err
+		return nil, err
 	}
-	cfg = tidyConfig(cfg)
-	return yaml.Write(filepath.Join(repoDir, config.LibrarianYAML), formatConfig(cfg))
+	return formatConfig(tidyConfig(cfg)), nil
 }
 
 func tidyLibraries(cfg *config.Config) ([]*config.Library, error) {
@@ -582,6 +582,36 @@ func TestTidy_DuplicateError(t *testing.T) {
 	}
 }
 
+func TestTidyConfig(t *testing.T) {
+	cfg := &config.Config{
+		Language: config.LanguageFake,
+		Sources: &config.Sources{
+			Googleapis: &config.Source{Commit: "abc123", SHA256: "sha123"},
+		},
+		Libraries: []*config.Library{
+			{Name: "lib-b", Roots: []string{"googleapis"}},
+			{Name: "lib-a"},
+		},
+	}
+	got, err := TidyConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.Config{
+		Language: config.LanguageFake,
+		Sources: &config.Sources{
+			Googleapis: &config.Source{Commit: "abc123", SHA256: "sha123"},
+		},
+		Libraries: []*config.Library{
+			{Name: "lib-a"},
+			{Name: "lib-b"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestTidy_DerivableOutput(t *testing.T) {
 	googleapisSource := &config.Sources{
 		Googleapis: &config.Source{
@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/googleapis/librarian/internal/command"
+)
+
+const (
+	// envGitHubToken is the environment variable holding a GitHub token.
+	envGitHubToken = "LIBRARIAN_GITHUB_TOKEN"
+	// envGitHubTokenFile is the environment variable holding the path of a
+	// file that contains a GitHub token. Unlike envGitHubToken, the token
+	// does not appear in the environment of librarian or its subprocesses.
+	envGitHubTokenFile = "LIBRARIAN_GITHUB_TOKEN_FILE"
+	// ghCLI is the GitHub CLI, which is asked for a token when neither
+	// environment variable is set.
+	ghCLI = "gh"
+)
+
+var errEmptyGitHubTokenFile = errors.New("GitHub token file is empty")
+
+var (
+	// ghTokensMu guards ghTokens.
+	ghTokensMu sync.Mutex
+	// ghTokens caches the output of gh auth token, keyed by the path of gh
+	// and the GitHub host, so that each GitHub API request does not start a
+	// gh process. An empty token is cached when gh is not logged in.
+	ghTokens = map[string]string{}
+)
+
+// githubToken returns the GitHub token used to authenticate GitHub API
+// requests, and a description of where it was found. The sources are, in
+// order of precedence:
+//
+//   - the LIBRARIAN_GITHUB_TOKEN environment variable
+//   - the file named by the LIBRARIAN_GITHUB_TOKEN_FILE environment variable
+//   - the output of gh auth token, if the GitHub CLI is installed and logged in
+//     to the host of the GitHub API
+//
+// If none of them provides a token, githubToken returns an empty token and no
+// error. An error is only returned if LIBRARIAN_GITHUB_TOKEN_FILE is set but
+// does not name a readable file containing a token.
+func githubToken(ctx context.Context) (token, source string, err error) {
+	if token := os.Getenv(envGitHubToken); token != "" {
+		return token, envGitHubToken, nil
+	}
+	if path := os.Getenv(envGitHubTokenFile); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("reading %s: %w", envGitHubTokenFile, err)
+		}
+		token := strings.TrimSpace(string(content))
+		if token == "" {
+			return "", "", fmt.Errorf("%w: %s", errEmptyGitHubTokenFile, path)
+		}
+		return token, envGitHubTokenFile, nil
+	}
+	gh, err := exec.LookPath(ghCLI)
+	if err != nil {
+		return "", "", nil
+	}
+	if token := ghAuthToken(ctx, gh, githubHost()); token != "" {
+		return token, "gh auth token", nil
+	}
+	return "", "", nil
+}
+
+// ghAuthToken returns the token of the GitHub CLI at gh for host, or an empty
+// token if gh is not logged in to host. The result is cached in ghTokens.
+func ghAuthToken(ctx context.Context, gh, host string) string {
+	key := gh + "\x00" + host
+	ghTokensMu.Lock()
+	defer ghTokensMu.Unlock()
+	if token, ok := ghTokens[key]; ok {
+		return token
+	}
+	out, err := command.Output(ctx, gh, "auth", "token", "--hostname", host)
+	if err != nil {
+		// gh is installed but not logged in, which is not an error:
+		// requests are sent unauthenticated.
+		slog.Debug("no token from gh auth token", "host", host, "error", err)
+		out = ""
+	}
+	token := strings.TrimSpace(out)
+	ghTokens[key] = token
+	return token
+}
+
+// githubHost returns the host that the GitHub CLI knows the GitHub API at
+// githubAPI by: github.com for https://api.github.com, and the host of the
+// endpoint for GitHub Enterprise Server.
+func githubHost() string {
+	u, err := url.Parse(githubAPI)
+	if err != nil || u.Hostname() == "" {
+		return "github.com"
+	}
+	if u.Hostname() == "api.github.com" {
+		return "github.com"
+	}
+	return u.Hostname()
+}
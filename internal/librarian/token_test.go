@@ -0,0 +1,167 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitHubToken(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ghDir := filepath.Join(dir, "gh")
+	if err := os.Mkdir(ghDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ghDir, ghCLI), []byte("#!/bin/sh\necho gh-token\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	loggedOutDir := filepath.Join(dir, "gh-logged-out")
+	if err := os.Mkdir(loggedOutDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(loggedOutDir, ghCLI), []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name       string
+		env        string
+		file       string
+		path       string
+		wantToken  string
+		wantSource string
+	}{
+		{
+			name:       "env takes precedence",
+			env:        "env-token",
+			file:       tokenFile,
+			path:       ghDir,
+			wantToken:  "env-token",
+			wantSource: envGitHubToken,
+		},
+		{
+			name:       "file takes precedence over gh",
+			file:       tokenFile,
+			path:       ghDir,
+			wantToken:  "file-token",
+			wantSource: envGitHubTokenFile,
+		},
+		{
+			name:       "gh",
+			path:       ghDir,
+			wantToken:  "gh-token",
+			wantSource: "gh auth token",
+		},
+		{
+			name: "gh logged out",
+			path: loggedOutDir,
+		},
+		{
+			name: "no token",
+			path: t.TempDir(),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(envGitHubToken, test.env)
+			t.Setenv(envGitHubTokenFile, test.file)
+			t.Setenv("PATH", test.path)
+			token, source, err := githubToken(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != test.wantToken || source != test.wantSource {
+				t.Errorf("githubToken() = (%q, %q), want (%q, %q)", token, source, test.wantToken, test.wantSource)
+			}
+		})
+	}
+}
+
+func TestGitHubToken_GitHubEnterprise(t *testing.T) {
+	dir := t.TempDir()
+	// The fake gh prints the host it is asked for, and counts its runs.
+	script := "#!/bin/sh\necho run >> " + filepath.Join(dir, "runs") + "\necho \"token-for-$4\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ghCLI), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envGitHubToken, "")
+	t.Setenv(envGitHubTokenFile, "")
+	t.Setenv("PATH", dir)
+	original := githubAPI
+	t.Cleanup(func() { githubAPI = original })
+	for _, test := range []struct {
+		api  string
+		want string
+	}{
+		{api: "https://api.github.com", want: "token-for-github.com"},
+		{api: "https://github.example.com/api/v3", want: "token-for-github.example.com"},
+		{api: "https://github.example.com/api/v3", want: "token-for-github.example.com"},
+	} {
+		githubAPI = test.api
+		token, _, err := githubToken(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != test.want {
+			t.Errorf("githubToken() with %s = %q, want %q", test.api, token, test.want)
+		}
+	}
+	runs, err := os.ReadFile(filepath.Join(dir, "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(runs), "run"); got != 2 {
+		t.Errorf("gh was run %d times, want 2", got)
+	}
+}
+
+func TestGitHubToken_Error(t *testing.T) {
+	dir := t.TempDir()
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		file    string
+		wantErr error
+	}{
+		{
+			name:    "missing file",
+			file:    filepath.Join(dir, "missing"),
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name:    "empty file",
+			file:    emptyFile,
+			wantErr: errEmptyGitHubTokenFile,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(envGitHubToken, "")
+			t.Setenv(envGitHubTokenFile, test.file)
+			if _, _, err := githubToken(t.Context()); !errors.Is(err, test.wantErr) {
+				t.Errorf("githubToken() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -18,17 +18,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/googleapis/librarian/internal/command"
 	"github.com/googleapis/librarian/internal/config"
 	"github.com/googleapis/librarian/internal/fetch"
-	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
 var (
-	githubAPI      = "https://api.github.com"
+	// defaultGitHubAPI is the GitHub REST API used unless the
+	// --github-api-endpoint flag is set.
+	defaultGitHubAPI = "https://api.github.com"
+	// githubAPI is the GitHub REST API used by the current run. It is set by
+	// [Run] from the --github-api-endpoint flag, or to defaultGitHubAPI.
+	githubAPI      = defaultGitHubAPI
 	githubDownload = "https://github.com"
 	sourceRepos    = map[string]fetch.RepoRef{
 		"sources.conformance": {Org: "protocolbuffers", Name: "protobuf", Branch: config.BranchMain},
@@ -40,6 +45,8 @@ var (
 	errNoSourcesProvided = errors.New("at least one source must be provided")
 	errUnknownSource     = errors.New("unknown source")
 	errEmptySources      = errors.New("sources required in librarian.yaml")
+
+	errInvalidGitHubAPIEndpoint = errors.New("invalid --github-api-endpoint: must be an absolute http or https URL, such as https://github.example.com/api/v3")
 )
 
 // updateCommand returns the `update` subcommand.
@@ -62,6 +69,14 @@ Supported targets:
 
 At least one target must be specified.
 
+Sources are looked up with the GitHub API. To avoid its rate limit for
+unauthenticated requests, provide a GitHub token with one of, in order of
+precedence:
+
+  - the LIBRARIAN_GITHUB_TOKEN environment variable
+  - a file named by the LIBRARIAN_GITHUB_TOKEN_FILE environment variable
+  - the GitHub CLI, if logged in (gh auth token)
+
 Examples:
 
 	librarian update sources.googleapis
@@ -87,7 +102,7 @@ latest API definitions is:
 					return fmt.Errorf("%w: %s", errUnknownSource, arg)
 				}
 			}
-			cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
+			cfg, err := readConfig(cmd.String("config-file"))
 			if err != nil {
 				return err
 			}
@@ -95,11 +110,24 @@ latest API definitions is:
 			if err != nil {
 				return err
 			}
-			return yaml.Write(config.LibrarianYAML, updatedCfg)
+			return writeConfig(cmd.String("config-file"), updatedCfg)
 		},
 	}
 }
 
+// parseGitHubAPIEndpoint validates a GitHub REST API endpoint and returns it
+// without a trailing slash.
+func parseGitHubAPIEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %w", errInvalidGitHubAPIEndpoint, endpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%w: %q", errInvalidGitHubAPIEndpoint, endpoint)
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
 // runUpdate refreshes the configured targets in Config.
 func runUpdate(ctx context.Context, cfg *config.Config, targets []string) (*config.Config, error) {
 	for _, target := range targets {
@@ -109,7 +137,7 @@ func runUpdate(ctx context.Context, cfg *config.Config, targets []string) (*conf
 			if err != nil {
 				return nil, err
 			}
-			cfg, err = setConfigValue(cfg, "version", strings.TrimSpace(version))
+			cfg, err = setConfigValue(ctx, cfg, "version", strings.TrimSpace(version))
 			if err != nil {
 				return nil, err
 			}
@@ -122,7 +150,7 @@ func runUpdate(ctx context.Context, cfg *config.Config, targets []string) (*conf
 				return nil, fmt.Errorf("%w: %s", errUnknownSource, target)
 			}
 			var err error
-			cfg, err = setConfigValue(cfg, target+".commit", repo.Branch)
+			cfg, err = setConfigValue(ctx, cfg, target+".commit", repo.Branch)
 			if err != nil {
 				return nil, err
 			}
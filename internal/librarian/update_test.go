@@ -96,12 +96,15 @@ func setupUpdateTest(t *testing.T, conf *config.Config) *updateTestSetup {
 		}
 	}))
 
+	originalDefaultAPI := defaultGitHubAPI
 	originalAPI := githubAPI
 	originalDownload := githubDownload
 	t.Cleanup(func() {
+		defaultGitHubAPI = originalDefaultAPI
 		githubAPI = originalAPI
 		githubDownload = originalDownload
 	})
+	defaultGitHubAPI = ts.URL
 	githubAPI = ts.URL
 	githubDownload = ts.URL
 
@@ -255,6 +258,73 @@ func TestUpdateCommand(t *testing.T) {
 	}
 }
 
+func TestUpdateCommand_GitHubAPIEndpoint(t *testing.T) {
+	initialConfig := updateTestConfig()
+	setup := setupUpdateTest(t, initialConfig)
+	defer setup.server.Close()
+	// Point the default at an unreachable address, so the update only
+	// succeeds if the flag is used.
+	defaultGitHubAPI = "http://127.0.0.1:1"
+
+	args := []string{"librarian", "--github-api-endpoint=" + setup.server.URL + "/", "update", "sources.googleapis"}
+	if err := Run(t.Context(), args...); err != nil {
+		t.Fatal(err)
+	}
+	got, err := yaml.Read[config.Config](setup.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Sources.Googleapis.Commit != googleapisTestCommit {
+		t.Errorf("got googleapis commit %q, want %q", got.Sources.Googleapis.Commit, googleapisTestCommit)
+	}
+	// A later run without the flag uses the default again.
+	if err := Run(t.Context(), "librarian", "update", "sources.googleapis"); err == nil {
+		t.Error("expected the default endpoint to be used without the flag")
+	}
+	if githubAPI != defaultGitHubAPI {
+		t.Errorf("got githubAPI %q, want %q", githubAPI, defaultGitHubAPI)
+	}
+}
+
+func TestParseGitHubAPIEndpoint(t *testing.T) {
+	for _, test := range []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://api.github.com", "https://api.github.com"},
+		{"https://github.example.com/api/v3/", "https://github.example.com/api/v3"},
+		{"http://localhost:8080", "http://localhost:8080"},
+	} {
+		t.Run(test.endpoint, func(t *testing.T) {
+			got, err := parseGitHubAPIEndpoint(test.endpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("parseGitHubAPIEndpoint(%q) = %q, want %q", test.endpoint, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseGitHubAPIEndpoint_Error(t *testing.T) {
+	for _, endpoint := range []string{
+		"",
+		"api.github.com",
+		"ftp://github.example.com",
+		"https://",
+		"https://github.example.com/api/v3?page=1",
+		"https://github.example.com/%zz",
+	} {
+		t.Run(endpoint, func(t *testing.T) {
+			_, err := parseGitHubAPIEndpoint(endpoint)
+			if !errors.Is(err, errInvalidGitHubAPIEndpoint) {
+				t.Errorf("parseGitHubAPIEndpoint(%q) error = %v, want %v", endpoint, err, errInvalidGitHubAPIEndpoint)
+			}
+		})
+	}
+}
+
 func TestUpdateCommand_Errors(t *testing.T) {
 	for _, test := range []struct {
 		name    string
@@ -272,6 +342,11 @@ func TestUpdateCommand_Errors(t *testing.T) {
 			args:    []string{"librarian", "update", "unknown"},
 			wantErr: errUnknownSource,
 		},
+		{
+			name:    "invalid github api endpoint",
+			args:    []string{"librarian", "--github-api-endpoint=api.github.com", "update", "sources.googleapis"},
+			wantErr: errInvalidGitHubAPIEndpoint,
+		},
 		{
 			name: "empty sources",
 			args: []string{"librarian", "update", "sources.googleapis"},
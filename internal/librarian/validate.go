@@ -0,0 +1,176 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	errSourceDirNotFound        = errors.New("source directory not found")
+	errUnknownRoot              = errors.New("unknown source root")
+	errRootNotConfigured        = errors.New("source root not configured in sources")
+	errInvalidReplaceRegex      = errors.New("invalid replace_regex pattern")
+	errUnknownDependency        = errors.New("unknown library in depends_on")
+	errInvalidNoopIgnorePattern = errors.New("invalid noop_ignore_patterns pattern")
+)
+
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "validate librarian.yaml without modifying it",
+		UsageText: "librarian validate",
+		Description: `validate reads librarian.yaml and reports every problem it finds,
+without modifying any files. It runs the same checks as librarian tidy,
+and additionally checks that:
+
+  - source directories configured with dir exist
+  - library roots name a source configured in librarian.yaml
+  - postprocess replace_regex patterns are valid regular expressions
+  - libraries in depends_on exist and do not form a cycle
+  - default tag_format, if set, contains {name} and {version}
+
+validate exits with a non-zero status if any problem is found.`,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := readConfig(cmd.String("config-file"))
+			if err != nil {
+				return err
+			}
+			return validateConfig(cfg)
+		},
+	}
+}
+
+// validateConfig returns all problems found in cfg, joined into a single
+// error.
+func validateConfig(cfg *config.Config) error {
+	var errs []error
+	if err := validateTools(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateLibraries(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.Sources == nil || cfg.Sources.Googleapis == nil {
+		errs = append(errs, errNoGoogleapiSourceInfo)
+	}
+	errs = append(errs, validateSources(cfg.Sources)...)
+	for _, lib := range cfg.Libraries {
+		errs = append(errs, validateRoots(cfg.Sources, lib)...)
+		errs = append(errs, validatePostprocess(lib)...)
+		if _, err := noopIgnorePatterns(lib); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, validateDependencies(cfg.Libraries)...)
+	if err := validateTagFormat(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// validateTagFormat returns an error if cfg sets a default tag_format that is
+// missing the {name} or {version} placeholder. An unset tag format is valid,
+// as not every repository is released with librarian.
+func validateTagFormat(cfg *config.Config) error {
+	if cfg.Default == nil || cfg.Default.TagFormat == "" {
+		return nil
+	}
+	if err := git.ValidateTagFormat(cfg.Default.TagFormat); err != nil {
+		return fmt.Errorf("default.tag_format: %w", err)
+	}
+	return nil
+}
+
+// sourceRoots maps the root names accepted in [config.Library.Roots] to the
+// corresponding source in src.
+func sourceRoots(src *config.Sources) map[string]*config.Source {
+	if src == nil {
+		src = &config.Sources{}
+	}
+	return map[string]*config.Source{
+		"conformance":  src.Conformance,
+		"discovery":    src.Discovery,
+		"googleapis":   src.Googleapis,
+		"protobuf-src": src.ProtobufSrc,
+		"showcase":     src.Showcase,
+	}
+}
+
+func validateSources(src *config.Sources) []error {
+	var errs []error
+	for name, source := range sourceRoots(src) {
+		if source == nil || source.Dir == "" {
+			continue
+		}
+		if stat, err := os.Stat(source.Dir); err != nil || !stat.IsDir() {
+			errs = append(errs, fmt.Errorf("%w: %s: %s", errSourceDirNotFound, name, source.Dir))
+		}
+	}
+	return errs
+}
+
+func validateRoots(src *config.Sources, lib *config.Library) []error {
+	var errs []error
+	roots := sourceRoots(src)
+	for _, root := range lib.Roots {
+		source, ok := roots[root]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: library %q: %s", errUnknownRoot, lib.Name, root))
+			continue
+		}
+		if source == nil {
+			errs = append(errs, fmt.Errorf("%w: library %q: %s", errRootNotConfigured, lib.Name, root))
+		}
+	}
+	return errs
+}
+
+func validateDependencies(libraries []*config.Library) []error {
+	var errs []error
+	for _, lib := range libraries {
+		for _, dep := range lib.DependsOn {
+			if !slices.ContainsFunc(libraries, func(l *config.Library) bool { return l.Name == dep }) {
+				errs = append(errs, fmt.Errorf("%w: library %q: %s", errUnknownDependency, lib.Name, dep))
+			}
+		}
+	}
+	if _, err := generationWaves(libraries); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func validatePostprocess(lib *config.Library) []error {
+	if lib.Postprocess == nil {
+		return nil
+	}
+	var errs []error
+	for _, r := range lib.Postprocess.ReplaceRegex {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%w: library %q: %w", errInvalidReplaceRegex, lib.Name, err))
+		}
+	}
+	return errs
+}
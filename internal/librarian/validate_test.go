@@ -0,0 +1,202 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
+	"github.com/googleapis/librarian/internal/sample"
+)
+
+func TestValidateConfig(t *testing.T) {
+	sourceDir := t.TempDir()
+	for _, test := range []struct {
+		name    string
+		cfg     *config.Config
+		wantErr []error
+	}{
+		{
+			name: "valid",
+			cfg: &config.Config{
+				Sources: &config.Sources{
+					Googleapis: &config.Source{Dir: sourceDir},
+					Showcase:   &config.Source{Commit: "abc123"},
+				},
+				Libraries: []*config.Library{
+					{
+						Name:  "showcase",
+						Roots: []string{"googleapis", "showcase"},
+						Postprocess: &config.Postprocess{
+							ReplaceRegex: []config.ReplaceRegexConfig{{Pattern: `foo\d+`}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing googleapis source",
+			cfg:     &config.Config{},
+			wantErr: []error{errNoGoogleapiSourceInfo},
+		},
+		{
+			name: "source dir not found",
+			cfg: &config.Config{
+				Sources: &config.Sources{
+					Googleapis: &config.Source{Dir: filepath.Join(sourceDir, "does-not-exist")},
+				},
+			},
+			wantErr: []error{errSourceDirNotFound},
+		},
+		{
+			name: "unknown root",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{Name: "lib", Roots: []string{"not-a-root"}},
+				},
+			},
+			wantErr: []error{errUnknownRoot},
+		},
+		{
+			name: "root not configured",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{Name: "lib", Roots: []string{"discovery"}},
+				},
+			},
+			wantErr: []error{errRootNotConfigured},
+		},
+		{
+			name: "invalid replace regex",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{
+						Name: "lib",
+						Postprocess: &config.Postprocess{
+							ReplaceRegex: []config.ReplaceRegexConfig{{Pattern: "("}},
+						},
+					},
+				},
+			},
+			wantErr: []error{errInvalidReplaceRegex},
+		},
+		{
+			name: "invalid noop ignore pattern",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{Name: "lib", NoopIgnorePatterns: []string{"("}},
+				},
+			},
+			wantErr: []error{errInvalidNoopIgnorePattern},
+		},
+		{
+			name: "unknown dependency",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{Name: "lib", DependsOn: []string{"missing"}},
+				},
+			},
+			wantErr: []error{errUnknownDependency},
+		},
+		{
+			name: "dependency cycle",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{Name: "a", DependsOn: []string{"b"}},
+					{Name: "b", DependsOn: []string{"a"}},
+				},
+			},
+			wantErr: []error{errDependencyCycle},
+		},
+		{
+			name: "invalid tag format",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Default: &config.Default{TagFormat: "v{version}"},
+			},
+			wantErr: []error{git.ErrInvalidTagFormat},
+		},
+		{
+			name: "reports all problems",
+			cfg: &config.Config{
+				Sources: &config.Sources{Googleapis: &config.Source{}},
+				Libraries: []*config.Library{
+					{Name: "lib", APIs: []*config.API{{Path: "google/foo/v1"}}},
+					{Name: "lib", APIs: []*config.API{{Path: "google/foo/v1"}}, Roots: []string{"unknown"}},
+				},
+			},
+			wantErr: []error{errDuplicateLibraryName, errDuplicateAPIPath, errUnknownRoot},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateConfig(test.cfg)
+			if len(test.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			for _, want := range test.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("validateConfig() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	configContent := `language: rust
+version: ` + sample.LibrarianVersion + `
+sources:
+  googleapis:
+    commit: 94ccedca05acb0bb60780789e93371c9e4100ddc
+libraries:
+  - name: google-cloud-storage-v1
+    roots:
+      - nowhere
+`
+	configPath := filepath.Join(tempDir, config.LibrarianYAML)
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(t.Context(), "librarian", "validate")
+	if !errors.Is(err, errUnknownRoot) {
+		t.Fatalf("Run() error = %v, want %v", err, errUnknownRoot)
+	}
+	got, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(configContent, string(got)); diff != "" {
+		t.Errorf("validate modified %s (-want +got):\n%s", config.LibrarianYAML, diff)
+	}
+}
@@ -15,13 +15,49 @@
 package librarian
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
 	"runtime/debug"
 	"strings"
+
+	"github.com/googleapis/librarian/internal/yaml"
 )
 
 // versionDevel is the version string for local builds without a module version.
 const versionDevel = "(devel)"
 
+// Output formats accepted by the version command's --output-format flag.
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+	outputFormatYAML = "yaml"
+)
+
+var errInvalidOutputFormat = errors.New("--output-format must be one of text, json or yaml")
+
+// buildCommit and buildDate describe the source commit and time of the build.
+// Release builds set them with the linker, for example:
+//
+//	go build -ldflags "-X github.com/googleapis/librarian/internal/librarian.buildCommit=$(git rev-parse HEAD)"
+//
+// If unset, the VCS information recorded by the go command is used instead.
+var (
+	buildCommit string
+	buildDate   string
+)
+
+// buildInfo describes the librarian binary, as printed by the version command
+// in the json and yaml output formats.
+type buildInfo struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Date      string `json:"date,omitempty" yaml:"date,omitempty"`
+	GoVersion string `json:"goVersion" yaml:"go_version"`
+}
+
 // Version return the version information for the binary, which is constructed
 // following https://go.dev/ref/mod#versions.
 func Version() string {
@@ -41,3 +77,56 @@ func version(info *debug.BuildInfo) string {
 	}
 	return info.Main.Version
 }
+
+// readBuildInfo returns the [buildInfo] of the running binary.
+func readBuildInfo() *buildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return &buildInfo{Commit: buildCommit, Date: buildDate, GoVersion: runtime.Version()}
+	}
+	return newBuildInfo(info, buildCommit, buildDate)
+}
+
+// newBuildInfo returns the [buildInfo] for info. The commit and date values
+// set at link time take precedence over the VCS information in info.
+func newBuildInfo(info *debug.BuildInfo, commit, date string) *buildInfo {
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && commit == "":
+			commit = setting.Value
+		case setting.Key == "vcs.time" && date == "":
+			date = setting.Value
+		}
+	}
+	return &buildInfo{
+		Version:   version(info),
+		Commit:    commit,
+		Date:      date,
+		GoVersion: info.GoVersion,
+	}
+}
+
+// writeVersion writes the build information to w in the given output format.
+func writeVersion(w io.Writer, info *buildInfo, format string) error {
+	switch format {
+	case "", outputFormatText:
+		_, err := fmt.Fprintf(w, "librarian version %s\n", info.Version)
+		return err
+	case outputFormatJSON:
+		b, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case outputFormatYAML:
+		b, err := yaml.Marshal(info)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	default:
+		return fmt.Errorf("%w: %q", errInvalidOutputFormat, format)
+	}
+}
@@ -15,8 +15,12 @@
 package librarian
 
 import (
+	"bytes"
+	"errors"
 	"runtime/debug"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestVersion(t *testing.T) {
@@ -78,3 +82,108 @@ func TestVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestNewBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.26.1",
+		Main: debug.Module{
+			Version: "v1.2.3",
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "1234567890001234"},
+			{Key: "vcs.time", Value: "2023-01-25T19:57:54Z"},
+		},
+	}
+	for _, test := range []struct {
+		name   string
+		commit string
+		date   string
+		want   *buildInfo
+	}{
+		{
+			name: "VCS information",
+			want: &buildInfo{
+				Version:   "v1.2.3",
+				Commit:    "1234567890001234",
+				Date:      "2023-01-25T19:57:54Z",
+				GoVersion: "go1.26.1",
+			},
+		},
+		{
+			name:   "linker values take precedence",
+			commit: "abcdef",
+			date:   "2026-01-02T03:04:05Z",
+			want: &buildInfo{
+				Version:   "v1.2.3",
+				Commit:    "abcdef",
+				Date:      "2026-01-02T03:04:05Z",
+				GoVersion: "go1.26.1",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := newBuildInfo(info, test.commit, test.date)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteVersion(t *testing.T) {
+	info := &buildInfo{
+		Version:   "v1.2.3",
+		Commit:    "abcdef",
+		Date:      "2026-01-02T03:04:05Z",
+		GoVersion: "go1.26.1",
+	}
+	for _, test := range []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "",
+			want:   "librarian version v1.2.3\n",
+		},
+		{
+			format: outputFormatText,
+			want:   "librarian version v1.2.3\n",
+		},
+		{
+			format: outputFormatJSON,
+			want: `{
+  "version": "v1.2.3",
+  "commit": "abcdef",
+  "date": "2026-01-02T03:04:05Z",
+  "goVersion": "go1.26.1"
+}
+`,
+		},
+		{
+			format: outputFormatYAML,
+			want: `version: v1.2.3
+commit: abcdef
+date: "2026-01-02T03:04:05Z"
+go_version: go1.26.1
+`,
+		},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			var got bytes.Buffer
+			if err := writeVersion(&got, info, test.format); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteVersion_Error(t *testing.T) {
+	var got bytes.Buffer
+	err := writeVersion(&got, &buildInfo{}, "xml")
+	if !errors.Is(err, errInvalidOutputFormat) {
+		t.Errorf("want error %v, got %v", errInvalidOutputFormat, err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/git"
+)
+
+// envCacheDir is the environment variable used when --cache-dir is not set.
+const envCacheDir = "LIBRARIANOPS_CACHE_DIR"
+
+var errCacheLocked = errors.New("cached repository is in use by another run")
+
+// openCachedRepo returns the directory of a clone of the given repository in
+// cacheDir, locked for the exclusive use of the caller until unlock is
+// called. The clone is created on first use, with the given depth. On later
+// uses it must be clean, and is updated to the latest commit of the remote's
+// default branch instead of being cloned again.
+func openCachedRepo(ctx context.Context, app *githubAppTokenSource, cacheDir, repoName string, depth int) (repoDir string, unlock func() error, err error) {
+	repoDir = filepath.Join(cacheDir, "github.com", "googleapis", repoName)
+	if err := os.MkdirAll(filepath.Dir(repoDir), 0o755); err != nil {
+		return "", nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	lockFile := repoDir + ".lock"
+	f, err := os.OpenFile(lockFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", nil, fmt.Errorf("%w: remove %s if no other run is active", errCacheLocked, lockFile)
+		}
+		return "", nil, fmt.Errorf("failed to lock cached repository: %w", err)
+	}
+	release := func() error {
+		return os.Remove(lockFile)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, release())
+		}
+	}()
+	if err := f.Close(); err != nil {
+		return "", nil, err
+	}
+
+	if _, err := os.Stat(repoDir); errors.Is(err, fs.ErrNotExist) {
+		if err := cloneRepo(ctx, app, repoDir, repoName, depth); err != nil {
+			return "", nil, err
+		}
+		return repoDir, release, nil
+	}
+	slog.Info("reusing cached repository", "dir", repoDir)
+	output, err := command.Output(ctx, command.Git, "-C", repoDir, "status", "--porcelain")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to check git status of %s: %w", repoDir, err)
+	}
+	if len(output) > 0 {
+		return "", nil, fmt.Errorf("%w: %s", git.ErrGitStatusUnclean, repoDir)
+	}
+	if err := command.Run(ctx, command.Git, "-C", repoDir, "fetch", "origin"); err != nil {
+		return "", nil, err
+	}
+	if err := command.Run(ctx, command.Git, "-C", repoDir, "checkout", "--detach", "origin/HEAD"); err != nil {
+		return "", nil, err
+	}
+	return repoDir, release, nil
+}
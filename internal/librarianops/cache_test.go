@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/git"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+func TestOpenCachedRepo(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	remoteDir := testhelper.SetupRepo(t)
+	installCloningGH(t, remoteDir)
+	cacheDir := t.TempDir()
+
+	repoDir, unlock, err := openCachedRepo(t.Context(), nil, cacheDir, repoFake, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(cacheDir, "github.com", "googleapis", repoFake); repoDir != want {
+		t.Errorf("repoDir = %q, want %q", repoDir, want)
+	}
+	if _, err := os.Stat(repoDir + ".lock"); err != nil {
+		t.Errorf("expected lock file while in use, got %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(repoDir + ".lock"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected lock file to be removed, got %v", err)
+	}
+
+	// A new remote commit is picked up by fetching rather than cloning again.
+	if err := os.WriteFile(filepath.Join(remoteDir, "new.txt"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "feat: add new file")
+	want, err := git.GetCommitHash(t.Context(), command.Git, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unlock, err = openCachedRepo(t.Context(), nil, cacheDir, repoFake, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	output, err := command.Output(t.Context(), command.Git, "-C", repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("HEAD = %q, want %q", got, want)
+	}
+}
+
+func TestOpenCachedRepo_Error(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	remoteDir := testhelper.SetupRepo(t)
+	installCloningGH(t, remoteDir)
+	for _, test := range []struct {
+		name    string
+		setup   func(t *testing.T, repoDir string)
+		wantErr error
+	}{
+		{
+			name: "dirty",
+			setup: func(t *testing.T, repoDir string) {
+				if err := os.WriteFile(filepath.Join(repoDir, "untracked.txt"), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: git.ErrGitStatusUnclean,
+		},
+		{
+			name: "locked",
+			setup: func(t *testing.T, repoDir string) {
+				if err := os.WriteFile(repoDir+".lock", nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: errCacheLocked,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			repoDir, unlock, err := openCachedRepo(t.Context(), nil, cacheDir, repoFake, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := unlock(); err != nil {
+				t.Fatal(err)
+			}
+			test.setup(t, repoDir)
+			_, _, err = openCachedRepo(t.Context(), nil, cacheDir, repoFake, 1)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("want error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+// installCloningGH puts a fake gh executable first in PATH. The fake
+// implements "gh repo clone <repo> <dir> -- <flags>" by cloning remoteDir
+// into dir.
+func installCloningGH(t *testing.T, remoteDir string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\ndir=$4\nshift 5\nexec git clone -q \"$@\" file://%s \"$dir\"\n", remoteDir)
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
@@ -15,22 +15,36 @@
 package librarianops
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/exec"
 	"os/user"
+	"regexp"
+	"slices"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/googleapis/librarian/internal/command"
 	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
 	"github.com/googleapis/librarian/internal/yaml"
 	"github.com/urfave/cli/v3"
 )
 
 const (
 	branchPrefix = "librarianops-generateall-"
+	// updateBranch is the branch used with --update-existing-pr. Unlike the
+	// branches named with branchPrefix, it is the same for every run, so
+	// that a later run finds the pull request of an earlier one.
+	updateBranch = "librarianops-generateall"
+	// originRemote is the git remote of the repository that pull requests
+	// are opened against.
+	originRemote = "origin"
 	commitTitle  = "feat: update API sources and regenerate"
 	// librarianImageTemplate is a template string to format a language and
 	// version into the name of a Docker image to run when the --docker flag
@@ -38,8 +52,145 @@ const (
 	// TODO(https://github.com/googleapis/librarian/issues/4464): change this
 	// to an Artifact Registry image when we publish automatically.
 	librarianImageTemplate = "docker.io/library/librarian-{language}:{version}"
+	// defaultContainerRuntime is the container runtime used when
+	// --container-runtime is not set.
+	defaultContainerRuntime = "docker"
+	// envContainerRuntime is the environment variable used when
+	// --container-runtime is not set.
+	envContainerRuntime = "LIBRARIANOPS_CONTAINER_RUNTIME"
+	// envAllowedImageRegistries is the environment variable used when
+	// --allowed-image-registry is not set.
+	envAllowedImageRegistries = "LIBRARIANOPS_ALLOWED_IMAGE_REGISTRIES"
+	// outputTailSize is the number of bytes at the end of librarian's output
+	// that are included in the error when librarian fails.
+	outputTailSize = 4096
 )
 
+var (
+	errInvalidImageDigest  = errors.New("image digest must be of the form sha256:<hex>")
+	errImageDigestMismatch = errors.New("image digest mismatch")
+	errImageNotAllowed     = errors.New("image is not in an allowed registry")
+	errInvalidUser         = errors.New("--container-user must be of the form user[:group]")
+	errInvalidSigningMode  = errors.New("invalid signing mode")
+	errSigningModeNoKey    = errors.New("--signing-mode requires --signing-key")
+	errInvalidCloneDepth   = errors.New("--clone-depth must not be negative")
+	errInvalidCommitTmpl   = errors.New("invalid --commit-message-template")
+	errContainerTimeout    = errors.New("container timed out")
+	errRuntimeNotFound     = errors.New("container runtime not found")
+	errCommitPerLibraryPR  = errors.New("--commit-per-library cannot be used with --pr-per-library")
+	errUnknownRemote       = errors.New("git remote not found")
+	errNotGitHubRemote     = errors.New("git remote is not a GitHub repository")
+	errInvalidBranchName   = errors.New("--branch-template does not produce a valid branch name")
+
+	// containerUserRegexp matches a user for docker run --user: a user name
+	// or uid, optionally followed by a group name or gid.
+	containerUserRegexp = regexp.MustCompile(`^[^:\s]+(:[^:\s]+)?$`)
+
+	// githubRemoteRegexp matches the URL of a GitHub remote, over HTTPS or
+	// SSH, capturing the owner and the repository name.
+	githubRemoteRegexp = regexp.MustCompile(`^(?:https://github\.com/|git@github\.com:|ssh://git@github\.com/)([^/]+)/([^/]+?)(?:\.git)?/?$`)
+)
+
+// signingModes lists the accepted values of --signing-mode, which correspond
+// to the values of git's gpg.format setting.
+var signingModes = map[string]bool{
+	"openpgp": true,
+	"ssh":     true,
+	"x509":    true,
+}
+
+// dockerOptions configures how librarian is run in Docker.
+type dockerOptions struct {
+	// runtime is the container runtime CLI, such as "docker" or "podman". It
+	// must accept docker's run, pull, image inspect and kill commands. If
+	// empty, defaultContainerRuntime is used.
+	runtime string
+	// image overrides the image derived from librarianImageTemplate. It may
+	// be pinned by digest, such as "example.com/librarian@sha256:...".
+	image string
+	// digest, if set, is the digest that the image must have. It is verified
+	// after pulling the image and before running any librarian command, and
+	// the image is then run by digest, so that a tag moved in the meantime
+	// has no effect.
+	digest string
+	// timeout, if positive, is the maximum duration of each librarian
+	// command run in a container. The container is killed if it runs longer.
+	timeout time.Duration
+	// hostMount, if not nil, translates the paths mounted into the container
+	// when librarianops itself runs in a container.
+	hostMount *hostMount
+	// user is the user[:group] that the container runs as. If empty, the
+	// container runs as the uid and gid of the current user.
+	user string
+	// allowedRegistries, if not empty, lists the registry prefixes that the
+	// image must be under. See [checkImageRegistry].
+	allowedRegistries []string
+}
+
+// prOptions configures the pull request created for the generated changes.
+type prOptions struct {
+	// draft creates the pull request as a draft, so that reviewers are not
+	// notified until it is marked as ready for review.
+	draft bool
+	// reviewers are the GitHub users or teams requested to review the pull
+	// request.
+	reviewers []string
+	// labels are added to the pull request.
+	labels []string
+	// perLibrary creates one pull request per changed library instead of a
+	// single pull request for all of them.
+	perLibrary bool
+	// updateExisting uses updateBranch instead of a new branch for each run.
+	// The branch is force-pushed with a lease, and an open pull request from
+	// it is updated instead of creating another one.
+	updateExisting bool
+	// branchTemplate, if set, names the branch instead of branchName. See
+	// [renderBranchTemplate] for its placeholders.
+	branchTemplate string
+	// pushRemote is the git remote the branch is pushed to, such as a fork.
+	// If empty, the branch is pushed to originRemote. The pull request is
+	// always opened against the repository of originRemote.
+	pushRemote string
+	// leases are the commits of the branches of the push remote when the run
+	// started, keyed by branch name. With updateExisting, a branch is only
+	// replaced if it has not moved since, so that commits pushed to it
+	// during the run are not lost.
+	leases map[string]string
+}
+
+// commitOptions configures the commit of the generated changes.
+type commitOptions struct {
+	// message is the template of the commit message, which is executed with
+	// a [commitMessageData]. If nil, commitTitle is used.
+	message *template.Template
+	// signing, if not nil, configures how the commit is signed.
+	signing *signingOptions
+	// perLibrary creates one commit per changed library, after a commit of
+	// the changes outside every library, instead of a single commit.
+	perLibrary bool
+}
+
+// commitMessageData is the data available to --commit-message-template.
+type commitMessageData struct {
+	// Repo is the name of the repository, such as "google-cloud-rust".
+	Repo string
+	// LibrarianVersion is the librarian version in librarian.yaml.
+	LibrarianVersion string
+	// GoogleapisCommit is the googleapis commit the libraries were generated
+	// from, or empty if the repository does not use a googleapis commit.
+	GoogleapisCommit string
+}
+
+// signingOptions configures how the generated commit is signed.
+type signingOptions struct {
+	// key is the signing key, passed to git commit --gpg-sign. For the
+	// "ssh" mode this is usually the path to a private or public key file.
+	key string
+	// mode is the signature format, used as git's gpg.format setting. If
+	// empty, git's configured format is used.
+	mode string
+}
+
 func generateCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "generate",
@@ -53,15 +204,61 @@ Specify a repository name to clone and process, or use -C to work in a specific
 directory (repo name is inferred from the directory basename).
 
 For each repository, librarianops will:
-  1. Clone the repository to a temporary directory (or use existing directory with -C)
-  2. Create a branch: librarianops-generateall-YYYY-MM-DD
+  1. Clone the repository to a temporary directory (or use existing directory with -C,
+     or reuse a clone in the --cache-dir directory)
+  2. Create a branch: librarianops-generateall-YYYYMMDDTHHMMSSZ, or
+     librarianops-generateall with --update-existing-pr
   3. Run librarian tidy
   4. Run librarian update for configured sources (sources.discovery, sources.googleapis)
   5. Run librarian generate --all
   6. Run cargo update --workspace (google-cloud-rust only)
-  7. Commit changes
-  8. Create a pull request`,
-		Flags: []cli.Flag{
+  7. Commit changes, or commit each changed library separately with
+     --commit-per-library
+  8. Create a pull request, or one pull request per changed library with
+     --pr-per-library. With --update-existing-pr, an open pull request from
+     the same branch is updated instead
+
+With --update-existing-pr, the branch is force-pushed with a lease: it is
+only replaced if it has not changed on the remote since the run started, so
+that commits pushed to it in the meantime are not overwritten.
+
+The --branch-template flag names the branch instead, once librarian update
+has run. It is rendered with {date} (YYYYMMDD), {timestamp}
+(YYYYMMDDTHHMMSSZ), {shortsha} (the first 7 characters of the googleapis
+commit) and {library}, such as librarianops/generate/{date}/{shortsha}.
+{library} is "all" for a single pull request. With --pr-per-library, a
+template with {library} names each library branch; otherwise the library
+name is appended to the branch name. The result must be a valid git branch
+name.
+
+The branch is pushed to origin, or to the remote named by --push-remote,
+such as a fork. The pull request is always opened against the repository
+of origin, from the branch of the push remote.
+
+GitHub authentication is selected with --github-auth. With the default,
+token, gh and git push use their own credentials, such as the GH_TOKEN
+environment variable. With app, librarianops authenticates as a GitHub App
+installation, given --github-app-id, --github-app-installation-id and
+--github-app-private-key-file: it mints an installation token, passes it to
+gh and git push, and mints a new one before it expires.
+
+The --allowed-image-registry flag restricts the images that --docker runs to
+those under the given registry prefixes, such as
+us-docker.pkg.dev/my-project/librarian. It applies both to --docker-image
+and to the image derived from the version in librarian.yaml, so that an edit
+to librarian.yaml cannot point generation at an untrusted image. A prefix
+matches whole path components, and images must be named in full, including
+docker.io for Docker Hub. librarianops fails before pulling or running an
+image that is not allowed.
+
+Containers run as the uid and gid of the user running librarianops, so that
+the files they generate are owned by that user rather than by root. The
+--container-user flag runs them as another user[:group] instead, such as
+1000:1000. Set it together with --host-mount when librarianops itself runs
+in a container as a different user, such as root, from the host user who
+owns the mounted directory: --host-mount only translates the mounted paths,
+not the ownership of the files written to them.`,
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "C",
 				Usage: "work in `directory` (repo name inferred from basename)",
@@ -70,45 +267,276 @@ For each repository, librarianops will:
 				Name:  "v",
 				Usage: "run librarian with verbose output",
 			},
+			&cli.StringFlag{
+				Name:    "cache-dir",
+				Usage:   "reuse clones of repositories kept in `directory` instead of cloning them again",
+				Sources: cli.EnvVars(envCacheDir),
+			},
+			&cli.BoolFlag{
+				Name:  "keep-working-dir",
+				Usage: "do not remove the temporary clone of the repository, and log its path if generation fails",
+			},
+			&cli.IntFlag{
+				Name:  "clone-depth",
+				Value: 1,
+				Usage: "clone only the latest `n` commits of the default branch; 0 clones the full history",
+			},
 			&cli.BoolFlag{
 				Name:  "docker",
 				Usage: "run librarian in Docker",
 			},
-		},
+			&cli.StringFlag{
+				Name:  "docker-image",
+				Usage: "Docker image to run librarian in, optionally pinned by digest; implies --docker",
+			},
+			&cli.StringFlag{
+				Name:  "docker-image-digest",
+				Usage: "expected sha256 digest of the Docker image, verified before running; implies --docker",
+			},
+			&cli.StringFlag{
+				Name:    "container-runtime",
+				Value:   defaultContainerRuntime,
+				Usage:   "container runtime `command` used with --docker, such as docker, podman or nerdctl",
+				Sources: cli.EnvVars(envContainerRuntime),
+			},
+			&cli.StringFlag{
+				Name:    "host-mount",
+				Usage:   "when running in a container, `host-dir:local-dir` describing where a host directory is mounted, so that --docker mounts host paths",
+				Sources: cli.EnvVars(envHostMount),
+			},
+			&cli.StringSliceFlag{
+				Name:    "allowed-image-registry",
+				Usage:   "only run images under this registry `prefix` with --docker; can be repeated",
+				Sources: cli.EnvVars(envAllowedImageRegistries),
+			},
+			&cli.StringFlag{
+				Name:  "container-user",
+				Usage: "run librarian containers as `user[:group]`, such as 1000:1000, instead of the current uid and gid; implies --docker",
+			},
+			&cli.DurationFlag{
+				Name:  "container-timeout",
+				Usage: "kill each librarian container that runs longer than `duration`, such as 30m; implies --docker",
+			},
+			&cli.BoolFlag{
+				Name:  "draft",
+				Usage: "create the pull request as a draft",
+			},
+			&cli.StringSliceFlag{
+				Name:  "reviewer",
+				Usage: "request a review of the pull request from a GitHub `user` or org/team",
+			},
+			&cli.StringSliceFlag{
+				Name:  "label",
+				Usage: "add a `label` to the pull request",
+			},
+			&cli.BoolFlag{
+				Name:  "pr-per-library",
+				Usage: "create one pull request per changed library instead of a single pull request",
+			},
+			&cli.BoolFlag{
+				Name:  "update-existing-pr",
+				Usage: "push to a fixed branch, and update its open pull request instead of creating another one",
+			},
+			&cli.StringFlag{
+				Name:  "branch-template",
+				Usage: "name the branch with this `template`, using {date}, {timestamp}, {shortsha} and {library}",
+			},
+			&cli.StringFlag{
+				Name:  "push-remote",
+				Usage: "push the branch to the git `remote` named, such as a fork, instead of origin",
+			},
+			&cli.BoolFlag{
+				Name:  "commit-per-library",
+				Usage: "create one commit per changed library in the pull request instead of a single commit",
+			},
+			&cli.StringFlag{
+				Name:  "commit-message-template",
+				Usage: "Go `template` for the commit message, with fields .Repo, .LibrarianVersion and .GoogleapisCommit",
+			},
+			&cli.StringFlag{
+				Name:  "signing-key",
+				Usage: "sign the commit with `key` (a GPG key ID, or an SSH key path with --signing-mode=ssh)",
+			},
+			&cli.StringFlag{
+				Name:  "signing-mode",
+				Usage: "signature format for --signing-key: openpgp, ssh or x509",
+			},
+		}, githubAuthFlags()...),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			repoName, workDir, verbose, err := parseFlags(cmd)
 			if err != nil {
 				return err
 			}
 			command.Verbose = verbose
-			return runGenerate(ctx, repoName, workDir, cmd.Bool("docker"))
+			var docker *dockerOptions
+			if cmd.Bool("docker") || cmd.String("docker-image") != "" || cmd.String("docker-image-digest") != "" || cmd.Duration("container-timeout") > 0 || cmd.String("container-user") != "" {
+				if user := cmd.String("container-user"); user != "" && !containerUserRegexp.MatchString(user) {
+					return fmt.Errorf("%w: %q", errInvalidUser, user)
+				}
+				runtime := cmd.String("container-runtime")
+				if _, err := exec.LookPath(runtime); err != nil {
+					return fmt.Errorf("%w: %q", errRuntimeNotFound, runtime)
+				}
+				docker = &dockerOptions{
+					runtime:           runtime,
+					image:             cmd.String("docker-image"),
+					digest:            cmd.String("docker-image-digest"),
+					timeout:           cmd.Duration("container-timeout"),
+					user:              cmd.String("container-user"),
+					allowedRegistries: cmd.StringSlice("allowed-image-registry"),
+				}
+				if value := cmd.String("host-mount"); value != "" {
+					docker.hostMount, err = parseHostMount(value)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			signing, err := parseSigningOptions(cmd.String("signing-key"), cmd.String("signing-mode"))
+			if err != nil {
+				return err
+			}
+			message, err := parseCommitMessageTemplate(cmd.String("commit-message-template"))
+			if err != nil {
+				return err
+			}
+			commit := &commitOptions{message: message, signing: signing, perLibrary: cmd.Bool("commit-per-library")}
+			pr := &prOptions{
+				draft:          cmd.Bool("draft"),
+				reviewers:      cmd.StringSlice("reviewer"),
+				labels:         cmd.StringSlice("label"),
+				perLibrary:     cmd.Bool("pr-per-library"),
+				updateExisting: cmd.Bool("update-existing-pr"),
+				pushRemote:     cmd.String("push-remote"),
+				branchTemplate: cmd.String("branch-template"),
+			}
+			if pr.branchTemplate != "" {
+				// Check the template with sample values before a long run.
+				if _, err := renderBranchTemplate(ctx, pr.branchTemplate, time.Now(), "0123456789abcdef", "library"); err != nil {
+					return err
+				}
+			}
+			if commit.perLibrary && pr.perLibrary {
+				return errCommitPerLibraryPR
+			}
+			app, err := parseGitHubAuth(cmd)
+			if err != nil {
+				return err
+			}
+			depth := cmd.Int("clone-depth")
+			if depth < 0 {
+				return errInvalidCloneDepth
+			}
+			return runGenerate(ctx, repoName, workDir, cmd.String("cache-dir"), depth, cmd.Bool("keep-working-dir"), docker, commit, pr, app)
 		},
 	}
 }
 
-func runGenerate(ctx context.Context, repoName, repoDir string, runInDocker bool) error {
+// runGenerate runs the generate workflow for repoName. If repoDir is empty,
+// the repository is cloned with the given depth, where 0 clones the full
+// history. The clone is made in cacheDir and reused by later runs if cacheDir
+// is set, and in a temporary directory otherwise. The temporary directory is
+// removed when done, unless keepWorkDir is set. GitHub is accessed with app,
+// as in [processRepo].
+func runGenerate(ctx context.Context, repoName, repoDir, cacheDir string, depth int, keepWorkDir bool, docker *dockerOptions, commit *commitOptions, pr *prOptions, app *githubAppTokenSource) (err error) {
 	if !supportedRepositories[repoName] {
 		return fmt.Errorf("repository %q not found in supported repositories list", repoName)
 	}
-	return processRepo(ctx, repoName, repoDir, "", command.Verbose, runInDocker)
-}
-
-func processRepo(ctx context.Context, repoName, repoDir, librarianBin string, verbose, runInDocker bool) (err error) {
-	if repoDir == "" {
+	if repoDir == "" && cacheDir != "" {
+		var unlock func() error
+		repoDir, unlock, err = openCachedRepo(ctx, app, cacheDir, repoName, depth)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, unlock())
+		}()
+	} else if repoDir == "" {
 		repoDir, err = os.MkdirTemp("", "librarianops-"+repoName+"-*")
 		if err != nil {
 			return fmt.Errorf("failed to create temp directory: %w", err)
 		}
 		defer func() {
+			if keepWorkDir {
+				if err != nil {
+					slog.Error("generation failed, working directory preserved", "dir", repoDir)
+				}
+				return
+			}
 			cerr := os.RemoveAll(repoDir)
 			if err == nil {
 				err = cerr
 			}
 		}()
-		if err := cloneRepo(ctx, repoDir, repoName); err != nil {
+		if err := cloneRepo(ctx, app, repoDir, repoName, depth); err != nil {
 			return err
 		}
 	}
+	return processRepo(ctx, repoName, repoDir, "", command.Verbose, docker, commit, pr, app)
+}
+
+// parseCommitMessageTemplate parses the value of --commit-message-template,
+// returning nil if it is empty.
+func parseCommitMessageTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("commit").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidCommitTmpl, err)
+	}
+	return tmpl, nil
+}
+
+// commitMessage returns the commit message for the generated changes in the
+// repository, whose configuration is cfg.
+func commitMessage(repoName string, cfg *config.Config, commit *commitOptions) (string, error) {
+	if commit == nil || commit.message == nil {
+		return commitTitle, nil
+	}
+	data := &commitMessageData{
+		Repo:             repoName,
+		LibrarianVersion: cfg.Version,
+		GoogleapisCommit: googleapisCommit(cfg),
+	}
+	var b strings.Builder
+	if err := commit.message.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidCommitTmpl, err)
+	}
+	return b.String(), nil
+}
+
+// googleapisCommit returns the googleapis commit in cfg, or "" if cfg does
+// not use googleapis.
+func googleapisCommit(cfg *config.Config) string {
+	if cfg.Sources == nil || cfg.Sources.Googleapis == nil {
+		return ""
+	}
+	return cfg.Sources.Googleapis.Commit
+}
+
+// parseSigningOptions returns the signing options for the given flag values,
+// or nil if commits should not be explicitly signed.
+func parseSigningOptions(key, mode string) (*signingOptions, error) {
+	if mode != "" && !signingModes[mode] {
+		return nil, fmt.Errorf("%w: %q", errInvalidSigningMode, mode)
+	}
+	if key == "" {
+		if mode != "" {
+			return nil, errSigningModeNoKey
+		}
+		return nil, nil
+	}
+	return &signingOptions{key: key, mode: mode}, nil
+}
+
+// processRepo runs the generate workflow in the repository at repoDir. If
+// librarianBin is set, that binary is used to run librarian. Otherwise, if
+// docker is not nil, librarian is run in Docker. The resulting commit and pull
+// request are created according to commit and pr, which may be nil to use the
+// defaults. gh and git authenticate to GitHub with app, if not nil, and with
+// their own credentials otherwise.
+func processRepo(ctx context.Context, repoName, repoDir, librarianBin string, verbose bool, docker *dockerOptions, commit *commitOptions, pr *prOptions, app *githubAppTokenSource) (err error) {
 	originalWD, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get current directory: %w", err)
@@ -118,7 +546,20 @@ func processRepo(ctx context.Context, repoName, repoDir, librarianBin string, ve
 	}
 	defer os.Chdir(originalWD)
 
-	if err := createBranch(ctx, time.Now()); err != nil {
+	// Check the push remote before generating, which can take a long time.
+	if pr != nil && pr.pushRemote != "" {
+		if err := checkRemote(ctx, pr.pushRemote); err != nil {
+			return err
+		}
+	}
+	if pr.updatesExisting() && repoName != repoFake {
+		if err := recordLeases(ctx, pr, app); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	branch := branchName(now, pr)
+	if err := createBranch(ctx, branch); err != nil {
 		return err
 	}
 	cfg, err := yaml.Read[config.Config](config.LibrarianYAML)
@@ -128,12 +569,28 @@ func processRepo(ctx context.Context, repoName, repoDir, librarianBin string, ve
 	if librarianBin == "" && cfg.Version == "" {
 		return errors.New("librarian.yaml must specify the librarian version")
 	}
+	var image string
+	if librarianBin == "" && docker != nil {
+		image = docker.image
+		if image == "" {
+			image = strings.NewReplacer("{language}", cfg.Language, "{version}", cfg.Version).Replace(librarianImageTemplate)
+		}
+		if err := checkImageRegistry(image, docker.allowedRegistries); err != nil {
+			return err
+		}
+		if docker.digest != "" {
+			image, err = verifyImageDigest(ctx, docker.runtime, image, docker.digest)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	run := func(args ...string) error {
 		if librarianBin != "" {
 			return runLibrarianBin(ctx, librarianBin, verbose, args...)
 		}
-		if runInDocker {
-			return runLibrarianInDocker(ctx, cfg.Language, cfg.Version, verbose, args...)
+		if image != "" {
+			return runLibrarianInDocker(ctx, docker, image, verbose, args...)
 		}
 		return runLibrarianWithVersion(ctx, cfg.Version, verbose, args...)
 	}
@@ -157,48 +614,310 @@ func processRepo(ctx context.Context, repoName, repoDir, librarianBin string, ve
 			return err
 		}
 	}
-	if err := commitChanges(ctx); err != nil {
+	// Read the configuration again, as librarian update changes the source
+	// commits.
+	cfg, err = yaml.Read[config.Config](config.LibrarianYAML)
+	if err != nil {
 		return err
 	}
+	var libraryBranch func(library string) (string, error)
+	if pr != nil && pr.branchTemplate != "" {
+		if strings.Contains(pr.branchTemplate, "{library}") {
+			libraryBranch = func(library string) (string, error) {
+				return renderBranchTemplate(ctx, pr.branchTemplate, now, googleapisCommit(cfg), library)
+			}
+		}
+		name, err := renderBranchTemplate(ctx, pr.branchTemplate, now, googleapisCommit(cfg), "all")
+		if err != nil {
+			return err
+		}
+		if err := command.Run(ctx, command.Git, "branch", "-M", name); err != nil {
+			return err
+		}
+		branch = name
+	}
+	message, err := commitMessage(repoName, cfg, commit)
+	if err != nil {
+		return err
+	}
+	var signing *signingOptions
+	if commit != nil {
+		signing = commit.signing
+	}
+	if err := commitChanges(ctx, message, signing); err != nil {
+		return err
+	}
+	if commit != nil && commit.perLibrary {
+		if err := commitPerLibrary(ctx, repoName, cfg, message, signing); err != nil {
+			return err
+		}
+	}
+	if pr != nil && pr.perLibrary {
+		return splitByLibrary(ctx, repoName, cfg, branch, libraryBranch, message, signing, pr, app)
+	}
 	if repoName != repoFake {
-		if err := pushBranch(ctx); err != nil {
+		if err := pushBranch(ctx, pr, app); err != nil {
 			return err
 		}
-		if err := createPR(ctx, repoName); err != nil {
+		if err := createPR(ctx, repoName, "", pr, app); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func cloneRepo(ctx context.Context, repoDir, repoName string) error {
-	return command.Run(ctx, "gh", "repo", "clone", fmt.Sprintf("googleapis/%s", repoName), repoDir)
+// cloneRepo clones the default branch of the repository into repoDir. If
+// depth is positive, only that many commits of history are fetched.
+func cloneRepo(ctx context.Context, app *githubAppTokenSource, repoDir, repoName string, depth int) error {
+	args := []string{"repo", "clone", fmt.Sprintf("googleapis/%s", repoName), repoDir, "--", "--single-branch"}
+	if depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", depth))
+	}
+	return runGH(ctx, app, args...)
+}
+
+// renderBranchTemplate returns the branch name for tmpl, replacing {date} and
+// {timestamp} with now in UTC, {shortsha} with the first 7 characters of
+// googleapisCommit, and {library} with library. The result is checked with
+// git check-ref-format.
+func renderBranchTemplate(ctx context.Context, tmpl string, now time.Time, googleapisCommit, library string) (string, error) {
+	now = now.UTC()
+	name := strings.NewReplacer(
+		"{date}", now.Format("20060102"),
+		"{timestamp}", now.Format("20060102T150405Z"),
+		"{shortsha}", googleapisCommit[:min(7, len(googleapisCommit))],
+		"{library}", library,
+	).Replace(tmpl)
+	if err := command.Run(ctx, command.Git, "check-ref-format", "--branch", name); err != nil {
+		return "", fmt.Errorf("%w: %q from %q", errInvalidBranchName, name, tmpl)
+	}
+	return name, nil
 }
 
-func createBranch(ctx context.Context, now time.Time) error {
-	branchName := fmt.Sprintf("%s%s", branchPrefix, now.UTC().Format("20060102T150405Z"))
-	return command.Run(ctx, command.Git, "checkout", "-b", branchName)
+// branchName returns the name of the branch for the generated changes, which
+// is updateBranch with --update-existing-pr, and includes now otherwise.
+func branchName(now time.Time, pr *prOptions) string {
+	if pr.updatesExisting() {
+		return updateBranch
+	}
+	return fmt.Sprintf("%s%s", branchPrefix, now.UTC().Format("20060102T150405Z"))
 }
 
-func commitChanges(ctx context.Context) error {
+// createBranch creates and checks out a branch for the generated changes. A
+// branch left by an earlier run in a cached repository is reset.
+func createBranch(ctx context.Context, branch string) error {
+	return command.Run(ctx, command.Git, "checkout", "-B", branch)
+}
+
+func commitChanges(ctx context.Context, message string, signing *signingOptions) error {
 	if err := command.Run(ctx, command.Git, "add", "."); err != nil {
 		return err
 	}
-	return command.Run(ctx, command.Git, "commit", "-m", commitTitle)
+	return commitStaged(ctx, message, signing)
 }
 
-func pushBranch(ctx context.Context) error {
-	return command.Run(ctx, command.Git, "push", "-u", "origin", "HEAD")
+// commitStaged commits the staged changes.
+func commitStaged(ctx context.Context, message string, signing *signingOptions) error {
+	var args []string
+	if signing != nil && signing.mode != "" {
+		args = append(args, "-c", "gpg.format="+signing.mode)
+	}
+	args = append(args, "commit", "-m", message)
+	if signing != nil {
+		args = append(args, "--gpg-sign="+signing.key)
+	}
+	return command.Run(ctx, command.Git, args...)
 }
 
-func createPR(ctx context.Context, repoName string) error {
+// pushBranch pushes the current branch to the push remote of pr. With
+// --update-existing-pr, the remote branch is replaced if it has not moved
+// since [recordLeases]; otherwise [git.ErrStaleLease] is returned.
+func pushBranch(ctx context.Context, pr *prOptions, app *githubAppTokenSource) error {
+	env, err := gitEnv(ctx, app)
+	if err != nil {
+		return err
+	}
+	if pr.updatesExisting() {
+		branch, err := command.Output(ctx, command.Git, "branch", "--show-current")
+		if err != nil {
+			return err
+		}
+		branch = strings.TrimSpace(branch)
+		return git.PushWithLease(ctx, command.Git, env, pr.remote(), branch, pr.leases[branch])
+	}
+	return command.RunWithEnv(ctx, env, command.Git, "push", "-u", pr.remote(), "HEAD")
+}
+
+// recordLeases records the branches of the push remote of pr in pr.leases.
+func recordLeases(ctx context.Context, pr *prOptions, app *githubAppTokenSource) error {
+	env, err := gitEnv(ctx, app)
+	if err != nil {
+		return err
+	}
+	pr.leases, err = git.RemoteBranches(ctx, command.Git, env, pr.remote())
+	return err
+}
+
+// gitEnv returns the environment for git commands that access GitHub. If
+// app is not nil, git uses gh as its credential helper, so that it is
+// authenticated with the installation token.
+func gitEnv(ctx context.Context, app *githubAppTokenSource) (map[string]string, error) {
+	env, err := githubEnv(ctx, app)
+	if err != nil || env == nil {
+		return env, err
+	}
+	// The empty helper clears any configured helpers first.
+	env["GIT_CONFIG_COUNT"] = "2"
+	env["GIT_CONFIG_KEY_0"] = "credential.helper"
+	env["GIT_CONFIG_VALUE_0"] = ""
+	env["GIT_CONFIG_KEY_1"] = "credential.helper"
+	env["GIT_CONFIG_VALUE_1"] = "!gh auth git-credential"
+	return env, nil
+}
+
+// createPR creates a pull request for the current branch. If library is set,
+// the pull request is scoped to that library. With --update-existing-pr, an
+// open pull request from the current branch is edited instead, if there is
+// one.
+func createPR(ctx context.Context, repoName, library string, pr *prOptions, app *githubAppTokenSource) error {
+	title, body := changeDescription(repoName, library)
+	repoArgs, head, err := forkArgs(ctx, pr)
+	if err != nil {
+		return err
+	}
+	if pr.updatesExisting() {
+		number, err := findOpenPR(ctx, app, repoArgs)
+		if err != nil {
+			return err
+		}
+		if number != "" {
+			slog.Info("updating existing pull request", "number", number)
+			args := append([]string{"pr", "edit", number}, repoArgs...)
+			args = append(args, "--title", title, "--body", body)
+			for _, reviewer := range pr.reviewers {
+				args = append(args, "--add-reviewer", reviewer)
+			}
+			for _, label := range pr.labels {
+				args = append(args, "--add-label", label)
+			}
+			return runGH(ctx, app, args...)
+		}
+	}
+	args := append([]string{"pr", "create"}, repoArgs...)
+	if head != "" {
+		args = append(args, "--head", head)
+	}
+	args = append(args, "--title", title, "--body", body)
+	if pr != nil {
+		if pr.draft {
+			args = append(args, "--draft")
+		}
+		for _, reviewer := range pr.reviewers {
+			args = append(args, "--reviewer", reviewer)
+		}
+		for _, label := range pr.labels {
+			args = append(args, "--label", label)
+		}
+	}
+	return runGH(ctx, app, args...)
+}
+
+// changeDescription returns the title and body describing the generated
+// changes, for all libraries or, if library is set, for that library.
+func changeDescription(repoName, library string) (title, body string) {
 	sources := "googleapis"
 	if repoName == repoRust {
 		sources = "googleapis and discovery-artifact-manager"
 	}
-	title := fmt.Sprintf("feat: update %s and regenerate", sources)
-	body := fmt.Sprintf("Update %s to the latest commit and regenerate all client libraries.", sources)
-	return command.Run(ctx, "gh", "pr", "create", "--title", title, "--body", body)
+	if library != "" {
+		return fmt.Sprintf("feat(%s): update %s and regenerate", library, sources),
+			fmt.Sprintf("Update %s to the latest commit and regenerate %s.", sources, library)
+	}
+	return fmt.Sprintf("feat: update %s and regenerate", sources),
+		fmt.Sprintf("Update %s to the latest commit and regenerate all client libraries.", sources)
+}
+
+// forkArgs returns the gh arguments selecting the repository of
+// originRemote, and the head of the pull request in owner:branch form, when
+// pr pushes to another remote. Both are empty otherwise, and gh picks the
+// repository and head itself.
+func forkArgs(ctx context.Context, pr *prOptions) ([]string, string, error) {
+	if pr.remote() == originRemote {
+		return nil, "", nil
+	}
+	baseOwner, baseName, err := githubRemote(ctx, originRemote)
+	if err != nil {
+		return nil, "", err
+	}
+	headOwner, _, err := githubRemote(ctx, pr.remote())
+	if err != nil {
+		return nil, "", err
+	}
+	branch, err := command.Output(ctx, command.Git, "branch", "--show-current")
+	if err != nil {
+		return nil, "", err
+	}
+	return []string{"--repo", baseOwner + "/" + baseName}, headOwner + ":" + strings.TrimSpace(branch), nil
+}
+
+// checkRemote returns an error if the repository in the current directory
+// has no remote with the given name.
+func checkRemote(ctx context.Context, remote string) error {
+	out, err := command.Output(ctx, command.Git, "remote")
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(strings.Fields(out), remote) {
+		return fmt.Errorf("%w: %q", errUnknownRemote, remote)
+	}
+	return nil
+}
+
+// githubRemote returns the owner and name of the GitHub repository that the
+// given remote points at.
+func githubRemote(ctx context.Context, remote string) (owner, name string, err error) {
+	url, err := command.Output(ctx, command.Git, "remote", "get-url", remote)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %q: %w", errUnknownRemote, remote, err)
+	}
+	url = strings.TrimSpace(url)
+	m := githubRemoteRegexp.FindStringSubmatch(url)
+	if m == nil {
+		return "", "", fmt.Errorf("%w: %s is %q", errNotGitHubRemote, remote, url)
+	}
+	return m[1], m[2], nil
+}
+
+// findOpenPR returns the number of the open pull request from the current
+// branch, or "" if there is none. repoArgs select the repository of the pull
+// request, as returned by [forkArgs].
+func findOpenPR(ctx context.Context, app *githubAppTokenSource, repoArgs []string) (string, error) {
+	branch, err := command.Output(ctx, command.Git, "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	args := append([]string{"pr", "list"}, repoArgs...)
+	out, err := outputGH(ctx, app, append(args, "--head", strings.TrimSpace(branch), "--state", "open", "--json", "number", "--jq", ".[].number")...)
+	if err != nil {
+		return "", err
+	}
+	number, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	return number, nil
+}
+
+// remote returns the git remote the branch is pushed to, which is
+// originRemote unless pr sets pushRemote.
+func (pr *prOptions) remote() string {
+	if pr == nil || pr.pushRemote == "" {
+		return originRemote
+	}
+	return pr.pushRemote
+}
+
+// updatesExisting reports whether pr sets updateExisting. It is false if pr
+// is nil.
+func (pr *prOptions) updatesExisting() bool {
+	return pr != nil && pr.updateExisting
 }
 
 func runCargoUpdate(ctx context.Context) error {
@@ -209,20 +928,36 @@ func runLibrarianWithVersion(ctx context.Context, version string, verbose bool,
 	if verbose {
 		args = append([]string{"-v"}, args...)
 	}
-	return command.RunStreaming(ctx, command.Go,
+	return command.RunStreamingWithOutputTail(ctx, outputTailSize, command.Go,
 		append([]string{"run", fmt.Sprintf("github.com/googleapis/librarian/cmd/librarian@%s", version)}, args...)...)
 }
 
-func runLibrarianInDocker(ctx context.Context, language, version string, verbose bool, args ...string) error {
+// runLibrarianInDocker runs librarian with the given arguments in a container
+// of dockerImage, configured by docker. If docker.timeout is positive and the
+// container runs longer, it is killed and errContainerTimeout is returned.
+func runLibrarianInDocker(ctx context.Context, docker *dockerOptions, dockerImage string, verbose bool, args ...string) error {
+	runtime := cmp.Or(docker.runtime, defaultContainerRuntime)
+	timeout := docker.timeout
+	phase := strings.Join(args, " ")
 	if verbose {
 		args = append([]string{"-v"}, args...)
 	}
-	dockerImage := strings.NewReplacer("{language}", language, "{version}", version).Replace(librarianImageTemplate)
-	currentUser, err := user.Current()
+	containerUser := docker.user
+	if containerUser == "" {
+		currentUser, err := user.Current()
+		if err != nil {
+			return err
+		}
+		containerUser = fmt.Sprintf("%s:%s", currentUser.Uid, currentUser.Gid)
+	}
+	homeCache, err := os.UserCacheDir()
 	if err != nil {
 		return err
 	}
-	homeCache, err := os.UserCacheDir()
+	// Not all runtimes accept relative paths in volume mounts.
+	// The paths are resolved by the host, so translate them if librarianops
+	// itself runs in a container.
+	wd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
@@ -230,22 +965,81 @@ func runLibrarianInDocker(ctx context.Context, language, version string, verbose
 		"run",
 		// Clean up the container afterward.
 		"--rm",
-		// Run as the current user in the container, so that files are still
-		// owned appropriately.
+		// Run as the current user in the container, or as the user given
+		// with --container-user, so that files are still owned
+		// appropriately.
 		"-u",
-		fmt.Sprintf("%s:%s", currentUser.Uid, currentUser.Gid),
+		containerUser,
 		// Map the current working directory to /repo.
 		"-v",
-		".:/repo",
+		docker.hostMount.hostPath(wd) + ":/repo",
 		// Map the cache directory (avoids fetching sources multiple times).
 		"-v",
-		homeCache + ":/.cache",
+		docker.hostMount.hostPath(homeCache) + ":/.cache",
 		// Use /repo as the working directory.
 		"-w",
 		"/repo",
-		dockerImage,
 	}
-	return command.RunStreaming(ctx, "docker", append(dockerArgs, args...)...)
+	if timeout <= 0 {
+		dockerArgs = append(dockerArgs, dockerImage)
+		return command.RunStreamingWithOutputTail(ctx, outputTailSize, runtime, append(dockerArgs, args...)...)
+	}
+	// Name the container so that it can be killed on timeout: killing the
+	// runtime client does not stop the container.
+	name := fmt.Sprintf("librarianops-%d-%d", os.Getpid(), time.Now().UnixNano())
+	dockerArgs = append(dockerArgs, "--name", name, dockerImage)
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = command.RunStreamingWithOutputTail(runCtx, outputTailSize, runtime, append(dockerArgs, args...)...)
+	if !errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if kerr := command.Run(context.WithoutCancel(ctx), runtime, "kill", name); kerr != nil {
+		slog.Warn("failed to kill timed out container", "container", name, "error", kerr)
+	}
+	return fmt.Errorf("%w: librarian %s did not finish within %v", errContainerTimeout, phase, timeout)
+}
+
+// checkImageRegistry returns an error unless image is under one of
+// registries, which are prefixes of image references such as
+// "us-docker.pkg.dev/my-project". A prefix only matches whole path
+// components, so "example.com/team" allows "example.com/team/librarian" but
+// not "example.com/team-b/librarian". Every image is allowed if registries is
+// empty.
+func checkImageRegistry(image string, registries []string) error {
+	if len(registries) == 0 {
+		return nil
+	}
+	for _, registry := range registries {
+		if strings.HasPrefix(image, strings.TrimSuffix(registry, "/")+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not under %s", errImageNotAllowed, image, strings.Join(registries, ", "))
+}
+
+// verifyImageDigest pulls the given Docker image and returns an error unless
+// one of its repository digests matches digest. It returns that repository
+// digest, such as "example.com/librarian@sha256:...", which is the image to
+// run: the tag of image may be moved to another image after the check.
+func verifyImageDigest(ctx context.Context, runtime, image, digest string) (string, error) {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", fmt.Errorf("%w: %q", errInvalidImageDigest, digest)
+	}
+	runtime = cmp.Or(runtime, defaultContainerRuntime)
+	if err := command.Run(ctx, runtime, "pull", image); err != nil {
+		return "", err
+	}
+	output, err := command.Output(ctx, runtime, "image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", image)
+	if err != nil {
+		return "", err
+	}
+	for _, repoDigest := range strings.Fields(output) {
+		if strings.HasSuffix(repoDigest, "@"+digest) {
+			return repoDigest, nil
+		}
+	}
+	return "", fmt.Errorf("%w: image %s does not have digest %s", errImageDigestMismatch, image, digest)
 }
 
 // runLibrarianBin runs a pre-built librarian binary with the given arguments.
@@ -253,7 +1047,7 @@ func runLibrarianBin(ctx context.Context, bin string, verbose bool, args ...stri
 	if verbose {
 		args = append([]string{"-v"}, args...)
 	}
-	return command.RunStreaming(ctx, bin, args...)
+	return command.RunStreamingWithOutputTail(ctx, outputTailSize, bin, args...)
 }
 
 func sourcesToUpdate(cfg *config.Config) []string {
@@ -15,13 +15,19 @@
 package librarianops
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/googleapis/librarian/internal/command"
 	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/git"
 	"github.com/googleapis/librarian/internal/sample"
 	"github.com/googleapis/librarian/internal/testhelper"
 	"github.com/googleapis/librarian/internal/yaml"
@@ -36,11 +42,18 @@ func TestGenerateCommand(t *testing.T) {
 	}
 
 	for _, test := range []struct {
-		name    string
-		verbose bool
+		name       string
+		verbose    bool
+		pr         *prOptions
+		wantBranch string
 	}{
-		{"default", false},
-		{"verbose", true},
+		{name: "default"},
+		{name: "verbose", verbose: true},
+		{
+			name:       "branch template",
+			pr:         &prOptions{branchTemplate: "librarian/generate/{library}"},
+			wantBranch: "librarian/generate/all",
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			repoDir := t.TempDir()
@@ -74,10 +87,18 @@ func TestGenerateCommand(t *testing.T) {
 				command.Verbose = true
 				defer func() { command.Verbose = false }()
 			}
-			runInDocker := false
-			if err := processRepo(t.Context(), repoFake, repoDir, librarianBin, test.verbose, runInDocker); err != nil {
+			if err := processRepo(t.Context(), repoFake, repoDir, librarianBin, test.verbose, nil, nil, test.pr, nil); err != nil {
 				t.Fatal(err)
 			}
+			if test.wantBranch != "" {
+				got, err := command.Output(t.Context(), command.Git, "-C", repoDir, "branch", "--show-current")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(test.wantBranch, strings.TrimSpace(got)); diff != "" {
+					t.Errorf("mismatch (-want +got):\n%s", diff)
+				}
+			}
 
 			readmePath := filepath.Join(repoDir, sample.Lib1Output, "README.md")
 			if _, err := os.Stat(readmePath); err != nil {
@@ -104,6 +125,22 @@ func TestGenerateCommand_Errors(t *testing.T) {
 			name: "unsupported repo via C flag",
 			args: []string{"librarianops", "generate", "-C", "/tmp/unsupported-repo"},
 		},
+		{
+			name: "negative clone depth",
+			args: []string{"librarianops", "generate", "--clone-depth=-1", "google-cloud-rust"},
+		},
+		{
+			name: "commit per library with pr per library",
+			args: []string{"librarianops", "generate", "--commit-per-library", "--pr-per-library", "google-cloud-rust"},
+		},
+		{
+			name: "invalid branch template",
+			args: []string{"librarianops", "generate", "--branch-template=librarian/{date}..{shortsha}", "google-cloud-rust"},
+		},
+		{
+			name: "invalid container user",
+			args: []string{"librarianops", "generate", "--container-user=1000:1000:1000", "google-cloud-rust"},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			err := Run(t.Context(), test.args...)
@@ -114,6 +151,38 @@ func TestGenerateCommand_Errors(t *testing.T) {
 	}
 }
 
+func TestRunGenerate_KeepWorkingDir(t *testing.T) {
+	testhelper.RequireCommand(t, command.Git)
+	for _, test := range []struct {
+		name        string
+		keepWorkDir bool
+		wantEntries int
+	}{
+		{"removed", false, 0},
+		{"kept", true, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// The repository has no librarian.yaml, so generation fails
+			// without running librarian.
+			remoteDir := testhelper.SetupRepo(t)
+			installCloningGH(t, remoteDir)
+			tmpDir := t.TempDir()
+			t.Setenv("TMPDIR", tmpDir)
+
+			if err := runGenerate(t.Context(), repoFake, "", "", 1, test.keepWorkDir, nil, nil, nil, nil); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			entries, err := os.ReadDir(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != test.wantEntries {
+				t.Errorf("got %d entries in %s, want %d", len(entries), tmpDir, test.wantEntries)
+			}
+		})
+	}
+}
+
 func TestSourcesToUpdate(t *testing.T) {
 	for _, test := range []struct {
 		name string
@@ -163,6 +232,805 @@ func TestSourcesToUpdate(t *testing.T) {
 	}
 }
 
+func TestCheckImageRegistry(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		image      string
+		registries []string
+	}{
+		{
+			name:  "no allowlist",
+			image: "evil.example.com/librarian:v1",
+		},
+		{
+			name:       "registry",
+			image:      "us-docker.pkg.dev/my-project/librarian/librarian-go:v1",
+			registries: []string{"us-docker.pkg.dev/my-project"},
+		},
+		{
+			name:       "trailing slash",
+			image:      "us-docker.pkg.dev/my-project/librarian-go:v1",
+			registries: []string{"us-docker.pkg.dev/my-project/"},
+		},
+		{
+			name:       "digest",
+			image:      "example.com/librarian@sha256:1234",
+			registries: []string{"us-docker.pkg.dev/my-project", "example.com"},
+		},
+		{
+			name:       "docker hub",
+			image:      "docker.io/library/librarian-rust:v1.0.0",
+			registries: []string{"docker.io/library"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkImageRegistry(test.image, test.registries); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCheckImageRegistry_Error(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		image      string
+		registries []string
+	}{
+		{
+			name:       "other registry",
+			image:      "evil.example.com/librarian:v1",
+			registries: []string{"us-docker.pkg.dev/my-project"},
+		},
+		{
+			name:       "partial path component",
+			image:      "us-docker.pkg.dev/my-project-evil/librarian:v1",
+			registries: []string{"us-docker.pkg.dev/my-project"},
+		},
+		{
+			name:       "registry as host prefix",
+			image:      "docker.io.evil.example.com/library/librarian:v1",
+			registries: []string{"docker.io"},
+		},
+		{
+			name:       "short name",
+			image:      "librarian-go:v1",
+			registries: []string{"docker.io/library"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkImageRegistry(test.image, test.registries)
+			if !errors.Is(err, errImageNotAllowed) {
+				t.Errorf("checkImageRegistry() error = %v, want %v", err, errImageNotAllowed)
+			}
+		})
+	}
+}
+
+func TestVerifyImageDigest(t *testing.T) {
+	installFakeDocker(t, "example.com/librarian@sha256:1234\n")
+	got, err := verifyImageDigest(t.Context(), "", "example.com/librarian:v1", "sha256:1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "example.com/librarian@sha256:1234"; got != want {
+		t.Errorf("verifyImageDigest() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyImageDigest_Error(t *testing.T) {
+	installFakeDocker(t, "example.com/librarian@sha256:1234\n")
+	for _, test := range []struct {
+		name    string
+		digest  string
+		wantErr error
+	}{
+		{"invalid digest", "1234", errInvalidImageDigest},
+		{"mismatch", "sha256:5678", errImageDigestMismatch},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := verifyImageDigest(t.Context(), "", "example.com/librarian:v1", test.digest)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("verifyImageDigest() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunLibrarianInDocker(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %s\n", argsFile)
+	if err := os.WriteFile(filepath.Join(dir, "podman"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	wd := t.TempDir()
+	t.Chdir(wd)
+
+	if err := runLibrarianInDocker(t.Context(), &dockerOptions{runtime: "podman"}, "example.com/librarian", false, "generate", "--all"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Fields(string(got))
+	if !slices.Contains(args, wd+":/repo") {
+		t.Errorf("args %q do not mount %s", args, wd)
+	}
+	if diff := cmp.Diff([]string{"example.com/librarian", "generate", "--all"}, args[len(args)-3:]); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// With a host mount, the host path of the working directory is mounted.
+	docker := &dockerOptions{runtime: "podman", hostMount: &hostMount{host: "/host/src", local: filepath.Dir(wd)}}
+	if err := runLibrarianInDocker(t.Context(), docker, "example.com/librarian", false, "generate", "--all"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/host/src/" + filepath.Base(wd) + ":/repo"; !slices.Contains(strings.Fields(string(got)), want) {
+		t.Errorf("args %q do not mount %s", got, want)
+	}
+
+	// With a container user, the container runs as that user.
+	docker = &dockerOptions{runtime: "podman", user: "1000:1000"}
+	if err := runLibrarianInDocker(t.Context(), docker, "example.com/librarian", false, "generate", "--all"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args = strings.Fields(string(got))
+	if i := slices.Index(args, "-u"); i < 0 || i+1 >= len(args) || args[i+1] != "1000:1000" {
+		t.Errorf("args %q do not run as 1000:1000", args)
+	}
+}
+
+func TestRunLibrarianInDocker_OutputInError(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho generating\necho >&2 'error: protoc failed'\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Chdir(t.TempDir())
+
+	err := runLibrarianInDocker(t.Context(), &dockerOptions{}, "example.com/librarian", false, "generate", "--all")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "error: protoc failed") {
+		t.Errorf("error %q does not include the container output", err)
+	}
+}
+
+func TestGenerateCommand_RuntimeNotFound(t *testing.T) {
+	err := Run(t.Context(), "librarianops", "generate", "--docker", "--container-runtime=no-such-runtime", "google-cloud-rust")
+	if !errors.Is(err, errRuntimeNotFound) {
+		t.Errorf("error = %v, want %v", err, errRuntimeNotFound)
+	}
+}
+
+func TestRunLibrarianInDocker_Timeout(t *testing.T) {
+	dir := t.TempDir()
+	killFile := filepath.Join(dir, "kill")
+	// The fake docker hangs on run, and records the arguments of kill.
+	script := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" = kill ]; then echo \"$@\" > %s; exit 0; fi\nexec sleep 60\n", killFile)
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	err := runLibrarianInDocker(t.Context(), &dockerOptions{timeout: 100 * time.Millisecond}, "example.com/librarian", false, "generate", "--all")
+	if !errors.Is(err, errContainerTimeout) {
+		t.Fatalf("runLibrarianInDocker() error = %v, want %v", err, errContainerTimeout)
+	}
+	if !strings.Contains(err.Error(), "librarian generate --all") {
+		t.Errorf("error %q does not name the librarian command", err)
+	}
+	got, err := os.ReadFile(killFile)
+	if err != nil {
+		t.Fatalf("expected container to be killed: %v", err)
+	}
+	if !strings.HasPrefix(string(got), "kill librarianops-") {
+		t.Errorf("got docker %q, want kill of the librarianops container", got)
+	}
+}
+
+// installFakeDocker puts a fake docker executable first in PATH. The fake
+// succeeds for every command and prints repoDigests for "docker image inspect".
+func installFakeDocker(t *testing.T, repoDigests string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" = image ]; then printf '%s'; fi\n", repoDigests)
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestParseSigningOptions(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		key  string
+		mode string
+		want *signingOptions
+	}{
+		{"no key", "", "", nil},
+		{"key only", "ABCDEF", "", &signingOptions{key: "ABCDEF"}},
+		{"ssh key", "/home/user/.ssh/id_ed25519.pub", "ssh", &signingOptions{key: "/home/user/.ssh/id_ed25519.pub", mode: "ssh"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseSigningOptions(test.key, test.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(signingOptions{})); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseSigningOptions_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		key     string
+		mode    string
+		wantErr error
+	}{
+		{"invalid mode", "ABCDEF", "pgp", errInvalidSigningMode},
+		{"mode without key", "", "ssh", errSigningModeNoKey},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseSigningOptions(test.key, test.mode)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("parseSigningOptions() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	cfg := &config.Config{
+		Version: "v1.2.3",
+		Sources: &config.Sources{
+			Googleapis: &config.Source{Commit: "abc123"},
+		},
+	}
+	for _, test := range []struct {
+		name     string
+		template string
+		cfg      *config.Config
+		want     string
+	}{
+		{
+			name: "default",
+			cfg:  cfg,
+			want: commitTitle,
+		},
+		{
+			name:     "googleapis commit",
+			template: "chore: regenerate {{.Repo}} at googleapis@{{.GoogleapisCommit}}",
+			cfg:      cfg,
+			want:     "chore: regenerate google-cloud-go at googleapis@abc123",
+		},
+		{
+			name:     "trailer",
+			template: "feat: regenerate with librarian {{.LibrarianVersion}}\n\nChange-Id: I0123",
+			cfg:      cfg,
+			want:     "feat: regenerate with librarian v1.2.3\n\nChange-Id: I0123",
+		},
+		{
+			name:     "no sources",
+			template: "chore: regenerate{{with .GoogleapisCommit}} at {{.}}{{end}}",
+			cfg:      &config.Config{},
+			want:     "chore: regenerate",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := parseCommitMessageTemplate(test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := commitMessage("google-cloud-go", test.cfg, &commitOptions{message: tmpl})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommitMessage_Error(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		template string
+	}{
+		{"parse error", "{{.Repo"},
+		{"unknown field", "{{.Unknown}}"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := parseCommitMessageTemplate(test.template)
+			if err == nil {
+				_, err = commitMessage("google-cloud-go", &config.Config{}, &commitOptions{message: tmpl})
+			}
+			if !errors.Is(err, errInvalidCommitTmpl) {
+				t.Errorf("error = %v, want %v", err, errInvalidCommitTmpl)
+			}
+		})
+	}
+}
+
+func TestCommitChanges(t *testing.T) {
+	testhelper.RequireCommand(t, "ssh-keygen")
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := command.Run(t.Context(), "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", keyPath); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name       string
+		signing    *signingOptions
+		wantSigned bool
+	}{
+		{"unsigned", nil, false},
+		{"ssh signed", &signingOptions{key: keyPath, mode: "ssh"}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testhelper.ContinueInNewGitRepository(t, t.TempDir())
+			if err := os.WriteFile("generated.txt", []byte("generated"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := commitChanges(t.Context(), commitTitle, test.signing); err != nil {
+				t.Fatal(err)
+			}
+			commit, err := command.Output(t.Context(), command.Git, "cat-file", "commit", "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(commit, "\ngpgsig "); got != test.wantSigned {
+				t.Errorf("signed = %v, want %v; commit:\n%s", got, test.wantSigned, commit)
+			}
+		})
+	}
+}
+
+func TestCommitChanges_NothingToCommit(t *testing.T) {
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	if err := commitChanges(t.Context(), commitTitle, nil); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCreatePR(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		repoName string
+		library  string
+		pr       *prOptions
+		want     []string
+	}{
+		{
+			name:     "default",
+			repoName: repoFake,
+			want: []string{
+				"pr", "create",
+				"--title", "feat: update googleapis and regenerate",
+				"--body", "Update googleapis to the latest commit and regenerate all client libraries.",
+			},
+		},
+		{
+			name:     "draft",
+			repoName: repoFake,
+			pr:       &prOptions{draft: true},
+			want: []string{
+				"pr", "create",
+				"--title", "feat: update googleapis and regenerate",
+				"--body", "Update googleapis to the latest commit and regenerate all client libraries.",
+				"--draft",
+			},
+		},
+		{
+			name:     "library",
+			repoName: repoRust,
+			library:  "google-cloud-secretmanager-v1",
+			want: []string{
+				"pr", "create",
+				"--title", "feat(google-cloud-secretmanager-v1): update googleapis and discovery-artifact-manager and regenerate",
+				"--body", "Update googleapis and discovery-artifact-manager to the latest commit and regenerate google-cloud-secretmanager-v1.",
+			},
+		},
+		{
+			name:     "reviewers and labels",
+			repoName: repoRust,
+			pr: &prOptions{
+				reviewers: []string{"octocat", "googleapis/rust-team"},
+				labels:    []string{"automerge", "generation"},
+			},
+			want: []string{
+				"pr", "create",
+				"--title", "feat: update googleapis and discovery-artifact-manager and regenerate",
+				"--body", "Update googleapis and discovery-artifact-manager to the latest commit and regenerate all client libraries.",
+				"--reviewer", "octocat",
+				"--reviewer", "googleapis/rust-team",
+				"--label", "automerge",
+				"--label", "generation",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			argsFile := installFakeGH(t)
+			if err := createPR(t.Context(), test.repoName, test.library, test.pr, nil); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreatePR_UpdateExisting(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		number string
+		want   []string
+	}{
+		{
+			name:   "open pull request",
+			number: "42",
+			want: []string{
+				"pr", "edit", "42",
+				"--title", "feat: update googleapis and regenerate",
+				"--body", "Update googleapis to the latest commit and regenerate all client libraries.",
+				"--add-reviewer", "octocat",
+				"--add-label", "automerge",
+			},
+		},
+		{
+			name: "no open pull request",
+			want: []string{
+				"pr", "create",
+				"--title", "feat: update googleapis and regenerate",
+				"--body", "Update googleapis to the latest commit and regenerate all client libraries.",
+				"--reviewer", "octocat",
+				"--label", "automerge",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testhelper.ContinueInNewGitRepository(t, t.TempDir())
+			testhelper.RunGit(t, "checkout", "-b", updateBranch)
+			// The fake gh answers pr list with test.number, and records
+			// the arguments of other commands.
+			dir := t.TempDir()
+			argsFile := filepath.Join(dir, "args")
+			script := fmt.Sprintf("#!/bin/sh\nif [ \"$1 $2\" = \"pr list\" ]; then\n  [ -n %q ] && echo %q\n  exit 0\nfi\nprintf '%%s\\n' \"$@\" > %s\n", test.number, test.number, argsFile)
+			if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			pr := &prOptions{
+				reviewers:      []string{"octocat"},
+				labels:         []string{"automerge"},
+				updateExisting: true,
+			}
+			if err := createPR(t.Context(), repoFake, "", pr, nil); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreatePR_PushRemote(t *testing.T) {
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	testhelper.RunGit(t, "remote", "add", "origin", "https://github.com/googleapis/google-cloud-rust.git")
+	testhelper.RunGit(t, "remote", "add", "fork", "git@github.com:octocat/google-cloud-rust.git")
+	testhelper.RunGit(t, "checkout", "-b", "librarianops-generateall-20260102T030405Z")
+	argsFile := installFakeGH(t)
+	if err := createPR(t.Context(), repoFake, "", &prOptions{pushRemote: "fork"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"pr", "create",
+		"--repo", "googleapis/google-cloud-rust",
+		"--head", "octocat:librarianops-generateall-20260102T030405Z",
+		"--title", "feat: update googleapis and regenerate",
+		"--body", "Update googleapis to the latest commit and regenerate all client libraries.",
+	}
+	if diff := cmp.Diff(want, strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPushBranch_PushRemote(t *testing.T) {
+	origin := t.TempDir()
+	testhelper.RunGit(t, "init", "--bare", origin)
+	fork := t.TempDir()
+	testhelper.RunGit(t, "init", "--bare", fork)
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	testhelper.RunGit(t, "remote", "add", "origin", origin)
+	testhelper.RunGit(t, "remote", "add", "fork", fork)
+	testhelper.RunGit(t, "checkout", "-b", updateBranch)
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "generate")
+	if err := pushBranch(t.Context(), &prOptions{pushRemote: "fork"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := command.Output(t.Context(), command.Git, "-C", fork, "rev-parse", updateBranch); err != nil {
+		t.Errorf("branch not pushed to fork: %v", err)
+	}
+	if _, err := command.Output(t.Context(), command.Git, "-C", origin, "rev-parse", updateBranch); err == nil {
+		t.Error("branch pushed to origin, want only fork")
+	}
+}
+
+func TestGitHubRemote(t *testing.T) {
+	for _, test := range []struct {
+		url       string
+		wantOwner string
+		wantName  string
+	}{
+		{url: "https://github.com/googleapis/google-cloud-rust", wantOwner: "googleapis", wantName: "google-cloud-rust"},
+		{url: "https://github.com/googleapis/google-cloud-rust.git", wantOwner: "googleapis", wantName: "google-cloud-rust"},
+		{url: "git@github.com:octocat/google-cloud-rust.git", wantOwner: "octocat", wantName: "google-cloud-rust"},
+		{url: "ssh://git@github.com/octocat/google-cloud-rust.git", wantOwner: "octocat", wantName: "google-cloud-rust"},
+	} {
+		t.Run(test.url, func(t *testing.T) {
+			testhelper.ContinueInNewGitRepository(t, t.TempDir())
+			testhelper.RunGit(t, "remote", "add", "origin", test.url)
+			owner, name, err := githubRemote(t.Context(), "origin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner != test.wantOwner || name != test.wantName {
+				t.Errorf("githubRemote() = %q, %q, want %q, %q", owner, name, test.wantOwner, test.wantName)
+			}
+		})
+	}
+}
+
+func TestGitHubRemote_Error(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		remote  string
+		wantErr error
+	}{
+		{name: "missing remote", remote: "fork", wantErr: errUnknownRemote},
+		{name: "not github", remote: "origin", wantErr: errNotGitHubRemote},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testhelper.ContinueInNewGitRepository(t, t.TempDir())
+			testhelper.RunGit(t, "remote", "add", "origin", "https://gitlab.com/googleapis/google-cloud-rust.git")
+			if _, _, err := githubRemote(t.Context(), test.remote); !errors.Is(err, test.wantErr) {
+				t.Errorf("githubRemote() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRemote(t *testing.T) {
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	testhelper.RunGit(t, "remote", "add", "fork", "git@github.com:octocat/google-cloud-rust.git")
+	if err := checkRemote(t.Context(), "fork"); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRemote(t.Context(), "forked"); !errors.Is(err, errUnknownRemote) {
+		t.Errorf("checkRemote() error = %v, want %v", err, errUnknownRemote)
+	}
+}
+
+func TestRenderBranchTemplate(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, test := range []struct {
+		name             string
+		tmpl             string
+		googleapisCommit string
+		library          string
+		want             string
+	}{
+		{
+			name:             "date and short sha",
+			tmpl:             "librarian/generate/{date}/{shortsha}",
+			googleapisCommit: "0123456789abcdef",
+			want:             "librarian/generate/20260102/0123456",
+		},
+		{
+			name:    "timestamp and library",
+			tmpl:    "generate-{library}-{timestamp}",
+			library: "google-cloud-secretmanager-v1",
+			want:    "generate-google-cloud-secretmanager-v1-20260102T030405Z",
+		},
+		{
+			name:             "short commit",
+			tmpl:             "generate-{shortsha}",
+			googleapisCommit: "abc",
+			want:             "generate-abc",
+		},
+		{
+			name: "no placeholders",
+			tmpl: "librarian-generate",
+			want: "librarian-generate",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := renderBranchTemplate(t.Context(), test.tmpl, now, test.googleapisCommit, test.library)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderBranchTemplate_Error(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, test := range []struct {
+		name string
+		tmpl string
+	}{
+		{name: "double dot", tmpl: "generate..{date}"},
+		{name: "space", tmpl: "generate {date}"},
+		{name: "empty short sha", tmpl: "generate/{shortsha}/"},
+		{name: "lock suffix", tmpl: "generate-{date}.lock"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := renderBranchTemplate(t.Context(), test.tmpl, now, "", "lib"); !errors.Is(err, errInvalidBranchName) {
+				t.Errorf("renderBranchTemplate(%q) error = %v, want %v", test.tmpl, err, errInvalidBranchName)
+			}
+		})
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, test := range []struct {
+		name string
+		pr   *prOptions
+		want string
+	}{
+		{name: "default", want: "librarianops-generateall-20260102T030405Z"},
+		{name: "update existing", pr: &prOptions{updateExisting: true}, want: "librarianops-generateall"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, branchName(now, test.pr)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPushBranch_UpdateExisting(t *testing.T) {
+	remote := t.TempDir()
+	testhelper.RunGit(t, "init", "--bare", remote)
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	testhelper.RunGit(t, "remote", "add", "origin", remote)
+	testhelper.RunGit(t, "checkout", "-b", updateBranch)
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "first run")
+	pr := &prOptions{updateExisting: true}
+	if err := recordLeases(t.Context(), pr, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushBranch(t.Context(), pr, nil); err != nil {
+		t.Fatal(err)
+	}
+	// A later run starts the branch again, so it does not descend from the
+	// pushed commit.
+	if err := recordLeases(t.Context(), pr, nil); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.RunGit(t, "commit", "--amend", "--allow-empty", "-m", "second run")
+	if err := pushBranch(t.Context(), pr, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := command.Output(t.Context(), command.Git, "-C", remote, "log", "-1", "--format=%s", updateBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("second run\n", got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPushBranch_UpdateExistingStaleLease(t *testing.T) {
+	remote := t.TempDir()
+	testhelper.RunGit(t, "init", "--bare", remote)
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	testhelper.RunGit(t, "remote", "add", "origin", remote)
+	testhelper.RunGit(t, "checkout", "-b", updateBranch)
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "first run")
+	testhelper.RunGit(t, "push", "origin", updateBranch)
+	pr := &prOptions{updateExisting: true}
+	if err := recordLeases(t.Context(), pr, nil); err != nil {
+		t.Fatal(err)
+	}
+	// Someone pushes to the branch while the run is generating.
+	testhelper.RunGit(t, "commit", "--allow-empty", "-m", "manual fix")
+	testhelper.RunGit(t, "push", "origin", updateBranch)
+	testhelper.RunGit(t, "reset", "--hard", "HEAD~1")
+	testhelper.RunGit(t, "commit", "--amend", "--allow-empty", "-m", "second run")
+	if err := pushBranch(t.Context(), pr, nil); !errors.Is(err, git.ErrStaleLease) {
+		t.Fatalf("pushBranch() error = %v, want %v", err, git.ErrStaleLease)
+	}
+	got, err := command.Output(t.Context(), command.Git, "-C", remote, "log", "-1", "--format=%s", updateBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("manual fix\n", got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCloneRepo(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		depth int
+		want  []string
+	}{
+		{
+			name:  "full history",
+			depth: 0,
+			want:  []string{"repo", "clone", "googleapis/google-cloud-rust", "dir", "--", "--single-branch"},
+		},
+		{
+			name:  "shallow",
+			depth: 1,
+			want:  []string{"repo", "clone", "googleapis/google-cloud-rust", "dir", "--", "--single-branch", "--depth=1"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			argsFile := installFakeGH(t)
+			if err := cloneRepo(t.Context(), nil, "dir", repoRust, test.depth); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// installFakeGH puts a fake gh executable first in PATH. The fake writes its
+// arguments, one per line, to the returned file.
+func installFakeGH(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %s\n", argsFile)
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return argsFile
+}
+
 func TestVerboseFlagSetsCommandVerbose(t *testing.T) {
 	origVerbose := command.Verbose
 	defer func() { command.Verbose = origVerbose }()
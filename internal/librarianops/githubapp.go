@@ -0,0 +1,273 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// githubAuthToken authenticates gh with its own credentials, such as
+	// the GH_TOKEN environment variable or a gh auth login session.
+	githubAuthToken = "token"
+	// githubAuthApp authenticates gh as a GitHub App installation.
+	githubAuthApp = "app"
+
+	envGitHubAuth              = "LIBRARIANOPS_GITHUB_AUTH"
+	envGitHubAppID             = "LIBRARIANOPS_GITHUB_APP_ID"
+	envGitHubAppInstallationID = "LIBRARIANOPS_GITHUB_APP_INSTALLATION_ID"
+	envGitHubAppPrivateKeyFile = "LIBRARIANOPS_GITHUB_APP_PRIVATE_KEY_FILE"
+
+	// githubAppJWTLifetime is the lifetime of the JWT that authenticates
+	// the app when minting an installation token. GitHub rejects JWTs that
+	// expire more than 10 minutes after they are issued.
+	githubAppJWTLifetime = 9 * time.Minute
+	// githubTokenRefreshMargin is how long before its expiry an installation
+	// token is replaced. Installation tokens are valid for an hour, so a
+	// token is never used for a gh command that starts close to its expiry.
+	githubTokenRefreshMargin = 10 * time.Minute
+)
+
+// githubAPI is the GitHub API endpoint. It is a variable so that tests can
+// replace it.
+var githubAPI = "https://api.github.com"
+
+var (
+	errInvalidGitHubAuth       = errors.New("--github-auth must be token or app")
+	errGitHubAppIncomplete     = errors.New("--github-auth=app requires --github-app-id, --github-app-installation-id and --github-app-private-key-file")
+	errGitHubAppFlagsWithToken = errors.New("--github-app-* flags require --github-auth=app")
+	errInvalidGitHubAppKey     = errors.New("invalid GitHub App private key")
+	errGitHubAppToken          = errors.New("failed to create GitHub App installation token")
+)
+
+// githubAppTokenSource mints GitHub App installation tokens, and caches each
+// token until it is close to expiry.
+type githubAppTokenSource struct {
+	appID          string
+	installationID string
+	key            *rsa.PrivateKey
+	// now returns the current time. It is a field so that tests can
+	// control token expiry.
+	now func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// githubAuthFlags returns the flags that select how librarianops
+// authenticates to GitHub.
+func githubAuthFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "github-auth",
+			Value:   githubAuthToken,
+			Usage:   "how gh and git push authenticate to GitHub: token uses gh's own credentials, app mints GitHub App installation tokens",
+			Sources: cli.EnvVars(envGitHubAuth),
+		},
+		&cli.StringFlag{
+			Name:    "github-app-id",
+			Usage:   "GitHub App `id`, with --github-auth=app",
+			Sources: cli.EnvVars(envGitHubAppID),
+		},
+		&cli.StringFlag{
+			Name:    "github-app-installation-id",
+			Usage:   "GitHub App installation `id`, with --github-auth=app",
+			Sources: cli.EnvVars(envGitHubAppInstallationID),
+		},
+		&cli.StringFlag{
+			Name:    "github-app-private-key-file",
+			Usage:   "`path` of the GitHub App private key in PEM format, with --github-auth=app",
+			Sources: cli.EnvVars(envGitHubAppPrivateKeyFile),
+		},
+	}
+}
+
+// parseGitHubAuth returns the GitHub App token source selected by the
+// --github-auth flags, or nil if gh's own credentials are used.
+func parseGitHubAuth(cmd *cli.Command) (*githubAppTokenSource, error) {
+	appID := cmd.String("github-app-id")
+	installationID := cmd.String("github-app-installation-id")
+	keyFile := cmd.String("github-app-private-key-file")
+	switch cmd.String("github-auth") {
+	case githubAuthToken:
+		if appID != "" || installationID != "" || keyFile != "" {
+			return nil, errGitHubAppFlagsWithToken
+		}
+		return nil, nil
+	case githubAuthApp:
+		if appID == "" || installationID == "" || keyFile == "" {
+			return nil, errGitHubAppIncomplete
+		}
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseGitHubAppKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return newGitHubAppTokenSource(appID, installationID, key), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errInvalidGitHubAuth, cmd.String("github-auth"))
+	}
+}
+
+func newGitHubAppTokenSource(appID, installationID string, key *rsa.PrivateKey) *githubAppTokenSource {
+	return &githubAppTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		now:            time.Now,
+	}
+}
+
+// parseGitHubAppKey parses a GitHub App private key, which GitHub provides
+// in PKCS #1 PEM format. PKCS #8 keys are also accepted.
+func parseGitHubAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", errInvalidGitHubAppKey)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidGitHubAppKey, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an RSA key", errInvalidGitHubAppKey)
+	}
+	return rsaKey, nil
+}
+
+// Token returns an installation token, minting a new one if there is no
+// cached token or the cached token expires within githubTokenRefreshMargin.
+func (s *githubAppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.now().Add(githubTokenRefreshMargin).Before(s.expiresAt) {
+		return s.token, nil
+	}
+	jwt, err := s.jwt()
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", githubAPI, s.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errGitHubAppToken, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("%w: %s", errGitHubAppToken, resp.Status)
+	}
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: %w", errGitHubAppToken, err)
+	}
+	s.token, s.expiresAt = body.Token, body.ExpiresAt
+	return s.token, nil
+}
+
+// jwt returns a JSON Web Token signed with the app's private key, which
+// authenticates the app itself rather than an installation.
+func (s *githubAppTokenSource) jwt() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		// Backdate the token to allow for clock drift, as recommended by
+		// GitHub.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// githubEnv returns the environment for commands that authenticate to
+// GitHub. If app is not nil, GH_TOKEN holds a current installation token;
+// otherwise the environment is inherited unchanged, and gh and git use their
+// own credentials.
+func githubEnv(ctx context.Context, app *githubAppTokenSource) (map[string]string, error) {
+	if app == nil {
+		return nil, nil
+	}
+	token, err := app.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"GH_TOKEN": token}, nil
+}
+
+// runGH runs gh, authenticated according to githubEnv. The token is fetched
+// for each command, so that long runs switch to a fresh token before the
+// previous one expires.
+func runGH(ctx context.Context, app *githubAppTokenSource, args ...string) error {
+	env, err := githubEnv(ctx, app)
+	if err != nil {
+		return err
+	}
+	return command.RunWithEnv(ctx, env, "gh", args...)
+}
+
+// outputGH runs gh like [runGH], and returns its output.
+func outputGH(ctx context.Context, app *githubAppTokenSource, args ...string) (string, error) {
+	env, err := githubEnv(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	return command.OutputWithEnv(ctx, env, "gh", args...)
+}
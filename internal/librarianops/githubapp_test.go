@@ -0,0 +1,294 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGitHubAppKey(t *testing.T) {
+	key := generateTestKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name  string
+		block *pem.Block
+	}{
+		{
+			name:  "pkcs1",
+			block: &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		},
+		{
+			name:  "pkcs8",
+			block: &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseGitHubAppKey(pem.EncodeToMemory(test.block))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(key) {
+				t.Error("parseGitHubAppKey() returned a different key")
+			}
+		})
+	}
+}
+
+func TestParseGitHubAppKey_Error(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "not pem",
+			data: []byte("not a key"),
+		},
+		{
+			name: "invalid key",
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("garbage")}),
+		},
+		{
+			name: "not rsa",
+			data: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8}),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseGitHubAppKey(test.data); !errors.Is(err, errInvalidGitHubAppKey) {
+				t.Errorf("parseGitHubAppKey() error = %v, want %v", err, errInvalidGitHubAppKey)
+			}
+		})
+	}
+}
+
+func TestGitHubAppTokenSource_Token(t *testing.T) {
+	key := generateTestKey(t)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var minted int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/456/access_tokens" {
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		if err := verifyTestJWT(&key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		minted++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "token-%d", "expires_at": %q}`, minted, start.Add(time.Duration(minted)*time.Hour).Format(time.RFC3339))
+	}))
+	defer ts.Close()
+	originalAPI := githubAPI
+	t.Cleanup(func() { githubAPI = originalAPI })
+	githubAPI = ts.URL
+
+	source := newGitHubAppTokenSource("123", "456", key)
+	for _, test := range []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{name: "first token", want: "token-1"},
+		{name: "cached", elapsed: 30 * time.Minute, want: "token-1"},
+		{name: "refreshed before expiry", elapsed: 55 * time.Minute, want: "token-2"},
+		{name: "cached after refresh", elapsed: 90 * time.Minute, want: "token-2"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			source.now = func() time.Time { return start.Add(test.elapsed) }
+			got, err := source.Token(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGitHubAppTokenSource_Token_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	originalAPI := githubAPI
+	t.Cleanup(func() { githubAPI = originalAPI })
+	githubAPI = ts.URL
+
+	source := newGitHubAppTokenSource("123", "456", generateTestKey(t))
+	if _, err := source.Token(t.Context()); !errors.Is(err, errGitHubAppToken) {
+		t.Errorf("Token() error = %v, want %v", err, errGitHubAppToken)
+	}
+}
+
+func TestRunGH_GitHubApp(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		app  *githubAppTokenSource
+		want string
+	}{
+		{
+			name: "token auth",
+			want: "inherited\n",
+		},
+		{
+			name: "app auth",
+			app: &githubAppTokenSource{
+				token:     "installation-token",
+				expiresAt: time.Now().Add(time.Hour),
+				now:       time.Now,
+			},
+			want: "installation-token\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GH_TOKEN", "inherited")
+			dir := t.TempDir()
+			out := filepath.Join(dir, "token")
+			script := fmt.Sprintf("#!/bin/sh\necho \"$GH_TOKEN\" > %s\n", out)
+			if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			if err := runGH(t.Context(), test.app, "pr", "create"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, string(got)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateCommand_GitHubAuthErrors(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{
+			name:    "unknown auth",
+			args:    []string{"--github-auth=oauth"},
+			wantErr: errInvalidGitHubAuth,
+		},
+		{
+			name:    "app flags with token auth",
+			args:    []string{"--github-app-id=123"},
+			wantErr: errGitHubAppFlagsWithToken,
+		},
+		{
+			name:    "incomplete app auth",
+			args:    []string{"--github-auth=app", "--github-app-id=123"},
+			wantErr: errGitHubAppIncomplete,
+		},
+		{
+			name: "invalid key",
+			args: []string{
+				"--github-auth=app",
+				"--github-app-id=123",
+				"--github-app-installation-id=456",
+				"--github-app-private-key-file=" + keyFile,
+			},
+			wantErr: errInvalidGitHubAppKey,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"librarianops", "generate"}, test.args...)
+			args = append(args, repoFake)
+			if err := Run(t.Context(), args...); !errors.Is(err, test.wantErr) {
+				t.Errorf("Run() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// verifyTestJWT checks the signature and issuer of a JWT created by
+// githubAppTokenSource.
+func verifyTestJWT(key *rsa.PublicKey, jwt string) error {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed JWT")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Issuer != "123" {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	return nil
+}
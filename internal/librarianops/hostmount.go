@@ -0,0 +1,121 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// envHostMount is the environment variable used when --host-mount is not set.
+// Container environments that run librarianops can set it to describe their
+// own mount.
+const envHostMount = "LIBRARIANOPS_HOST_MOUNT"
+
+var errInvalidHostMount = errors.New("--host-mount must be of the form <host-dir>:<local-dir>")
+
+// hostMount describes a directory of the host that is mounted at a local
+// directory, when librarianops itself runs in a container. Paths passed to
+// the container runtime are resolved by the host, so local paths under the
+// mount must be translated to host paths.
+type hostMount struct {
+	// host is the directory on the host.
+	host string
+	// local is the directory where host is mounted, as seen by librarianops.
+	local string
+}
+
+// parseHostMount parses a value of the form <host-dir>:<local-dir>. Either
+// directory may be a Windows path with a drive letter, such as C:\src. The
+// host directory must be absolute, and the local directory must exist; the
+// host directory is not checked further, as it is usually not visible to
+// librarianops.
+func parseHostMount(value string) (*hostMount, error) {
+	var mounts []*hostMount
+	for i, c := range value {
+		if c != ':' {
+			continue
+		}
+		host, local := value[:i], value[i+1:]
+		if isAbsMountPath(host) && isMountPath(local) {
+			mounts = append(mounts, &hostMount{host: host, local: local})
+		}
+	}
+	if len(mounts) != 1 {
+		return nil, fmt.Errorf("%w: %q", errInvalidHostMount, value)
+	}
+	m := mounts[0]
+	local, err := filepath.Abs(m.local)
+	if err != nil {
+		return nil, err
+	}
+	m.local = local
+	info, err := os.Stat(m.local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidHostMount, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %q is not a directory", errInvalidHostMount, m.local)
+	}
+	return m, nil
+}
+
+// isMountPath reports whether path is a non-empty path without colons, other
+// than after a leading Windows drive letter.
+func isMountPath(path string) bool {
+	if len(path) >= 2 && path[1] == ':' && isDriveLetter(path[0]) {
+		path = path[2:]
+		// A drive letter on its own, such as "C:", is not a path.
+		if path == "" {
+			return false
+		}
+	}
+	return path != "" && !strings.Contains(path, ":")
+}
+
+// isAbsMountPath reports whether path is a valid mount path that is absolute
+// on either Unix or Windows.
+func isAbsMountPath(path string) bool {
+	if !isMountPath(path) {
+		return false
+	}
+	if len(path) >= 3 && path[1] == ':' && isDriveLetter(path[0]) {
+		path = path[2:]
+	}
+	return path[0] == '/' || path[0] == '\\'
+}
+
+func isDriveLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// hostPath returns the host path of the local path p. Paths outside of the
+// mount are returned unchanged.
+func (m *hostMount) hostPath(p string) string {
+	if m == nil {
+		return p
+	}
+	rel, err := filepath.Rel(m.local, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	if rel == "." {
+		return m.host
+	}
+	return strings.TrimSuffix(m.host, "/") + "/" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseHostMount(t *testing.T) {
+	local := t.TempDir()
+	for _, test := range []struct {
+		name  string
+		value string
+		want  *hostMount
+	}{
+		{"unix", "/home/user/src:" + local, &hostMount{host: "/home/user/src", local: local}},
+		{"windows host", `C:\Users\user\src:` + local, &hostMount{host: `C:\Users\user\src`, local: local}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseHostMount(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(hostMount{})); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseHostMount_Error(t *testing.T) {
+	local := t.TempDir()
+	file := filepath.Join(local, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name  string
+		value string
+	}{
+		{"no colon", "/home/user/src"},
+		{"empty host", ":" + local},
+		{"empty local", "/home/user/src:"},
+		{"too many colons", "/a:/b:" + local},
+		{"drive letter only", "C:" + local},
+		{"local does not exist", "/home/user/src:" + filepath.Join(local, "missing")},
+		{"local is a file", "/home/user/src:" + file},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseHostMount(test.value); !errors.Is(err, errInvalidHostMount) {
+				t.Errorf("parseHostMount(%q) error = %v, want %v", test.value, err, errInvalidHostMount)
+			}
+		})
+	}
+}
+
+func TestHostPath(t *testing.T) {
+	m := &hostMount{host: "/home/user/src", local: "/workspace"}
+	for _, test := range []struct {
+		name  string
+		mount *hostMount
+		path  string
+		want  string
+	}{
+		{"mount root", m, "/workspace", "/home/user/src"},
+		{"under mount", m, "/workspace/google-cloud-rust", "/home/user/src/google-cloud-rust"},
+		{"outside mount", m, "/workspace-other/repo", "/workspace-other/repo"},
+		{"no mount", nil, "/workspace/repo", "/workspace/repo"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.mount.hostPath(test.path); got != test.want {
+				t.Errorf("hostPath(%q) = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/librarian"
+)
+
+// splitByLibrary splits the generated commit at the tip of branch into one
+// branch and pull request per changed library. Each library branch starts
+// from the parent of the generated commit, and contains the changes to the
+// library's output directory along with the changes outside of every
+// library, such as librarian.yaml, so that each pull request can be merged
+// on its own.
+//
+// Library branches are named by libraryBranch, or by appending the library
+// name to branch if libraryBranch is nil.
+//
+// A failure for one library is logged and does not stop the others; the
+// errors are joined and returned at the end.
+func splitByLibrary(ctx context.Context, repoName string, cfg *config.Config, branch string, libraryBranch func(library string) (string, error), message string, signing *signingOptions, pr *prOptions, app *githubAppTokenSource) error {
+	base := branch + "~1"
+	changes, shared, err := libraryChanges(ctx, cfg, base, branch)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(changes)) {
+		files := append(changes[name], shared...)
+		libBranch := branch + "-" + name
+		if libraryBranch != nil {
+			if libBranch, err = libraryBranch(name); err != nil {
+				errs = append(errs, fmt.Errorf("library %q: %w", name, err))
+				continue
+			}
+		}
+		if err := commitLibrary(ctx, repoName, name, base, branch, libBranch, files, message, signing, pr, app); err != nil {
+			slog.Error("failed to create pull request", "library", name, "error", err)
+			errs = append(errs, fmt.Errorf("library %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// libraryChanges returns the files changed between base and head, keyed by
+// the library whose output directory contains them. Files that are not in
+// any library's output directory are returned in shared.
+func libraryChanges(ctx context.Context, cfg *config.Config, base, head string) (changes map[string][]string, shared []string, err error) {
+	output, err := command.Output(ctx, command.Git, "diff", "--name-only", "--no-renames", "-z", base, head)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs := librarian.LibraryOutputs(cfg)
+	changes = map[string][]string{}
+	for file := range strings.SplitSeq(output, "\x00") {
+		if file == "" {
+			continue
+		}
+		if name := owningLibrary(outputs, file); name != "" {
+			changes[name] = append(changes[name], file)
+		} else {
+			shared = append(shared, file)
+		}
+	}
+	return changes, shared, nil
+}
+
+// owningLibrary returns the name of the library whose output directory
+// contains file, or "" if there is none. If output directories are nested,
+// the innermost one is used.
+func owningLibrary(outputs map[string]string, file string) string {
+	var owner, longest string
+	for name, output := range outputs {
+		output = path.Clean(output)
+		if output == "." || len(output) <= len(longest) {
+			continue
+		}
+		if file == output || strings.HasPrefix(file, output+"/") {
+			owner, longest = name, output
+		}
+	}
+	return owner
+}
+
+// commitLibrary creates libBranch for library from base, commits the given
+// files as they are in head, and pushes the branch and creates a pull request
+// for it.
+func commitLibrary(ctx context.Context, repoName, library, base, head, libBranch string, files []string, message string, signing *signingOptions, pr *prOptions, app *githubAppTokenSource) error {
+	// Force the checkout, as a failure for a previous library may have left
+	// changes in the working tree. All generated changes are committed in
+	// head, so nothing is lost.
+	if err := command.Run(ctx, command.Git, "checkout", "--force", "-B", libBranch, base); err != nil {
+		return err
+	}
+	args := append([]string{"--literal-pathspecs", "restore", "--source=" + head, "--staged", "--worktree", "--"}, files...)
+	if err := command.Run(ctx, command.Git, args...); err != nil {
+		return err
+	}
+	if err := commitChanges(ctx, message, signing); err != nil {
+		return err
+	}
+	if repoName == repoFake {
+		return nil
+	}
+	if err := pushBranch(ctx, pr, app); err != nil {
+		return err
+	}
+	return createPR(ctx, repoName, library, pr, app)
+}
+
+// commitPerLibrary replaces the generated commit at the tip of the current
+// branch with a commit of the changes outside every library, such as
+// librarian.yaml, followed by one commit per changed library. The first
+// commit uses message; each library commit has a message scoped to the
+// library, so that git blame leads to the commit of the library.
+func commitPerLibrary(ctx context.Context, repoName string, cfg *config.Config, message string, signing *signingOptions) error {
+	changes, shared, err := libraryChanges(ctx, cfg, "HEAD~1", "HEAD")
+	if err != nil {
+		return err
+	}
+	// Undo the commit, keeping its changes in the working tree.
+	if err := command.Run(ctx, command.Git, "reset", "--quiet", "HEAD~1"); err != nil {
+		return err
+	}
+	if len(shared) > 0 {
+		if err := commitFiles(ctx, shared, message, signing); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(changes)) {
+		if err := commitFiles(ctx, changes[name], libraryCommitMessage(repoName, name, cfg), signing); err != nil {
+			return fmt.Errorf("library %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// commitFiles commits the changes to files, including their deletion.
+func commitFiles(ctx context.Context, files []string, message string, signing *signingOptions) error {
+	args := append([]string{"--literal-pathspecs", "add", "--all", "--"}, files...)
+	if err := command.Run(ctx, command.Git, args...); err != nil {
+		return err
+	}
+	return commitStaged(ctx, message, signing)
+}
+
+// libraryCommitMessage returns the message of the commit for library with
+// --commit-per-library. If the googleapis commit is known, a Source-Link
+// trailer links to it.
+func libraryCommitMessage(repoName, library string, cfg *config.Config) string {
+	title, body := changeDescription(repoName, library)
+	message := title + "\n\n" + body
+	if cfg.Sources != nil && cfg.Sources.Googleapis != nil && cfg.Sources.Googleapis.Commit != "" {
+		message += "\n\nSource-Link: https://github.com/googleapis/googleapis/commit/" + cfg.Sources.Googleapis.Commit
+	}
+	return message
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package librarianops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/sample"
+	"github.com/googleapis/librarian/internal/testhelper"
+)
+
+func TestSplitByLibrary(t *testing.T) {
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	cfg := sample.Config()
+	writeTestFiles(t, map[string]string{
+		config.LibrarianYAML:                       "version: v1\n",
+		filepath.Join(sample.Lib1Output, "lib.rs"): "v1",
+		filepath.Join(sample.Lib2Output, "lib.rs"): "v1",
+		filepath.Join(sample.Lib2Output, "old.rs"): "v1",
+	})
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial commit")
+	branch := branchName(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), nil)
+	if err := createBranch(t.Context(), branch); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, map[string]string{
+		config.LibrarianYAML:                       "version: v2\n",
+		filepath.Join(sample.Lib1Output, "lib.rs"): "v2",
+		filepath.Join(sample.Lib2Output, "lib.rs"): "v2",
+	})
+	if err := os.Remove(filepath.Join(sample.Lib2Output, "old.rs")); err != nil {
+		t.Fatal(err)
+	}
+	if err := commitChanges(t.Context(), commitTitle, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := splitByLibrary(t.Context(), repoFake, cfg, branch, nil, commitTitle, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		library string
+		want    []string
+	}{
+		{
+			library: sample.Lib1Name,
+			want: []string{
+				config.LibrarianYAML,
+				"src/storage/lib.rs",
+			},
+		},
+		{
+			library: sample.Lib2Name,
+			want: []string{
+				config.LibrarianYAML,
+				"src/gax-internal/lib.rs",
+				"src/gax-internal/old.rs",
+			},
+		},
+	} {
+		t.Run(test.library, func(t *testing.T) {
+			out, err := command.Output(t.Context(), command.Git, "diff", "--name-only", branch+"~1", branch+"-"+test.library)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, strings.Fields(out)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSplitByLibrary_LibraryBranch(t *testing.T) {
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	cfg := sample.Config()
+	writeTestFiles(t, map[string]string{
+		filepath.Join(sample.Lib1Output, "lib.rs"): "v1",
+		filepath.Join(sample.Lib2Output, "lib.rs"): "v1",
+	})
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial commit")
+	branch := "generate-all"
+	if err := createBranch(t.Context(), branch); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, map[string]string{
+		filepath.Join(sample.Lib1Output, "lib.rs"): "v2",
+		filepath.Join(sample.Lib2Output, "lib.rs"): "v2",
+	})
+	if err := commitChanges(t.Context(), commitTitle, nil); err != nil {
+		t.Fatal(err)
+	}
+	libraryBranch := func(library string) (string, error) {
+		return "generate/" + library, nil
+	}
+	if err := splitByLibrary(t.Context(), repoFake, cfg, branch, libraryBranch, commitTitle, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, library := range []string{sample.Lib1Name, sample.Lib2Name} {
+		if _, err := command.Output(t.Context(), command.Git, "rev-parse", "--verify", "generate/"+library); err != nil {
+			t.Errorf("branch for %s not created: %v", library, err)
+		}
+	}
+}
+
+func TestCommitPerLibrary(t *testing.T) {
+	testhelper.ContinueInNewGitRepository(t, t.TempDir())
+	cfg := sample.Config()
+	cfg.Sources = &config.Sources{Googleapis: &config.Source{Commit: "abc123"}}
+	writeTestFiles(t, map[string]string{
+		config.LibrarianYAML:                       "version: v1\n",
+		filepath.Join(sample.Lib1Output, "lib.rs"): "v1",
+		filepath.Join(sample.Lib2Output, "lib.rs"): "v1",
+		filepath.Join(sample.Lib2Output, "old.rs"): "v1",
+	})
+	testhelper.RunGit(t, "add", ".")
+	testhelper.RunGit(t, "commit", "-m", "initial commit")
+	writeTestFiles(t, map[string]string{
+		config.LibrarianYAML:                       "version: v2\n",
+		filepath.Join(sample.Lib1Output, "lib.rs"): "v2",
+		filepath.Join(sample.Lib2Output, "new.rs"): "v2",
+	})
+	if err := os.Remove(filepath.Join(sample.Lib2Output, "old.rs")); err != nil {
+		t.Fatal(err)
+	}
+	if err := commitChanges(t.Context(), commitTitle, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := commitPerLibrary(t.Context(), repoFake, cfg, commitTitle, nil); err != nil {
+		t.Fatal(err)
+	}
+	out, err := command.Output(t.Context(), command.Git, "log", "--reverse", "--format=%B%x00", "--name-only", "HEAD~3..HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Fields(strings.ReplaceAll(out, "\x00", " |"))
+	want := strings.Fields(fmt.Sprintf(`
+		%s |
+		librarian.yaml
+		feat(%[2]s): update googleapis and regenerate
+		Update googleapis to the latest commit and regenerate %[2]s.
+		Source-Link: https://github.com/googleapis/googleapis/commit/abc123 |
+		%[3]s/new.rs
+		%[3]s/old.rs
+		feat(%[4]s): update googleapis and regenerate
+		Update googleapis to the latest commit and regenerate %[4]s.
+		Source-Link: https://github.com/googleapis/googleapis/commit/abc123 |
+		%[5]s/lib.rs`,
+		commitTitle, sample.Lib2Name, sample.Lib2Output, sample.Lib1Name, sample.Lib1Output))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	// Every change is committed.
+	status, err := command.Output(t.Context(), command.Git, "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != "" {
+		t.Errorf("working tree not clean:\n%s", status)
+	}
+}
+
+func TestOwningLibrary(t *testing.T) {
+	outputs := map[string]string{
+		"storage":     "src/storage",
+		"echo-server": "src/storage/echo-server/",
+		"root":        ".",
+	}
+	for _, test := range []struct {
+		file string
+		want string
+	}{
+		{"src/storage/lib.rs", "storage"},
+		{"src/storage", "storage"},
+		{"src/storage/echo-server/main.rs", "echo-server"},
+		{"src/storage-v2/lib.rs", ""},
+		{"librarian.yaml", ""},
+	} {
+		t.Run(test.file, func(t *testing.T) {
+			if got := owningLibrary(outputs, test.file); got != test.want {
+				t.Errorf("owningLibrary(%q) = %q, want %q", test.file, got, test.want)
+			}
+		})
+	}
+}
+
+func writeTestFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -167,6 +167,45 @@ func fromAPI(cfg *config.Config, api *serviceconfig.API, library *config.Library
 	}
 }
 
+// BackfillAPI fills the fields of api that are not set from the equivalent
+// fields of metadata, which is typically read from an existing
+// .repo-metadata.json file:
+//   - APIDescription fills Description
+//   - APIID fills ServiceName
+//   - APIShortname fills ShortName
+//   - IssueTracker fills NewIssueURI
+//   - ProductDocumentation fills DocumentationURI
+//   - ReleaseLevel fills ReleaseLevels for metadata.Language
+//
+// Values already present in api, from sdk.yaml or the service config, take
+// precedence over metadata. A release level for metadata.Language is only
+// added if api has neither a level for that language nor for all languages.
+func (metadata *RepoMetadata) BackfillAPI(api *serviceconfig.API) {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&api.Description, metadata.APIDescription)
+	fill(&api.ServiceName, metadata.APIID)
+	fill(&api.ShortName, metadata.APIShortname)
+	fill(&api.NewIssueURI, metadata.IssueTracker)
+	fill(&api.DocumentationURI, metadata.ProductDocumentation)
+	if metadata.Language == "" || metadata.ReleaseLevel == "" {
+		return
+	}
+	if _, ok := api.ReleaseLevels[metadata.Language]; ok {
+		return
+	}
+	if _, ok := api.ReleaseLevels[config.LanguageAll]; ok {
+		return
+	}
+	if api.ReleaseLevels == nil {
+		api.ReleaseLevels = map[string]string{}
+	}
+	api.ReleaseLevels[metadata.Language] = metadata.ReleaseLevel
+}
+
 // extractBaseProductURL extracts the base product URL from a documentation URI.
 // Example: "https://cloud.google.com/secret-manager/docs/overview" -> "https://cloud.google.com/secret-manager/"
 func extractBaseProductURL(docURI string) string {
@@ -201,7 +240,7 @@ func Read(libraryOutputDir string) (*RepoMetadata, error) {
 
 	var metadata *RepoMetadata
 	if err := json.Unmarshal(data, &metadata); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(libraryOutputDir, repoMetadataFile), err)
 	}
 	return metadata, nil
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/serviceconfig"
 )
 
 func TestFromLibrary(t *testing.T) {
@@ -248,6 +249,14 @@ func TestRead_Error(t *testing.T) {
 			},
 			// We can't specify the exact error here.
 		},
+		{
+			name: "wrong field type",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, repoMetadataFile), []byte(`{"release_level": 1}`), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
 		{
 			name:    "no file",
 			setup:   func(t *testing.T, dir string) {},
@@ -268,6 +277,96 @@ func TestRead_Error(t *testing.T) {
 	}
 }
 
+func TestBackfillAPI(t *testing.T) {
+	metadata := &RepoMetadata{
+		APIDescription:       "metadata description",
+		APIID:                "secretmanager.googleapis.com",
+		APIShortname:         "secretmanager",
+		IssueTracker:         "https://example.com/issues",
+		Language:             config.LanguagePython,
+		ProductDocumentation: "https://example.com/docs",
+		ReleaseLevel:         "preview",
+	}
+	for _, test := range []struct {
+		name string
+		api  *serviceconfig.API
+		want *serviceconfig.API
+	}{
+		{
+			name: "empty API",
+			api:  &serviceconfig.API{Path: "google/cloud/secretmanager/v1"},
+			want: &serviceconfig.API{
+				Path:             "google/cloud/secretmanager/v1",
+				Description:      "metadata description",
+				DocumentationURI: "https://example.com/docs",
+				NewIssueURI:      "https://example.com/issues",
+				ReleaseLevels:    map[string]string{config.LanguagePython: "preview"},
+				ServiceName:      "secretmanager.googleapis.com",
+				ShortName:        "secretmanager",
+			},
+		},
+		{
+			name: "API values take precedence",
+			api: &serviceconfig.API{
+				Path:             "google/cloud/secretmanager/v1",
+				Description:      "service config description",
+				DocumentationURI: "https://cloud.google.com/secret-manager/docs/overview",
+				NewIssueURI:      "https://issuetracker.google.com/issues/new",
+				ReleaseLevels:    map[string]string{config.LanguagePython: "stable"},
+				ServiceName:      "secretmanager.googleapis.com",
+				ShortName:        "secrets",
+			},
+			want: &serviceconfig.API{
+				Path:             "google/cloud/secretmanager/v1",
+				Description:      "service config description",
+				DocumentationURI: "https://cloud.google.com/secret-manager/docs/overview",
+				NewIssueURI:      "https://issuetracker.google.com/issues/new",
+				ReleaseLevels:    map[string]string{config.LanguagePython: "stable"},
+				ServiceName:      "secretmanager.googleapis.com",
+				ShortName:        "secrets",
+			},
+		},
+		{
+			name: "release level for all languages takes precedence",
+			api: &serviceconfig.API{
+				ReleaseLevels: map[string]string{config.LanguageAll: "stable"},
+			},
+			want: &serviceconfig.API{
+				Description:      "metadata description",
+				DocumentationURI: "https://example.com/docs",
+				NewIssueURI:      "https://example.com/issues",
+				ReleaseLevels:    map[string]string{config.LanguageAll: "stable"},
+				ServiceName:      "secretmanager.googleapis.com",
+				ShortName:        "secretmanager",
+			},
+		},
+		{
+			name: "release level for other language is kept",
+			api: &serviceconfig.API{
+				ReleaseLevels: map[string]string{config.LanguageGo: "stable"},
+			},
+			want: &serviceconfig.API{
+				Description:      "metadata description",
+				DocumentationURI: "https://example.com/docs",
+				NewIssueURI:      "https://example.com/issues",
+				ReleaseLevels: map[string]string{
+					config.LanguageGo:     "stable",
+					config.LanguagePython: "preview",
+				},
+				ServiceName: "secretmanager.googleapis.com",
+				ShortName:   "secretmanager",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			metadata.BackfillAPI(test.api)
+			if diff := cmp.Diff(test.want, test.api); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestWriteJSON(t *testing.T) {
 	tmpDir := t.TempDir()
 	data := struct {
@@ -271,6 +271,17 @@ type DeriveNextOptions struct {
 	// This has no effect on prerelease versions unless BumpVersionCore is also
 	// enabled.
 	DowngradePreGAChanges bool
+
+	// Prerelease, if set, makes the next version a prerelease with this
+	// identifier, such as "rc" or "beta". When the current version is already
+	// a prerelease with the same identifier, only its prerelease number is
+	// bumped. When it is a prerelease with a different identifier, the version
+	// core is kept and the prerelease number starts at 1. Otherwise, the
+	// version core is bumped and the prerelease number starts at 1, so that
+	// "2.0.0" followed by a [Minor] change becomes "2.1.0-rc.1".
+	//
+	// BumpVersionCore is ignored when Prerelease is set.
+	Prerelease string
 }
 
 // DeriveNext determines the appropriate SemVer version bump based on the
@@ -290,6 +301,10 @@ func DeriveNext(changeLevel ChangeLevel, currentVersion string, opts DeriveNextO
 
 // deriveNext implements next version derivation based on the [DeriveNextOptions].
 func deriveNext(changeLevel ChangeLevel, v Version, opts DeriveNextOptions) string {
+	if opts.Prerelease != "" {
+		return deriveNextPrerelease(changeLevel, v, opts)
+	}
+
 	// Only bump the prerelease version number.
 	if v.Prerelease != "" && !opts.BumpVersionCore {
 		// Append prerelease number if there isn't one.
@@ -310,6 +325,37 @@ func deriveNext(changeLevel ChangeLevel, v Version, opts DeriveNextOptions) stri
 		*v.PrereleaseNumber = 1
 	}
 
+	bumpVersionCore(changeLevel, &v, opts)
+	return v.String()
+}
+
+// deriveNextPrerelease implements next version derivation when
+// [DeriveNextOptions.Prerelease] is set.
+func deriveNextPrerelease(changeLevel ChangeLevel, v Version, opts DeriveNextOptions) string {
+	if v.Prerelease == opts.Prerelease {
+		if v.PrereleaseNumber == nil {
+			v.PrereleaseSeparator = "."
+			v.PrereleaseNumber = new(int)
+		}
+		*v.PrereleaseNumber++
+		return v.String()
+	}
+	// A prerelease with a different identifier already targets the version
+	// core, so only a stable version needs its core bumped.
+	if v.Prerelease == "" {
+		bumpVersionCore(changeLevel, &v, opts)
+	}
+	first := 1
+	v.Prerelease = opts.Prerelease
+	v.PrereleaseSeparator = "."
+	v.PrereleaseNumber = &first
+	v.SpecVersion = SpecV2
+	return v.String()
+}
+
+// bumpVersionCore bumps the major, minor or patch segment of v according to
+// changeLevel.
+func bumpVersionCore(changeLevel ChangeLevel, v *Version, opts DeriveNextOptions) {
 	// Breaking changes result in a minor bump for pre-1.0.0 versions across
 	// all languages. Some languages, however, prefer to downgrade all pre-1.0.0
 	// changes e.g. Rust.
@@ -321,7 +367,6 @@ func deriveNext(changeLevel ChangeLevel, v Version, opts DeriveNextOptions) stri
 		}
 	}
 
-	// Bump the version core.
 	switch changeLevel {
 	case Major:
 		v.Major++
@@ -333,8 +378,6 @@ func deriveNext(changeLevel ChangeLevel, v Version, opts DeriveNextOptions) stri
 	case Patch:
 		v.Patch++
 	}
-
-	return v.String()
 }
 
 var (
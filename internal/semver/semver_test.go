@@ -519,6 +519,48 @@ func TestDeriveNextOptions_DeriveNext(t *testing.T) {
 			expectedVersion: "0.2.3-alpha.3",
 			opts:            DeriveNextOptions{DowngradePreGAChanges: true},
 		},
+		{
+			name:            "stable to first prerelease",
+			highestChange:   Minor,
+			currentVersion:  "2.0.0",
+			expectedVersion: "2.1.0-rc.1",
+			opts:            DeriveNextOptions{Prerelease: "rc"},
+		},
+		{
+			name:            "prerelease of same identifier bumps number",
+			highestChange:   Major,
+			currentVersion:  "2.1.0-rc.1",
+			expectedVersion: "2.1.0-rc.2",
+			opts:            DeriveNextOptions{Prerelease: "rc"},
+		},
+		{
+			name:            "prerelease of same identifier without number",
+			highestChange:   Minor,
+			currentVersion:  "2.1.0-rc",
+			expectedVersion: "2.1.0-rc.1",
+			opts:            DeriveNextOptions{Prerelease: "rc"},
+		},
+		{
+			name:            "prerelease of different identifier keeps core",
+			highestChange:   Minor,
+			currentVersion:  "2.1.0-beta.3",
+			expectedVersion: "2.1.0-rc.1",
+			opts:            DeriveNextOptions{Prerelease: "rc"},
+		},
+		{
+			name:            "prerelease ignores bump core option",
+			highestChange:   Minor,
+			currentVersion:  "2.1.0-rc.1",
+			expectedVersion: "2.1.0-rc.2",
+			opts:            DeriveNextOptions{Prerelease: "rc", BumpVersionCore: true},
+		},
+		{
+			name:            "pre-1.0.0 prerelease with downgrade",
+			highestChange:   Minor,
+			currentVersion:  "0.2.3",
+			expectedVersion: "0.2.4-beta.1",
+			opts:            DeriveNextOptions{Prerelease: "beta", DowngradePreGAChanges: true},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			nextVersion, err := DeriveNext(test.highestChange, test.currentVersion, test.opts)
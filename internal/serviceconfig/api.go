@@ -108,6 +108,12 @@ type API struct {
 	// Map key is the language name (e.g., "python", "rust").
 	// Optional. If omitted, all languages use GRPCRest by default.
 	Transports map[string]Transport `yaml:"transports,omitempty"`
+
+	// Mixins maps the mixin services declared in the service config, such as
+	// google.cloud.location.Locations, to the fully qualified names of the
+	// mixin methods the API implements. They are only resolved by
+	// [FindWithMixins], and are never read from sdk.yaml.
+	Mixins map[string][]string `yaml:"-"`
 }
 
 // Transport gets transport for a given language.
@@ -0,0 +1,70 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceconfig
+
+import (
+	"slices"
+	"strings"
+)
+
+// mixinServices lists the services that may be mixed into an API by
+// declaring them in the apis section of its service config.
+var mixinServices = []string{
+	"google.cloud.location.Locations",
+	"google.iam.v1.IAMPolicy",
+	"google.longrunning.Operations",
+}
+
+// FindWithMixins is like [Find], but also resolves the mixins declared in the
+// service config into [API.Mixins].
+func FindWithMixins(googleapisDir, path string, language string) (*API, error) {
+	return find(googleapisDir, path, language, true)
+}
+
+// findMixins returns the mixins declared in the apis section of cfg, keyed by
+// service name. The methods of each mixin are those with an HTTP rule or
+// listed in the apis section, sorted and without duplicates.
+func findMixins(cfg *Service) map[string][]string {
+	var mixins map[string][]string
+	for _, api := range cfg.GetApis() {
+		name := api.GetName()
+		if !slices.Contains(mixinServices, name) {
+			continue
+		}
+		if mixins == nil {
+			mixins = map[string][]string{}
+		}
+		methods := mixins[name]
+		for _, method := range api.GetMethods() {
+			methods = append(methods, name+"."+method.GetName())
+		}
+		mixins[name] = methods
+	}
+	for _, rule := range cfg.GetHttp().GetRules() {
+		selector := rule.GetSelector()
+		i := strings.LastIndex(selector, ".")
+		if i == -1 {
+			continue
+		}
+		if methods, ok := mixins[selector[:i]]; ok {
+			mixins[selector[:i]] = append(methods, selector)
+		}
+	}
+	for name, methods := range mixins {
+		slices.Sort(methods)
+		mixins[name] = slices.Compact(methods)
+	}
+	return mixins
+}
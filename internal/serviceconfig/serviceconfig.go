@@ -111,6 +111,10 @@ func findAPI(path string) *API {
 // it does not live under https://github.com/googleapis/googleapis.
 // For this API only, googleapisDir should point to showcase source dir instead.
 func Find(googleapisDir, path string, language string) (*API, error) {
+	return find(googleapisDir, path, language, false)
+}
+
+func find(googleapisDir, path string, language string, withMixins bool) (*API, error) {
 	result := findAPI(path)
 
 	// Find the service config if it hasn't been specified.
@@ -130,6 +134,9 @@ func Find(googleapisDir, path string, language string) (*API, error) {
 			return nil, err
 		}
 		result = populateFromServiceConfig(result, serviceConfig)
+		if withMixins {
+			result.Mixins = findMixins(serviceConfig)
+		}
 	}
 	return result, nil
 }
@@ -456,3 +456,107 @@ func TestSortAPIs(t *testing.T) {
 		})
 	}
 }
+
+func TestFindWithMixins(t *testing.T) {
+	got, err := FindWithMixins(googleapisDir, "google/cloud/secretmanager/v1", config.LanguageGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"google.cloud.location.Locations": {
+			"google.cloud.location.Locations.GetLocation",
+			"google.cloud.location.Locations.ListLocations",
+		},
+	}
+	if diff := cmp.Diff(want, got.Mixins); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Find does not resolve mixins.
+	got, err = Find(googleapisDir, "google/cloud/secretmanager/v1", config.LanguageGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Mixins != nil {
+		t.Errorf("Find() resolved mixins %v, want nil", got.Mixins)
+	}
+}
+
+func TestFindMixins(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content string
+		want    map[string][]string
+	}{
+		{
+			name: "no mixins",
+			content: `type: google.api.Service
+name: example.googleapis.com
+apis:
+  - name: google.example.v1.ExampleService
+`,
+		},
+		{
+			name: "locations mixin",
+			content: `type: google.api.Service
+name: example.googleapis.com
+apis:
+  - name: google.cloud.location.Locations
+  - name: google.example.v1.ExampleService
+http:
+  rules:
+    - selector: google.cloud.location.Locations.ListLocations
+      get: '/v1/{name=projects/*}/locations'
+    - selector: google.cloud.location.Locations.GetLocation
+      get: '/v1/{name=projects/*/locations/*}'
+    - selector: google.example.v1.ExampleService.GetExample
+      get: '/v1/{name=examples/*}'
+`,
+			want: map[string][]string{
+				"google.cloud.location.Locations": {
+					"google.cloud.location.Locations.GetLocation",
+					"google.cloud.location.Locations.ListLocations",
+				},
+			},
+		},
+		{
+			name: "methods are not duplicated",
+			content: `type: google.api.Service
+name: example.googleapis.com
+apis:
+  - name: google.iam.v1.IAMPolicy
+    methods:
+      - name: SetIamPolicy
+  - name: google.cloud.location.Locations
+  - name: google.iam.v1.IAMPolicy
+    methods:
+      - name: GetIamPolicy
+http:
+  rules:
+    - selector: google.iam.v1.IAMPolicy.GetIamPolicy
+      get: '/v1/{resource=examples/*}:getIamPolicy'
+`,
+			want: map[string][]string{
+				"google.iam.v1.IAMPolicy": {
+					"google.iam.v1.IAMPolicy.GetIamPolicy",
+					"google.iam.v1.IAMPolicy.SetIamPolicy",
+				},
+				"google.cloud.location.Locations": nil,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "service.yaml")
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Read(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, findMixins(cfg)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
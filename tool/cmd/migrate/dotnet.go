@@ -24,7 +24,6 @@ import (
 	"sort"
 
 	"github.com/googleapis/librarian/internal/config"
-	"github.com/googleapis/librarian/internal/librarian"
 )
 
 // DotnetAPIsJSON represents the root of the apis.json file.
@@ -66,8 +65,8 @@ func runDotnetMigration(ctx context.Context, repoPath string) error {
 	// The directory name in Googleapis is present for migration code to look
 	// up API details. It shouldn't be persisted.
 	cfg.Sources.Googleapis.Dir = ""
-	if err := librarian.RunTidyOnConfig(ctx, repoPath, cfg); err != nil {
-		return fmt.Errorf("%w: %w", errTidyFailed, err)
+	if err := writeConfig(ctx, repoPath, cfg); err != nil {
+		return err
 	}
 	log.Printf("Successfully migrated %d .NET libraries", len(cfg.Libraries))
 	return nil
@@ -43,7 +43,7 @@ func fetchRepoWithCommit(ctx context.Context, endpoints *fetch.Endpoints, org, n
 		Name:   name,
 		Branch: commitish,
 	}
-	commit, sha256, err := fetch.LatestCommitAndChecksum(endpoints, repo)
+	commit, sha256, err := fetch.LatestCommitAndChecksum(ctx, endpoints, repo)
 	if err != nil {
 		return nil, err
 	}
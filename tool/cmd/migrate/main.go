@@ -16,13 +16,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+
+	"github.com/googleapis/librarian/internal/command"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/librarian"
+	"github.com/googleapis/librarian/internal/yaml"
 )
 
 const (
@@ -33,6 +41,12 @@ var (
 	errRepoNotFound = errors.New("repo argument is required")
 	errTidyFailed   = errors.New("librarian tidy failed")
 	errFetchSource  = errors.New("cannot fetch source")
+	errConfigDrift  = errors.New("librarian.yaml differs from the migrated configuration")
+
+	// validateOnly is set by the -validate-only flag. When it is set, the
+	// migrated configuration is compared with the existing librarian.yaml
+	// instead of being written.
+	validateOnly bool
 )
 
 func main() {
@@ -46,6 +60,7 @@ func run(ctx context.Context, args []string) error {
 	// TODO(https://github.com/googleapis/librarian/issues/4567): change this
 	// to use github.com/urfave/cli/v3 consistently with other tooling.
 	flagSet := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flagSet.BoolVar(&validateOnly, "validate-only", false, "print a diff against the existing librarian.yaml instead of writing it, and fail if they differ")
 	if err := flagSet.Parse(args); err != nil {
 		return err
 	}
@@ -62,6 +77,8 @@ func run(ctx context.Context, args []string) error {
 	switch base {
 	case "google-cloud-dotnet":
 		return runDotnetMigration(ctx, abs)
+	case "google-cloud-node":
+		return runNodejsMigration(ctx, abs)
 	case "google-cloud-php":
 		return runPHPMigration(ctx, abs)
 	case "google-cloud-ruby":
@@ -70,3 +87,69 @@ func run(ctx context.Context, args []string) error {
 		return fmt.Errorf("invalid path: %q", repoPath)
 	}
 }
+
+// writeConfig tidies cfg and writes it to librarian.yaml in repoPath. If
+// validateOnly is set, it instead prints a diff between the existing
+// librarian.yaml and cfg, and returns errConfigDrift if they differ.
+func writeConfig(ctx context.Context, repoPath string, cfg *config.Config) error {
+	if !validateOnly {
+		if err := librarian.RunTidyOnConfig(ctx, repoPath, cfg); err != nil {
+			return fmt.Errorf("%w: %w", errTidyFailed, err)
+		}
+		return nil
+	}
+	cfg, err := librarian.TidyConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errTidyFailed, err)
+	}
+	return validateConfig(ctx, repoPath, cfg)
+}
+
+// validateConfig compares cfg with the librarian.yaml in repoPath, which is
+// treated as empty if it does not exist. Both are marshaled before comparing,
+// so that formatting and comments do not count as differences. If they
+// differ, a unified diff is printed and errConfigDrift is returned.
+func validateConfig(ctx context.Context, repoPath string, cfg *config.Config) error {
+	var existing []byte
+	existingCfg, err := yaml.Read[config.Config](filepath.Join(repoPath, config.LibrarianYAML))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if existing, err = yaml.Marshal(existingCfg); err != nil {
+			return err
+		}
+	}
+	migrated, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(existing, migrated) {
+		log.Printf("%s is up to date", config.LibrarianYAML)
+		return nil
+	}
+	// Write both versions under descriptive directory names, so that the diff
+	// headers show which side is which.
+	dir, err := os.MkdirTemp("", "migrate-validate-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string][]byte{"existing": existing, "migrated": migrated} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, config.LibrarianYAML), content, 0o644); err != nil {
+			return err
+		}
+	}
+	err = command.RunStreamingInDir(ctx, dir, command.Git, "diff", "--no-index", "--no-color", "--",
+		filepath.Join("existing", config.LibrarianYAML), filepath.Join("migrated", config.LibrarianYAML))
+	// git diff exits with status 1 when the files differ.
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return err
+	}
+	return errConfigDrift
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/yaml"
+)
+
+func TestWriteConfig_ValidateOnly(t *testing.T) {
+	newConfig := func() *config.Config {
+		return &config.Config{
+			Language: config.LanguageNodejs,
+			Sources: &config.Sources{
+				Googleapis: &config.Source{Commit: "abcd123", SHA256: "sha123"},
+			},
+			Libraries: []*config.Library{
+				{Name: "google-cloud-batch", APIs: []*config.API{{Path: "google/cloud/batch/v1"}}},
+			},
+		}
+	}
+	for _, test := range []struct {
+		name     string
+		existing *config.Config
+		wantErr  error
+	}{
+		{
+			name:     "up to date",
+			existing: newConfig(),
+		},
+		{
+			name: "drift",
+			existing: func() *config.Config {
+				cfg := newConfig()
+				cfg.Sources.Googleapis.Commit = "old123"
+				return cfg
+			}(),
+			wantErr: errConfigDrift,
+		},
+		{
+			name:    "no existing config",
+			wantErr: errConfigDrift,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Cleanup(func() { validateOnly = false })
+			validateOnly = true
+			repoPath := t.TempDir()
+			path := filepath.Join(repoPath, config.LibrarianYAML)
+			if test.existing != nil {
+				if err := yaml.Write(path, test.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, _ := os.ReadFile(path)
+
+			err := writeConfig(t.Context(), repoPath, newConfig())
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("writeConfig() error = %v, want %v", err, test.wantErr)
+			}
+			after, _ := os.ReadFile(path)
+			if string(before) != string(after) {
+				t.Errorf("writeConfig() modified %s in validate-only mode", config.LibrarianYAML)
+			}
+		})
+	}
+}
+
+func TestWriteConfig_TidyFailed(t *testing.T) {
+	for _, validate := range []bool{false, true} {
+		t.Cleanup(func() { validateOnly = false })
+		validateOnly = validate
+		cfg := &config.Config{Language: config.LanguageNodejs}
+		if err := writeConfig(t.Context(), t.TempDir(), cfg); !errors.Is(err, errTidyFailed) {
+			t.Errorf("writeConfig() with validateOnly=%t error = %v, want %v", validate, err, errTidyFailed)
+		}
+	}
+}
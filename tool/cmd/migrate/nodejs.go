@@ -0,0 +1,214 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/yaml"
+)
+
+// nodejsPackagesDir is the directory of google-cloud-node that holds one
+// directory per package.
+const nodejsPackagesDir = "packages"
+
+var (
+	// nodejsAPIPath matches the source of an .OwlBot.yaml deep-copy-regex
+	// entry. The version is either a literal, such as v1, or the pattern
+	// (v.*), which copies every version of the API.
+	nodejsAPIPath = regexp.MustCompile(`^/(.+?)/(v\d+\w*|\(v\.\*\))/[^/]*-nodejs`)
+	apiVersionDir = regexp.MustCompile(`^v\d+\w*$`)
+)
+
+// packageJSON is the subset of a package.json file read by the migration.
+type packageJSON struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func runNodejsMigration(ctx context.Context, repoPath string) error {
+	src, err := fetchSource(ctx)
+	if err != nil {
+		return errFetchSource
+	}
+	cfg := &config.Config{
+		Language: config.LanguageNodejs,
+		Sources: &config.Sources{
+			Googleapis: src,
+		},
+		Default: &config.Default{
+			Output: nodejsPackagesDir,
+		},
+	}
+	if err := mergeConfig(cfg, repoPath); err != nil {
+		return err
+	}
+	existingLibs, err := parseExistingLibraries(repoPath)
+	if err != nil {
+		return err
+	}
+	libs, err := findNodejsLibraries(src.Dir, repoPath)
+	if err != nil {
+		return err
+	}
+	cfg.Libraries = mergeLibs(existingLibs, libs)
+	// The directory name in Googleapis is present for migration code to look
+	// up API details. It shouldn't be persisted.
+	cfg.Sources.Googleapis.Dir = ""
+	if err := writeConfig(ctx, repoPath, cfg); err != nil {
+		return err
+	}
+	log.Printf("Successfully migrated Node.js libraries configuration")
+	return nil
+}
+
+// findNodejsLibraries returns a library for each directory in the packages
+// directory of repoPath that contains a package.json file. Packages without
+// generated APIs are handwritten, and are given an explicit output.
+func findNodejsLibraries(googleapisDir, repoPath string) ([]*config.Library, error) {
+	entries, err := os.ReadDir(filepath.Join(repoPath, nodejsPackagesDir))
+	if err != nil {
+		return nil, fmt.Errorf("reading packages directory: %w", err)
+	}
+	var libraries []*config.Library
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		dir := filepath.Join(repoPath, nodejsPackagesDir, name)
+		pkg, err := readPackageJSON(filepath.Join(dir, "package.json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lib := &config.Library{
+			Name:    name,
+			Version: pkg.Version,
+		}
+		if pkg.Name != "" && pkg.Name != defaultNodejsPackageName(name) {
+			lib.Nodejs = &config.NodejsPackage{
+				PackageName: pkg.Name,
+			}
+		}
+		owlBotPath := filepath.Join(dir, ".OwlBot.yaml")
+		if fileExists(owlBotPath) {
+			lib.APIs, err = parseNodejsAPIsFromOwlBot(googleapisDir, owlBotPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(lib.APIs) == 0 {
+			lib.Output = filepath.ToSlash(filepath.Join(nodejsPackagesDir, name))
+		}
+		libraries = append(libraries, lib)
+	}
+	return libraries, nil
+}
+
+func readPackageJSON(path string) (*packageJSON, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &pkg, nil
+}
+
+// parseNodejsAPIsFromOwlBot returns the APIs copied by the deep-copy-regex
+// sources of an .OwlBot.yaml file, in the order they first appear. A source
+// that copies every version of an API is resolved to the versions present in
+// googleapisDir.
+func parseNodejsAPIsFromOwlBot(googleapisDir, owlBotPath string) ([]*config.API, error) {
+	data, err := os.ReadFile(owlBotPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading OwlBot config %s: %w", owlBotPath, err)
+	}
+	owlbot, err := yaml.Unmarshal[owlbotYaml](data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OwlBot config %s: %w", owlBotPath, err)
+	}
+	var paths []string
+	for _, src := range owlbot.DeepCopyRegex {
+		matches := nodejsAPIPath.FindStringSubmatch(src.Source)
+		if len(matches) != 3 {
+			continue
+		}
+		versions := []string{matches[2]}
+		if matches[2] == "(v.*)" {
+			versions, err = findAPIVersions(googleapisDir, matches[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, version := range versions {
+			path := matches[1] + "/" + version
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	var apis []*config.API
+	for _, path := range paths {
+		apis = append(apis, &config.API{Path: path})
+	}
+	return apis, nil
+}
+
+// findAPIVersions returns the versions of the API in dir of googleapisDir,
+// which are the version directories that contain a BUILD.bazel file.
+func findAPIVersions(googleapisDir, dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(googleapisDir, dir))
+	if err != nil {
+		return nil, fmt.Errorf("reading API directory %s: %w", dir, err)
+	}
+	var versions []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !apiVersionDir.MatchString(entry.Name()) {
+			continue
+		}
+		if fileExists(filepath.Join(googleapisDir, dir, entry.Name(), "BUILD.bazel")) {
+			versions = append(versions, entry.Name())
+		}
+	}
+	return versions, nil
+}
+
+// defaultNodejsPackageName returns the npm package name that librarian
+// derives from a library name, by splitting it on the second dash. For
+// example, google-cloud-batch becomes @google-cloud/batch.
+func defaultNodejsPackageName(name string) string {
+	parts := strings.SplitN(name, "-", 3)
+	if len(parts) < 3 {
+		return name
+	}
+	return fmt.Sprintf("@%s-%s/%s", parts[0], parts[1], parts[2])
+}
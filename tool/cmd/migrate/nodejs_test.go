@@ -0,0 +1,198 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/librarian/internal/config"
+	"github.com/googleapis/librarian/internal/yaml"
+)
+
+func TestRunNodejsMigration(t *testing.T) {
+	oldFetchSource := fetchSource
+	t.Cleanup(func() {
+		fetchSource = oldFetchSource
+	})
+	absGoogleapis, err := filepath.Abs(filepath.Join("testdata", "googleapis"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetchSource = func(ctx context.Context) (*config.Source, error) {
+		return &config.Source{
+			Commit: "abcd123",
+			SHA256: "sha123",
+			Dir:    absGoogleapis,
+		}, nil
+	}
+	dir := t.TempDir()
+	pkgDir := filepath.Join(dir, "packages", "google-cloud-secretmanager")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "package.json"), []byte(`{"name": "@google-cloud/secret-manager", "version": "6.1.1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	owlBot := "deep-copy-regex:\n  - source: /google/cloud/secretmanager/(v.*)/.*-nodejs\n"
+	if err := os.WriteFile(filepath.Join(pkgDir, ".OwlBot.yaml"), []byte(owlBot), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	if err := runNodejsMigration(t.Context(), "."); err != nil {
+		t.Fatal(err)
+	}
+	got, err := yaml.Read[config.Config](config.LibrarianYAML)
+	if err != nil {
+		t.Fatalf("reading generated librarian.yaml: %v", err)
+	}
+	want := &config.Config{
+		Language: config.LanguageNodejs,
+		Sources: &config.Sources{
+			Googleapis: &config.Source{
+				Commit: "abcd123",
+				SHA256: "sha123",
+			},
+		},
+		Default: &config.Default{
+			Output: "packages",
+		},
+		Libraries: []*config.Library{
+			{
+				Name:    "google-cloud-secretmanager",
+				Version: "6.1.1",
+				APIs: []*config.API{
+					{Path: "google/cloud/secretmanager/v1"},
+				},
+				Nodejs: &config.NodejsPackage{
+					PackageName: "@google-cloud/secret-manager",
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindNodejsLibraries(t *testing.T) {
+	googleapisDir := filepath.Join("testdata", "googleapis")
+	repoPath := filepath.Join("testdata", "google-cloud-node")
+	got, err := findNodejsLibraries(googleapisDir, repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*config.Library{
+		{
+			Name:    "google-cloud-billing-budgets",
+			Version: "6.1.0",
+			APIs: []*config.API{
+				{Path: "google/cloud/billing/budgets/v1"},
+				{Path: "google/cloud/billing/budgets/v1beta1"},
+			},
+		},
+		{
+			Name:    "google-cloud-compute",
+			Version: "6.5.0",
+			APIs: []*config.API{
+				{Path: "google/cloud/compute/v1"},
+				{Path: "google/cloud/compute/v1beta"},
+			},
+		},
+		{
+			Name:    "google-cloud-secretmanager",
+			Version: "6.1.1",
+			APIs: []*config.API{
+				{Path: "google/cloud/secretmanager/v1"},
+			},
+			Nodejs: &config.NodejsPackage{
+				PackageName: "@google-cloud/secret-manager",
+			},
+		},
+		{
+			Name:    "typeless-sample-bot",
+			Version: "3.1.0",
+			Output:  "packages/typeless-sample-bot",
+			Nodejs: &config.NodejsPackage{
+				PackageName: "@google-cloud/typeless-sample-bot",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindNodejsLibraries_Error(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "no packages directory",
+		},
+		{
+			name: "invalid package.json",
+			files: map[string]string{
+				"packages/google-cloud-batch/package.json": "{",
+			},
+		},
+		{
+			name: "missing API directory",
+			files: map[string]string{
+				"packages/google-cloud-batch/package.json": `{"name": "@google-cloud/batch"}`,
+				"packages/google-cloud-batch/.OwlBot.yaml": "deep-copy-regex:\n  - source: /google/cloud/batch/(v.*)/.*-nodejs\n",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			for name, content := range test.files {
+				path := filepath.Join(repoPath, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := findNodejsLibraries(t.TempDir(), repoPath); err == nil {
+				t.Error("findNodejsLibraries() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDefaultNodejsPackageName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want string
+	}{
+		{name: "google-cloud-batch", want: "@google-cloud/batch"},
+		{name: "google-cloud-billing-budgets", want: "@google-cloud/billing-budgets"},
+		{name: "google-maps-places", want: "@google-maps/places"},
+		{name: "gapic-node", want: "gapic-node"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := defaultNodejsPackageName(test.name)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
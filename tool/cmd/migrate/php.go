@@ -29,7 +29,6 @@ import (
 
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/googleapis/librarian/internal/config"
-	"github.com/googleapis/librarian/internal/librarian"
 	"github.com/googleapis/librarian/internal/yaml"
 )
 
@@ -76,8 +75,8 @@ func runPHPMigration(ctx context.Context, repoPath string) error {
 	// The directory name in Googleapis is present for migration code to look
 	// up API details. It shouldn't be persisted.
 	cfg.Sources.Googleapis.Dir = ""
-	if err := librarian.RunTidyOnConfig(ctx, repoPath, cfg); err != nil {
-		return fmt.Errorf("%w: %w", errTidyFailed, err)
+	if err := writeConfig(ctx, repoPath, cfg); err != nil {
+		return err
 	}
 	log.Printf("Successfully migrated %d PHP libraries configuration to librarian.yaml", len(cfg.Libraries))
 	return nil
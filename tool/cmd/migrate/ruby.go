@@ -27,7 +27,6 @@ import (
 	"strings"
 
 	"github.com/googleapis/librarian/internal/config"
-	"github.com/googleapis/librarian/internal/librarian"
 	"github.com/googleapis/librarian/internal/yaml"
 )
 
@@ -96,8 +95,8 @@ func runRubyMigration(ctx context.Context, repoPath string) error {
 	// The directory name in Googleapis is present for migration code to look
 	// up API details. It shouldn't be persisted.
 	cfg.Sources.Googleapis.Dir = ""
-	if err := librarian.RunTidyOnConfig(ctx, repoPath, cfg); err != nil {
-		return fmt.Errorf("%w: %w", errTidyFailed, err)
+	if err := writeConfig(ctx, repoPath, cfg); err != nil {
+		return err
 	}
 	log.Printf("Successfully migrated Ruby libraries configuration")
 	return nil